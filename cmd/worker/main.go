@@ -0,0 +1,20 @@
+package main
+
+import (
+	"oil/config"
+	"oil/di"
+	"oil/shared/logger"
+)
+
+// The worker runs the Kafka consumers without the HTTP server. Consumers can
+// also run inside the HTTP server by setting KAFKA_CONSUMER_ENABLE.
+func main() {
+	cfg := config.Get()
+
+	logger.InitLogger()
+
+	logger.SetLogLevel(cfg)
+
+	worker := di.InitializeWorker()
+	worker.Run()
+}
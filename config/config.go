@@ -37,7 +37,32 @@ type Config struct {
 			MaxRequests   int  `envconfig:"MAX_REQUESTS"`
 			WindowSeconds int  `envconfig:"WINDOW_SECONDS"`
 		} `envconfig:"RATE_LIMITER"`
-		APIKey string `envconfig:"API_KEY"`
+		APIKey       string `envconfig:"API_KEY"`
+		OpeningHours struct {
+			Open  string `envconfig:"OPEN"`
+			Close string `envconfig:"CLOSE"`
+		} `envconfig:"OPENING_HOURS"`
+		Retention struct {
+			Enable         bool `envconfig:"ENABLE"`
+			SoftDeleteDays int  `envconfig:"SOFT_DELETE_DAYS"`
+		} `envconfig:"RETENTION"`
+		Auth struct {
+			RequireVerifiedEmail  bool     `envconfig:"REQUIRE_VERIFIED_EMAIL"`
+			VerifyEmailURL        string   `envconfig:"VERIFY_EMAIL_URL"`
+			VerificationExpireMin int      `envconfig:"VERIFICATION_EXPIRE_MIN"`
+			ResetPasswordURL      string   `envconfig:"RESET_PASSWORD_URL"`
+			ResetExpireMin        int      `envconfig:"RESET_EXPIRE_MIN"`
+			MFARequiredRoles      []string `envconfig:"MFA_REQUIRED_ROLES"`
+			Lockout               struct {
+				Attempts   int `envconfig:"ATTEMPTS"`
+				IPAttempts int `envconfig:"IP_ATTEMPTS"`
+				Min        int `envconfig:"MIN"`
+				MaxMin     int `envconfig:"MAX_MIN"`
+			} `envconfig:"LOCKOUT"`
+		} `envconfig:"AUTH"`
+		// TrustedProxies are the addresses or CIDR ranges of the proxies in
+		// front of the app, whose forwarding headers name the caller.
+		TrustedProxies []string `envconfig:"TRUSTED_PROXIES"`
 	} `envconfig:"APP"`
 
 	Cache struct {
@@ -54,10 +79,17 @@ type Config struct {
 	} `envconfig:"CACHE"`
 
 	JWT struct {
-		AccessSecret     string `envconfig:"ACCESS_SECRET"`
-		RefreshSecret    string `envconfig:"REFRESH_SECRET"`
-		AccessExpireMin  int    `envconfig:"ACCESS_EXPIRE_MIN"`
-		RefreshExpireMin int    `envconfig:"REFRESH_EXPIRE_MIN"`
+		AccessSecret       string `envconfig:"ACCESS_SECRET"`
+		RefreshSecret      string `envconfig:"REFRESH_SECRET"`
+		AccessExpireMin    int    `envconfig:"ACCESS_EXPIRE_MIN"`
+		RefreshExpireMin   int    `envconfig:"REFRESH_EXPIRE_MIN"`
+		ChallengeExpireMin int    `envconfig:"CHALLENGE_EXPIRE_MIN"`
+		Keys               struct {
+			Dir        string `envconfig:"DIR"`
+			Algorithm  string `envconfig:"ALGORITHM"`
+			RotateDays int    `envconfig:"ROTATE_DAYS"`
+			OverlapMin int    `envconfig:"OVERLAP_MIN"`
+		} `envconfig:"KEYS"`
 	} `envconfig:"JWT"`
 
 	DB struct {
@@ -95,7 +127,23 @@ type Config struct {
 		} `envconfig:"SASL"`
 		Brokers       []string `envconfig:"BROKERS"`
 		ConsumerGroup string   `envconfig:"CONSUMER_GROUP"`
-		Topics        struct{} `envconfig:"TOPICS"`
+		Topics        struct {
+			BookingEvents string `envconfig:"BOOKING_EVENTS"`
+		} `envconfig:"TOPICS"`
+		Outbox struct {
+			Enable              bool `envconfig:"ENABLE"`
+			PollIntervalMs      int  `envconfig:"POLL_INTERVAL_MS"`
+			BatchSize           int  `envconfig:"BATCH_SIZE"`
+			MaxAttempts         int  `envconfig:"MAX_ATTEMPTS"`
+			RetryBackoffSeconds int  `envconfig:"RETRY_BACKOFF_SECONDS"`
+			RetentionHours      int  `envconfig:"RETENTION_HOURS"`
+		} `envconfig:"OUTBOX"`
+		Consumer struct {
+			Enable           bool   `envconfig:"ENABLE"`
+			MaxAttempts      int    `envconfig:"MAX_ATTEMPTS"`
+			RetryBackoffMs   int    `envconfig:"RETRY_BACKOFF_MS"`
+			DeadLetterSuffix string `envconfig:"DEAD_LETTER_SUFFIX"`
+		} `envconfig:"CONSUMER"`
 	} `envconfig:"KAFKA"`
 
 	External struct {
@@ -109,6 +157,21 @@ type Config struct {
 		Otel struct {
 			Endpoint string `envconfig:"ENDPOINT"`
 		} `envconfig:"OTEL"`
+		Mail struct {
+			Driver string `envconfig:"DRIVER"`
+			From   string `envconfig:"FROM"`
+			Dir    string `envconfig:"DIR"`
+			SMTP   struct {
+				Host     string `envconfig:"HOST"`
+				Port     string `envconfig:"PORT"`
+				Username string `envconfig:"USERNAME"`
+				Password string `envconfig:"PASSWORD"`
+			} `envconfig:"SMTP"`
+		} `envconfig:"MAIL"`
+		Google struct {
+			ClientID string `envconfig:"CLIENT_ID"`
+			Issuer   string `envconfig:"ISSUER"`
+		} `envconfig:"GOOGLE"`
 	} `envconfig:"EXTERNAL"`
 }
 
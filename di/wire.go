@@ -6,12 +6,18 @@ package di
 import (
 	"oil/config"
 	"oil/infras/jwt"
+	"oil/infras/kafka"
+	"oil/infras/mail"
+	"oil/infras/oidc"
 	"oil/infras/otel"
 	"oil/infras/postgres"
 	"oil/infras/redis"
 	"oil/infras/s3"
 	"oil/permissions"
 	"oil/shared/cache"
+	"oil/shared/lockout"
+	"oil/transport/consumer"
+	consumerRouter "oil/transport/consumer/router"
 	"oil/transport/http"
 	"oil/transport/http/middleware"
 	"oil/transport/http/router"
@@ -24,8 +30,21 @@ import (
 	bookingService "oil/internal/domains/booking/service"
 	bookingHandler "oil/internal/handlers/booking"
 
+	outboxRepository "oil/internal/domains/outbox/repository"
+	outboxService "oil/internal/domains/outbox/service"
+	outboxHandler "oil/internal/handlers/outbox"
+
+	retentionService "oil/internal/domains/retention/service"
+
+	auditRepository "oil/internal/domains/audit/repository"
+	auditService "oil/internal/domains/audit/service"
+	auditHandler "oil/internal/handlers/audit"
+
+	bookingConsumer "oil/internal/consumers/booking"
+
 	"github.com/google/wire"
 
+	authRepository "oil/internal/domains/auth/repository"
 	authService "oil/internal/domains/auth/service"
 	userRepository "oil/internal/domains/user/repository"
 	userService "oil/internal/domains/user/service"
@@ -40,11 +59,14 @@ var configurations = wire.NewSet(
 
 var infrastructures = wire.NewSet(
 	postgres.New,
+	postgres.NewTxManager,
 	otel.New,
 	redis.New,
 	s3.New,
 	jwt.New,
-	// kafka.New,
+	mail.New,
+	oidc.New,
+	kafka.New,
 )
 
 var middlewares = wire.NewSet(
@@ -54,6 +76,7 @@ var middlewares = wire.NewSet(
 
 var sharedHelpers = wire.NewSet(
 	cache.NewRedisCache,
+	lockout.New,
 )
 
 var roomDomain = wire.NewSet(
@@ -66,7 +89,25 @@ var bookingDomain = wire.NewSet(
 	bookingService.New,
 )
 
+var outboxDomain = wire.NewSet(
+	outboxRepository.New,
+	outboxService.New,
+)
+
+var retentionDomain = wire.NewSet(
+	retentionService.New,
+)
+
+var auditDomain = wire.NewSet(
+	auditRepository.New,
+	auditService.New,
+)
+
 var authDomain = wire.NewSet(
+	authRepository.NewEmailVerification,
+	authRepository.NewPasswordReset,
+	authRepository.NewMFA,
+	authRepository.NewRecoveryCode,
 	authService.New,
 )
 
@@ -82,6 +123,9 @@ var domains = wire.NewSet(
 	userDomain,
 	roomDomain,
 	bookingDomain,
+	outboxDomain,
+	retentionDomain,
+	auditDomain,
 )
 
 var routing = wire.NewSet(
@@ -90,9 +134,20 @@ var routing = wire.NewSet(
 	roomHandler.New,
 	bookingHandler.New,
 	userHandler.New,
+	outboxHandler.New,
+	auditHandler.New,
 	router.New,
 )
 
+var consumers = wire.NewSet(
+	wire.Struct(new(consumerRouter.DomainConsumers), "*"),
+	bookingConsumer.New,
+	consumerRouter.New,
+	wire.Bind(new(consumer.Registry), new(*consumerRouter.Router)),
+	consumer.NewReaderFactory,
+	consumer.New,
+)
+
 func InitializeService() *http.HTTP {
 	wire.Build(
 		configurations,
@@ -101,8 +156,20 @@ func InitializeService() *http.HTTP {
 		sharedHelpers,
 		domains,
 		routing,
+		consumers,
 		http.New,
 	)
 
 	return &http.HTTP{}
 }
+
+func InitializeWorker() *consumer.Worker {
+	wire.Build(
+		config.Get,
+		kafka.New,
+		consumers,
+		consumer.NewWorker,
+	)
+
+	return &consumer.Worker{}
+}
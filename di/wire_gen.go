@@ -10,21 +10,38 @@ import (
 	"github.com/google/wire"
 	"oil/config"
 	"oil/infras/jwt"
+	"oil/infras/kafka"
+	"oil/infras/mail"
+	"oil/infras/oidc"
 	"oil/infras/otel"
 	"oil/infras/postgres"
 	"oil/infras/redis"
 	"oil/infras/s3"
-	service2 "oil/internal/domains/auth/service"
-	repository3 "oil/internal/domains/gallery/repository"
-	service3 "oil/internal/domains/gallery/service"
-	"oil/internal/domains/todo/repository"
-	"oil/internal/domains/todo/service"
-	repository2 "oil/internal/domains/user/repository"
+	booking2 "oil/internal/consumers/booking"
+	repository6 "oil/internal/domains/audit/repository"
+	service6 "oil/internal/domains/audit/service"
+	repository2 "oil/internal/domains/auth/repository"
+	"oil/internal/domains/auth/service"
+	repository4 "oil/internal/domains/booking/repository"
+	service3 "oil/internal/domains/booking/service"
+	repository5 "oil/internal/domains/outbox/repository"
+	service5 "oil/internal/domains/outbox/service"
+	service7 "oil/internal/domains/retention/service"
+	repository3 "oil/internal/domains/room/repository"
+	service2 "oil/internal/domains/room/service"
+	"oil/internal/domains/user/repository"
+	service4 "oil/internal/domains/user/service"
+	"oil/internal/handlers/audit"
 	"oil/internal/handlers/auth"
-	"oil/internal/handlers/gallery"
-	"oil/internal/handlers/todo"
+	"oil/internal/handlers/booking"
+	"oil/internal/handlers/outbox"
+	"oil/internal/handlers/room"
+	"oil/internal/handlers/user"
 	"oil/permissions"
 	"oil/shared/cache"
+	"oil/shared/lockout"
+	"oil/transport/consumer"
+	router2 "oil/transport/consumer/router"
 	"oil/transport/http"
 	"oil/transport/http/middleware"
 	"oil/transport/http/router"
@@ -35,53 +52,109 @@ import (
 func InitializeService() *http.HTTP {
 	configConfig := config.Get()
 	connection := postgres.New(configConfig)
+	txManager := postgres.NewTxManager(connection)
 	otelOtel := otel.New(configConfig)
-	repositoryTodo := repository.New(connection, otelOtel)
+	repositoryUser := repository.New(connection, txManager, otelOtel)
+	emailVerification := repository2.NewEmailVerification(connection, txManager, otelOtel)
+	passwordReset := repository2.NewPasswordReset(connection, txManager, otelOtel)
+	mfa := repository2.NewMFA(connection, txManager, otelOtel)
+	recoveryCode := repository2.NewRecoveryCode(connection, txManager, otelOtel)
+	sender := mail.New(configConfig, otelOtel)
+	verifier := oidc.New(configConfig, otelOtel)
 	client := redis.New(configConfig)
 	redisCache := cache.NewRedisCache(client, otelOtel)
-	serviceTodo := service.New(repositoryTodo, configConfig, redisCache, otelOtel)
-	handler := todo.New(serviceTodo, otelOtel)
-	user := repository2.New(connection, otelOtel)
+	lockoutLockout := lockout.New(redisCache)
 	jwtJWT := jwt.New(configConfig, redisCache)
-	serviceAuth := service2.New(user, configConfig, otelOtel, jwtJWT)
-	authHandler := auth.New(serviceAuth, otelOtel)
-	repositoryGallery := repository3.New(connection, otelOtel)
+	serviceAuth := service.New(repositoryUser, emailVerification, passwordReset, mfa, recoveryCode, txManager, sender, verifier, lockoutLockout, configConfig, otelOtel, jwtJWT)
+	handler := auth.New(serviceAuth, otelOtel)
+	repositoryRoom := repository3.New(connection, txManager, otelOtel)
+	repositoryBooking := repository4.New(connection, txManager, otelOtel)
+	repositoryOutbox := repository5.New(connection, txManager, otelOtel)
 	s3S3 := s3.New(configConfig, otelOtel)
-	serviceGallery := service3.New(repositoryGallery, configConfig, redisCache, otelOtel, s3S3)
-	galleryHandler := gallery.New(serviceGallery, s3S3, otelOtel)
+	serviceRoom := service2.New(repositoryRoom, repositoryBooking, repositoryOutbox, txManager, configConfig, redisCache, otelOtel, s3S3)
+	roomHandler := room.New(serviceRoom, otelOtel)
+	serviceBooking := service3.New(repositoryBooking, repositoryRoom, repositoryUser, repositoryOutbox, txManager, configConfig, redisCache, otelOtel)
+	bookingHandler := booking.New(serviceBooking, otelOtel)
+	serviceUser := service4.New(repositoryUser, configConfig, redisCache, lockoutLockout, otelOtel)
+	userHandler := user.New(serviceUser, otelOtel)
+	kafkaClient := kafka.New(configConfig)
+	relay := service5.New(repositoryOutbox, kafkaClient, configConfig, otelOtel)
+	outboxHandler := outbox.New(relay, otelOtel)
+	auditLog := repository6.New(connection, txManager, otelOtel)
+	serviceAuditLog := service6.New(auditLog, configConfig, otelOtel)
+	auditHandler := audit.New(serviceAuditLog, otelOtel)
 	domainHandlers := router.DomainHandlers{
-		Todo:    handler,
-		Auth:    authHandler,
-		Gallery: galleryHandler,
+		Auth:    handler,
+		Room:    roomHandler,
+		Booking: bookingHandler,
+		User:    userHandler,
+		Outbox:  outboxHandler,
+		Audit:   auditHandler,
 	}
 	routerRouter := router.New(domainHandlers)
 	appMiddleware := middleware.NewAppMiddleware(otelOtel, configConfig, redisCache)
 	permissionData := permissions.Get()
 	authRole := middleware.NewAuthRoleMiddleware(jwtJWT, otelOtel, permissionData, configConfig)
-	httpHTTP := http.New(configConfig, routerRouter, connection, appMiddleware, authRole)
+	purger := service7.New(repositoryBooking, repositoryRoom, repositoryUser, configConfig, otelOtel)
+	readerFactory := consumer.NewReaderFactory(kafkaClient)
+	bookingConsumer := booking2.New(configConfig)
+	domainConsumers := router2.DomainConsumers{
+		Booking: bookingConsumer,
+	}
+	router3 := router2.New(domainConsumers)
+	runtime := consumer.New(kafkaClient, configConfig, readerFactory, router3)
+	httpHTTP := http.New(configConfig, routerRouter, connection, appMiddleware, authRole, relay, purger, jwtJWT, runtime)
 	return httpHTTP
 }
 
+func InitializeWorker() *consumer.Worker {
+	configConfig := config.Get()
+	client := kafka.New(configConfig)
+	readerFactory := consumer.NewReaderFactory(client)
+	bookingConsumer := booking2.New(configConfig)
+	domainConsumers := router2.DomainConsumers{
+		Booking: bookingConsumer,
+	}
+	routerRouter := router2.New(domainConsumers)
+	runtime := consumer.New(client, configConfig, readerFactory, routerRouter)
+	worker := consumer.NewWorker(configConfig, runtime)
+	return worker
+}
+
 // wire.go:
 
 var configurations = wire.NewSet(config.Get, permissions.Get)
 
-var infrastructures = wire.NewSet(postgres.New, otel.New, redis.New, s3.New, jwt.New)
+var infrastructures = wire.NewSet(postgres.New, postgres.NewTxManager, otel.New, redis.New, s3.New, jwt.New, mail.New, oidc.New, kafka.New)
 
 var middlewares = wire.NewSet(middleware.NewAppMiddleware, middleware.NewAuthRoleMiddleware)
 
-var sharedHelpers = wire.NewSet(cache.NewRedisCache)
+var sharedHelpers = wire.NewSet(cache.NewRedisCache, lockout.New)
 
-var todoDomain = wire.NewSet(repository.New, service.New)
+var roomDomain = wire.NewSet(repository3.New, service2.New)
 
-var authDomain = wire.NewSet(repository2.New, service2.New)
+var bookingDomain = wire.NewSet(repository4.New, service3.New)
 
-var galleryDomain = wire.NewSet(repository3.New, service3.New)
+var outboxDomain = wire.NewSet(repository5.New, service5.New)
+
+var retentionDomain = wire.NewSet(service7.New)
+
+var auditDomain = wire.NewSet(repository6.New, service6.New)
+
+var authDomain = wire.NewSet(repository2.NewEmailVerification, repository2.NewPasswordReset, repository2.NewMFA, repository2.NewRecoveryCode, service.New)
+
+var userDomain = wire.NewSet(repository.New, service4.New)
 
 var domains = wire.NewSet(
-	todoDomain,
 	authDomain,
-	galleryDomain,
+	userDomain,
+	roomDomain,
+	bookingDomain,
+	outboxDomain,
+	retentionDomain,
+	auditDomain,
 )
 
-var routing = wire.NewSet(wire.Struct(new(router.DomainHandlers), "*"), todo.New, auth.New, gallery.New, router.New)
+var routing = wire.NewSet(wire.Struct(new(router.DomainHandlers), "*"), auth.New, room.New, booking.New, user.New, outbox.New, audit.New, router.New)
+
+var consumers = wire.NewSet(wire.Struct(new(router2.DomainConsumers), "*"), booking2.New, router2.New, wire.Bind(new(consumer.Registry), new(*router2.Router)), consumer.NewReaderFactory, consumer.New)
@@ -4,17 +4,20 @@ package jwt
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
+	"math"
 	"oil/config"
 	"oil/shared"
 	"oil/shared/cache"
 	"oil/shared/constant"
 	"oil/shared/timezone"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 var (
@@ -29,6 +32,13 @@ var (
 	ErrTokenSigningFailed      = errors.New("failed to sign token")
 	ErrTokenGenerationFailed   = errors.New("failed to generate token")
 	ErrCacheOperationFailed    = errors.New("cache operation failed")
+	ErrRevokedToken            = errors.New("token has been revoked")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrTokenReused             = errors.New("refresh token has already been used")
+
+	// errSessionChanged is returned when a session was saved by someone else
+	// between reading and saving it.
+	errSessionChanged = errors.New("session changed while it was being saved")
 )
 
 type TokenType string
@@ -36,9 +46,30 @@ type TokenType string
 const (
 	AccessToken             TokenType = "access"
 	RefreshToken            TokenType = "refresh"
+	ChallengeToken          TokenType = "mfa_challenge"
 	cacheJwtUserPrefix      string    = "jwt:user"
 	cacheJwtBlacklistPrefix string    = "jwt:blacklist"
 	cacheJwtRevokedValue    string    = "revoked"
+
+	defaultChallengeExpireMin = 5
+	defaultKeyOverlapMin      = 60
+
+	keyRotateInterval = time.Hour
+	headerKeyID       = "kid"
+	headerType        = "typ"
+
+	// legacyHeaderType is the typ header of the tokens signed with the secrets
+	// before the token types had their own.
+	legacyHeaderType = "JWT"
+)
+
+// The typ headers of the token types, which tell them apart to services that
+// verify our tokens with the published keys alone: they must require
+// HeaderTypeAccess, as only access tokens carry it.
+const (
+	HeaderTypeAccess    = "at+jwt"
+	HeaderTypeRefresh   = "refresh+jwt"
+	HeaderTypeChallenge = "mfa-challenge+jwt"
 )
 
 type Claims struct {
@@ -48,59 +79,136 @@ type Claims struct {
 	TokenID  string    `json:"token_id"`
 	Type     TokenType `json:"type"`
 	IssuedAt time.Time `json:"iat"`
+	// MFA reports whether the user passed multi-factor authentication to get
+	// the token. It is carried over when the tokens are refreshed.
+	MFA bool `json:"mfa,omitempty"`
+	// SessionID is the session the token belongs to. The tokens of a session
+	// are valid for as long as the session lasts.
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Session is a device signed in by a user: the login that issued a token pair
+// and the refreshes that followed it. It is the family of the refresh tokens,
+// of which only the last one issued may be used.
+type Session struct {
+	ID             string    `json:"id"`
+	UserAgent      string    `json:"user_agent"`
+	IP             string    `json:"ip"`
+	IssuedAt       time.Time `json:"issued_at"`
+	RefreshedAt    time.Time `json:"refreshed_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	RefreshTokenID string    `json:"refresh_token_id"`
+	// ReusedAt is when a refresh token of the session was used a second time,
+	// which ended the session. It is kept until it expires to show the user.
+	ReusedAt *time.Time `json:"reused_at,omitempty"`
+	// stored is the session as read from the cache, which a refresh replaces
+	// only if no other refresh replaced it first. It is empty for a new one.
+	stored string
+}
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
 type JWT interface {
-	GenerateTokenPair(ctx context.Context, userID, email, role string) (*TokenPair, error)
+	GenerateTokenPair(ctx context.Context, userID, email, role string, mfa bool) (*TokenPair, error)
+	GenerateChallengeToken(ctx context.Context, userID, email, role string) (string, error)
 	ValidateToken(ctx context.Context, tokenString string, tokenType TokenType) (*Claims, error)
 	RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error)
 	RevokeToken(ctx context.Context, tokenString string, tokenType TokenType) error
+	RedeemToken(ctx context.Context, tokenString string, tokenType TokenType) error
 	RevokeAllUserTokens(ctx context.Context, userID string) error
 	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+	Sessions(ctx context.Context, userID string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	JWKS() JWKS
+	RotateKeys(ctx context.Context)
 }
 
 type Service struct {
 	config *config.Config
 	cache  cache.RedisCache
+	keys   *keyRing
 }
 
-// New creates a new JWT service with Redis integration
+// New creates a new JWT service with Redis integration. With a key directory
+// configured, tokens are signed with the RS256 or EdDSA keys in it instead of
+// the shared secrets, which then only verify the tokens issued before.
 func New(cfg *config.Config, redisCache cache.RedisCache) JWT {
-	return &Service{
+	s := &Service{
 		config: cfg,
 		cache:  redisCache,
 	}
+
+	if cfg.JWT.Keys.Dir != "" {
+		keys, err := newKeyRing(cfg.JWT.Keys.Dir, cfg.JWT.Keys.Algorithm, s.keyRotation(), s.keyOverlap(), s.keyLifetime(), timezone.Now())
+		if err != nil {
+			log.Fatal().Err(err).Str("dir", cfg.JWT.Keys.Dir).Msg("failed to load JWT signing keys")
+		}
+
+		s.keys = keys
+	}
+
+	return s
 }
 
-// GenerateTokenPair generates both access and refresh tokens with Redis tracking
-func (s *Service) GenerateTokenPair(ctx context.Context, userID, email, role string) (*TokenPair, error) {
+// GenerateTokenPair starts a session for the device the request comes from and
+// generates both access and refresh tokens of it
+func (s *Service) GenerateTokenPair(ctx context.Context, userID, email, role string, mfa bool) (*TokenPair, error) {
 	now := timezone.Now()
+	session := Session{
+		ID:       uuid.New().String(),
+		IssuedAt: now,
+	}
+
+	return s.issueTokenPair(ctx, Claims{UserID: userID, Email: email, Role: role, MFA: mfa}, session, now)
+}
+
+// issueTokenPair generates the tokens of a session and saves the session, which
+// lasts as long as its refresh token
+func (s *Service) issueTokenPair(ctx context.Context, claims Claims, session Session, now time.Time) (*TokenPair, error) {
+	claims.SessionID = session.ID
 
 	// Generate access token
-	accessToken, accessTokenID, err := s.generateToken(userID, email, role, AccessToken, now, s.config.JWT.AccessExpireMin)
+	claims.Type = AccessToken
+
+	accessToken, _, err := s.generateToken(claims, now, s.config.JWT.AccessExpireMin)
 	if err != nil {
 		return nil, ErrTokenGenerationFailed
 	}
 
 	// Generate refresh token
-	refreshToken, refreshTokenID, err := s.generateToken(userID, email, role, RefreshToken, now, s.config.JWT.RefreshExpireMin)
+	claims.Type = RefreshToken
+
+	refreshToken, refreshTokenID, err := s.generateToken(claims, now, s.config.JWT.RefreshExpireMin)
 	if err != nil {
 		return nil, ErrTokenGenerationFailed
 	}
 
-	// Store token metadata in Redis
-	if err := s.storeTokenMetadata(ctx, userID, accessTokenID, AccessToken, s.config.JWT.AccessExpireMin*constant.MinutesToSeconds); err != nil {
-		return nil, ErrCacheOperationFailed
-	}
+	session.UserAgent, _ = ctx.Value(constant.ContextKeyUserAgent).(string)
+	session.IP, _ = ctx.Value(constant.ContextKeyClientIP).(string)
+	session.RefreshedAt = now
+	session.RefreshTokenID = refreshTokenID
+	session.ExpiresAt = now.Add(time.Duration(s.config.JWT.RefreshExpireMin) * time.Minute)
 
-	if err := s.storeTokenMetadata(ctx, userID, refreshTokenID, RefreshToken, s.config.JWT.RefreshExpireMin*constant.MinutesToSeconds); err != nil {
-		return nil, ErrCacheOperationFailed
+	sessionKey := shared.BuildCacheKey(cacheJwtUserPrefix, claims.UserID, session.ID)
+	expireSec := s.config.JWT.RefreshExpireMin * constant.MinutesToSeconds
+
+	if session.stored == "" {
+		if err := s.cache.Save(ctx, sessionKey, session, expireSec); err != nil {
+			return nil, ErrCacheOperationFailed
+		}
+	} else {
+		saved, err := s.cache.CompareAndSave(ctx, sessionKey, session.stored, session, expireSec)
+		if err != nil {
+			return nil, ErrCacheOperationFailed
+		}
+
+		if !saved {
+			return nil, errSessionChanged
+		}
 	}
 
 	return &TokenPair{
@@ -109,41 +217,66 @@ func (s *Service) GenerateTokenPair(ctx context.Context, userID, email, role str
 	}, nil
 }
 
-// generateToken creates a JWT token with the specified parameters
-func (s *Service) generateToken(userID, email, role string, tokenType TokenType, issuedAt time.Time, expireMin int) (string, string, error) {
+// GenerateChallengeToken generates the short-lived token of a user who passed
+// the first login step and still has to pass multi-factor authentication
+func (s *Service) GenerateChallengeToken(_ context.Context, userID, email, role string) (string, error) {
+	expireMin := s.config.JWT.ChallengeExpireMin
+	if expireMin <= 0 {
+		expireMin = defaultChallengeExpireMin
+	}
+
+	claims := Claims{UserID: userID, Email: email, Role: role, Type: ChallengeToken}
+
+	challengeToken, _, err := s.generateToken(claims, timezone.Now(), expireMin)
+	if err != nil {
+		return "", ErrTokenGenerationFailed
+	}
+
+	return challengeToken, nil
+}
+
+// generateToken creates a JWT token of the user, type and session in claims
+func (s *Service) generateToken(claims Claims, issuedAt time.Time, expireMin int) (string, string, error) {
 	expiresAt := issuedAt.Add(time.Duration(expireMin) * time.Minute)
 	tokenID := uuid.New().String()
 
-	claims := Claims{
-		UserID:   userID,
-		Email:    email,
-		Role:     role,
-		TokenID:  tokenID,
-		Type:     tokenType,
-		IssuedAt: issuedAt,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expiresAt),
-			IssuedAt:  jwt.NewNumericDate(issuedAt),
-			NotBefore: jwt.NewNumericDate(issuedAt),
-			Issuer:    s.config.App.Name,
-			Subject:   userID,
-			ID:        tokenID,
-		},
+	claims.TokenID = tokenID
+	claims.IssuedAt = issuedAt
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(issuedAt),
+		NotBefore: jwt.NewNumericDate(issuedAt),
+		Issuer:    s.config.App.Name,
+		Subject:   claims.UserID,
+		ID:        tokenID,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	secret, typ, err := s.tokenSecret(claims.Type)
+	if err != nil {
+		return "", "", err
+	}
 
-	var secret string
+	if s.keys != nil {
+		key, err := s.keys.signer(issuedAt)
+		if err != nil {
+			return "", "", ErrTokenSigningFailed
+		}
 
-	switch tokenType {
-	case AccessToken:
-		secret = s.config.JWT.AccessSecret
-	case RefreshToken:
-		secret = s.config.JWT.RefreshSecret
-	default:
-		return "", "", ErrUnknownTokenType
+		token := jwt.NewWithClaims(key.method, claims)
+		token.Header[headerKeyID] = key.id
+		token.Header[headerType] = typ
+
+		signedToken, err := token.SignedString(key.private)
+		if err != nil {
+			return "", "", ErrTokenSigningFailed
+		}
+
+		return signedToken, tokenID, nil
 	}
 
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header[headerType] = typ
+
 	signedToken, err := token.SignedString([]byte(secret))
 	if err != nil {
 		return "", "", ErrTokenSigningFailed
@@ -152,25 +285,77 @@ func (s *Service) generateToken(userID, email, role string, tokenType TokenType,
 	return signedToken, tokenID, nil
 }
 
-// ValidateToken validates and parses a JWT token with Redis blacklist check
-func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenType TokenType) (*Claims, error) {
-	var secret string
-
+// tokenSecret returns the secret and the typ header of a token type
+func (s *Service) tokenSecret(tokenType TokenType) (string, string, error) {
 	switch tokenType {
 	case AccessToken:
-		secret = s.config.JWT.AccessSecret
+		return s.config.JWT.AccessSecret, HeaderTypeAccess, nil
+	case ChallengeToken:
+		return s.config.JWT.AccessSecret, HeaderTypeChallenge, nil
 	case RefreshToken:
-		secret = s.config.JWT.RefreshSecret
+		return s.config.JWT.RefreshSecret, HeaderTypeRefresh, nil
 	default:
-		return nil, ErrUnknownTokenType
+		return "", "", ErrUnknownTokenType
+	}
+}
+
+// ValidateToken validates and parses a JWT token, and checks in Redis that it
+// was not revoked and that its session was not ended
+func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenType TokenType) (*Claims, error) {
+	claims, err := s.parseToken(tokenString, tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if token is revoked in Redis
+	revoked, err := s.IsTokenRevoked(ctx, claims.TokenID)
+	if err != nil {
+		return nil, ErrCacheOperationFailed
+	}
+
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+
+	// Challenge tokens are issued before a session starts
+	if tokenType == ChallengeToken {
+		return claims, nil
+	}
+
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh tokens are single-use, the last one issued replaces the others
+	if tokenType == RefreshToken && claims.TokenID != session.RefreshTokenID {
+		return nil, ErrRevokedToken
+	}
+
+	return claims, nil
+}
+
+// parseToken verifies the signature, expiry and type of a JWT token
+func (s *Service) parseToken(tokenString string, tokenType TokenType) (*Claims, error) {
+	secret, typ, err := s.tokenSecret(tokenType)
+	if err != nil {
+		return nil, err
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			// Without a secret, only the signing keys are trusted
+			if secret == "" {
+				return nil, ErrUnexpectedSigningMethod
+			}
+
+			return []byte(secret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodEd25519:
+			return s.verificationKey(token)
+		default:
 			return nil, ErrUnexpectedSigningMethod
 		}
-
-		return []byte(secret), nil
 	})
 
 	if err != nil {
@@ -191,33 +376,89 @@ func (s *Service) ValidateToken(ctx context.Context, tokenString string, tokenTy
 		return nil, ErrInvalidClaim
 	}
 
-	// Check if token is revoked in Redis
-	revoked, err := s.IsTokenRevoked(ctx, claims.TokenID)
+	// Tokens signed with the secrets before the typ headers are told apart by
+	// their claim alone, as the secrets are not published.
+	if header, _ := token.Header[headerType].(string); header != typ {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || header != legacyHeaderType {
+			return nil, ErrInvalidClaim
+		}
+	}
+
+	return claims, nil
+}
+
+// verificationKey returns the public key of the signing key a token names
+func (s *Service) verificationKey(token *jwt.Token) (interface{}, error) {
+	if s.keys == nil {
+		return nil, ErrUnexpectedSigningMethod
+	}
+
+	keyID, _ := token.Header[headerKeyID].(string)
+
+	key, err := s.keys.key(keyID, timezone.Now())
 	if err != nil {
-		return nil, ErrCacheOperationFailed
+		return nil, err
 	}
 
-	if revoked {
-		return nil, ErrInvalidToken
+	if key.method.Alg() != token.Method.Alg() {
+		return nil, ErrUnexpectedSigningMethod
 	}
 
-	return claims, nil
+	return key.private.Public(), nil
 }
 
-// RefreshTokens generates new token pair using refresh token
+// RefreshTokens rotates the tokens of a session: the refresh token is exchanged
+// for a new pair and cannot be used again. Using it again means that it was
+// stolen, either by whoever used it first or by whoever uses it now, so the
+// session is ended and all of its tokens are revoked.
 func (s *Service) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
-	claims, err := s.ValidateToken(ctx, refreshToken, RefreshToken)
+	claims, err := s.parseToken(refreshToken, RefreshToken)
 	if err != nil {
 		return nil, err
 	}
 
-	// Revoke the old refresh token
-	if err := s.RevokeToken(ctx, refreshToken, RefreshToken); err != nil {
-		return nil, ErrCacheOperationFailed
+	session, err := s.activeSession(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenID != session.RefreshTokenID {
+		return nil, s.endReusedSession(ctx, claims, session)
 	}
 
-	// Generate new token pair
-	return s.GenerateTokenPair(ctx, claims.UserID, claims.Email, claims.Role)
+	// Generate new token pair in the same session. Of concurrent refreshes with
+	// the same token only the first to save the session gets it, the others
+	// used the token again.
+	tokenPair, err := s.issueTokenPair(ctx, Claims{UserID: claims.UserID, Email: claims.Email, Role: claims.Role, MFA: claims.MFA}, session, timezone.Now())
+	if errors.Is(err, errSessionChanged) {
+		return nil, s.endReusedSession(ctx, claims, session)
+	}
+
+	return tokenPair, err
+}
+
+// endReusedSession ends a session whose refresh token was used again. The
+// session is kept, marked, until it would have expired, so that the user sees
+// it in their sessions.
+func (s *Service) endReusedSession(ctx context.Context, claims *Claims, session Session) error {
+	log.Warn().
+		Str("user_id", claims.UserID).
+		Str("session_id", session.ID).
+		Str("token_id", claims.TokenID).
+		Msg("refresh token reused, revoking its session")
+
+	now := timezone.Now()
+	session.ReusedAt = &now
+
+	remaining := session.ExpiresAt.Sub(now)
+	if remaining > 0 {
+		sessionKey := shared.BuildCacheKey(cacheJwtUserPrefix, claims.UserID, session.ID)
+		if err := s.cache.Save(ctx, sessionKey, session, int(remaining.Seconds())); err != nil {
+			return ErrCacheOperationFailed
+		}
+	}
+
+	return ErrTokenReused
 }
 
 // ExtractTokenFromHeader extracts JWT token from Authorization header
@@ -234,19 +475,6 @@ func ExtractTokenFromHeader(authHeader string) (string, error) {
 	return authHeader[len(prefix):], nil
 }
 
-// storeTokenMetadata stores token metadata in Redis
-func (s *Service) storeTokenMetadata(ctx context.Context, userID, tokenID string, tokenType TokenType, expireSeconds int) error {
-	// Store in user's token list using BuildCacheKey
-	cacheKey := shared.BuildCacheKey(cacheJwtUserPrefix, userID, tokenID)
-	tokenData := fmt.Sprintf("%s:%s", tokenID, tokenType)
-
-	if err := s.cache.Save(ctx, cacheKey, tokenData, expireSeconds); err != nil {
-		return ErrCacheOperationFailed
-	}
-
-	return nil
-}
-
 // RevokeToken revokes a specific token by adding it to blacklist
 func (s *Service) RevokeToken(ctx context.Context, tokenString string, _ TokenType) error {
 	// Parse token to get claims without validation
@@ -270,18 +498,46 @@ func (s *Service) RevokeToken(ctx context.Context, tokenString string, _ TokenTy
 		}
 	}
 
-	// Remove from user's active tokens using BuildCacheKey
-	userTokenKey := shared.BuildCacheKey(cacheJwtUserPrefix, claims.UserID, claims.TokenID)
-	if err := s.cache.Delete(ctx, userTokenKey); err != nil {
+	return nil
+}
+
+// RedeemToken revokes a single-use token, failing with ErrRevokedToken when it
+// was revoked already, so that of concurrent redemptions only one goes through.
+func (s *Service) RedeemToken(ctx context.Context, tokenString string, _ TokenType) error {
+	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return ErrTokenParsingFailed
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	remaining := time.Until(claims.ExpiresAt.Time)
+	if remaining <= 0 {
+		return ErrExpiredToken
+	}
+
+	blacklistKey := shared.BuildCacheKey(cacheJwtBlacklistPrefix, claims.TokenID)
+
+	// Rounded up, since an expiry of 0 would keep the revocation forever.
+	revoked, err := s.cache.SaveIfAbsent(ctx, blacklistKey, cacheJwtRevokedValue, int(math.Ceil(remaining.Seconds())))
+	if err != nil {
 		return ErrCacheOperationFailed
 	}
 
+	if !revoked {
+		return ErrRevokedToken
+	}
+
 	return nil
 }
 
-// RevokeAllUserTokens revokes all tokens for a specific user
+// RevokeAllUserTokens revokes all tokens for a specific user by ending all of
+// their sessions
 func (s *Service) RevokeAllUserTokens(ctx context.Context, userID string) error {
-	// Clear all user tokens using BuildCacheKey pattern
+	// Clear all user sessions using BuildCacheKey pattern
 	userTokensPattern := shared.BuildCacheKey(cacheJwtUserPrefix, userID, "*")
 	if err := s.cache.Clear(ctx, userTokensPattern); err != nil {
 		return ErrCacheOperationFailed
@@ -307,3 +563,156 @@ func (s *Service) IsTokenRevoked(ctx context.Context, tokenID string) (bool, err
 
 	return true, nil
 }
+
+// Sessions returns the sessions of a user, the most recently used first. They
+// include the sessions ended because a refresh token was reused.
+func (s *Service) Sessions(ctx context.Context, userID string) ([]Session, error) {
+	keys, err := s.cache.Keys(ctx, shared.BuildCacheKey(cacheJwtUserPrefix, userID, constant.Asterix))
+	if err != nil {
+		return nil, ErrCacheOperationFailed
+	}
+
+	sessions := make([]Session, 0, len(keys))
+
+	for _, key := range keys {
+		var session Session
+
+		if err := s.cache.Get(ctx, key, &session); err != nil {
+			// The session ended after the keys were listed
+			if errors.Is(err, cache.Nil) {
+				continue
+			}
+
+			return nil, ErrCacheOperationFailed
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	slices.SortFunc(sessions, func(a, b Session) int {
+		return b.RefreshedAt.Compare(a.RefreshedAt)
+	})
+
+	return sessions, nil
+}
+
+// RevokeSession ends a session of a user, which revokes all of its tokens
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := s.session(ctx, userID, sessionID); err != nil {
+		return err
+	}
+
+	if err := s.cache.Delete(ctx, shared.BuildCacheKey(cacheJwtUserPrefix, userID, sessionID)); err != nil {
+		return ErrCacheOperationFailed
+	}
+
+	return nil
+}
+
+// activeSession returns the session of a token, which must not have ended
+func (s *Service) activeSession(ctx context.Context, claims *Claims) (Session, error) {
+	session, err := s.session(ctx, claims.UserID, claims.SessionID)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return session, ErrRevokedToken
+		}
+
+		return session, err
+	}
+
+	if session.ReusedAt != nil {
+		return session, ErrRevokedToken
+	}
+
+	return session, nil
+}
+
+// session returns a session of a user, which may have ended for reuse
+func (s *Service) session(ctx context.Context, userID, sessionID string) (Session, error) {
+	var session Session
+
+	if sessionID == "" {
+		return session, ErrSessionNotFound
+	}
+
+	var stored string
+
+	if err := s.cache.Get(ctx, shared.BuildCacheKey(cacheJwtUserPrefix, userID, sessionID), &stored); err != nil {
+		if errors.Is(err, cache.Nil) {
+			return session, ErrSessionNotFound
+		}
+
+		return session, ErrCacheOperationFailed
+	}
+
+	if err := json.Unmarshal([]byte(stored), &session); err != nil {
+		return session, ErrCacheOperationFailed
+	}
+
+	session.stored = stored
+
+	return session, nil
+}
+
+// JWKS returns the public keys that verify the tokens, for other services to
+// verify them without a secret. It is empty when tokens are signed with the
+// shared secrets.
+func (s *Service) JWKS() JWKS {
+	if s.keys == nil {
+		return JWKS{Keys: []JWK{}}
+	}
+
+	return s.keys.jwks()
+}
+
+// RotateKeys reads the key directory every keyRotateInterval until ctx is
+// cancelled, picking up the keys of other instances, and generates and removes
+// keys as they are due when rotation is on.
+func (s *Service) RotateKeys(ctx context.Context) {
+	if s.keys == nil {
+		return
+	}
+
+	ticker := time.NewTicker(keyRotateInterval)
+	defer ticker.Stop()
+
+	log.Info().Dur("rotation", s.keyRotation()).Dur("overlap", s.keyOverlap()).Msg("JWT key rotation started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("JWT key rotation stopped")
+
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.keys.rotate(timezone.Now()); err != nil {
+			log.Error().Err(err).Msg("failed to rotate JWT signing keys")
+		}
+	}
+}
+
+// keyRotation returns how long a key signs before the next one is generated,
+// zero when keys are not rotated
+func (s *Service) keyRotation() time.Duration {
+	return time.Duration(max(s.config.JWT.Keys.RotateDays, 0)) * 24 * time.Hour
+}
+
+// keyOverlap returns how long a new key is published before it signs
+func (s *Service) keyOverlap() time.Duration {
+	overlapMin := s.config.JWT.Keys.OverlapMin
+	if overlapMin <= 0 {
+		overlapMin = defaultKeyOverlapMin
+	}
+
+	return time.Duration(overlapMin) * time.Minute
+}
+
+// keyLifetime returns how long the tokens signed by a key remain valid after it
+// stopped signing
+func (s *Service) keyLifetime() time.Duration {
+	expireMin := max(s.config.JWT.AccessExpireMin, s.config.JWT.RefreshExpireMin, s.config.JWT.ChallengeExpireMin, defaultChallengeExpireMin)
+
+	return time.Duration(expireMin) * time.Minute
+}
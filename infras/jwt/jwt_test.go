@@ -0,0 +1,752 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"oil/config"
+	"oil/infras/jwt"
+	"oil/infras/otel/mocks"
+	"oil/shared/cache"
+	"oil/shared/constant"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	gojwt "github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryCache keeps the cache in a map, as Redis would without expiry.
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}}
+}
+
+func (c *memoryCache) Save(_ context.Context, key string, value any, _ int) error {
+	data, err := encode(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = data
+
+	return nil
+}
+
+func (c *memoryCache) SaveIfAbsent(_ context.Context, key string, value any, _ int) (bool, error) {
+	data, err := encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+
+	c.values[key] = data
+
+	return true, nil
+}
+
+func (c *memoryCache) CompareAndSave(_ context.Context, key, old string, value any, _ int) (bool, error) {
+	data, err := encode(value)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored, ok := c.values[key]; !ok || string(stored) != old {
+		return false, nil
+	}
+
+	c.values[key] = data
+
+	return true, nil
+}
+
+// encode stores strings as they are and other values as JSON, as Redis does.
+func encode(value any) ([]byte, error) {
+	if s, ok := value.(string); ok {
+		return []byte(s), nil
+	}
+
+	return json.Marshal(value)
+}
+
+func (c *memoryCache) Get(_ context.Context, key string, value any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.values[key]
+	if !ok {
+		return cache.Nil
+	}
+
+	if s, ok := value.(*string); ok {
+		*s = string(data)
+
+		return nil
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+
+	return nil
+}
+
+func (c *memoryCache) Clear(ctx context.Context, pattern string) error {
+	keys, _ := c.Keys(ctx, pattern)
+	for _, key := range keys {
+		_ = c.Delete(ctx, key)
+	}
+
+	return nil
+}
+
+func (c *memoryCache) Keys(_ context.Context, pattern string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+
+	for key := range c.values {
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (c *memoryCache) Incr(_ context.Context, key string, _ int) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, _ := strconv.ParseInt(string(c.values[key]), 10, 64)
+	value++
+	c.values[key] = []byte(strconv.FormatInt(value, 10))
+
+	return value, nil
+}
+
+func newConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.App.Name = "oil"
+	cfg.JWT.AccessSecret = "access-secret"
+	cfg.JWT.RefreshSecret = "refresh-secret"
+	cfg.JWT.AccessExpireMin = 15
+	cfg.JWT.RefreshExpireMin = 60
+
+	return cfg
+}
+
+func newService() jwt.JWT {
+	return jwt.New(newConfig(), newMemoryCache())
+}
+
+// newKeyConfig returns a config signing with the keys of dir, rotated monthly.
+func newKeyConfig(dir, algorithm string) *config.Config {
+	cfg := newConfig()
+	cfg.JWT.Keys.Dir = dir
+	cfg.JWT.Keys.Algorithm = algorithm
+	cfg.JWT.Keys.RotateDays = 30
+	cfg.JWT.Keys.OverlapMin = 60
+
+	return cfg
+}
+
+// writeKey writes a PKCS8 private key to dir as rotation would, created at
+// created.
+func writeKey(t *testing.T, dir, id string, key any, created time.Time) {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{"Created": created.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	}
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, id+".pem"), pem.EncodeToMemory(block), 0o600))
+}
+
+// tokenKeyID returns the kid header of a token.
+func tokenKeyID(t *testing.T, tokenString string) string {
+	t.Helper()
+
+	token, _, err := gojwt.NewParser().ParseUnverified(tokenString, &jwt.Claims{})
+	assert.NoError(t, err)
+
+	keyID, _ := token.Header["kid"].(string)
+
+	return keyID
+}
+
+// publicKey decodes the public key of a JWK, as a service verifying our
+// tokens would.
+func publicKey(t *testing.T, jwk jwt.JWK) any {
+	t.Helper()
+
+	decode := func(s string) []byte {
+		data, err := base64.RawURLEncoding.DecodeString(s)
+		assert.NoError(t, err)
+
+		return data
+	}
+
+	if jwk.Kty == "OKP" {
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(decode(jwk.N)),
+		E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64()),
+	}
+}
+
+func TestService_Sessions(t *testing.T) {
+	svc := newService()
+
+	ctx := context.WithValue(context.Background(), constant.ContextKeyUserAgent, "Firefox")
+	ctx = context.WithValue(ctx, constant.ContextKeyClientIP, "10.0.0.1")
+
+	first, err := svc.GenerateTokenPair(ctx, "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	_, err = svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), first.AccessToken, jwt.AccessToken)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, claims.SessionID)
+
+	sessions, err := svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	for _, session := range sessions {
+		if session.ID == claims.SessionID {
+			assert.Equal(t, "Firefox", session.UserAgent)
+			assert.Equal(t, "10.0.0.1", session.IP)
+		}
+	}
+
+	assert.NoError(t, svc.RevokeSession(context.Background(), "user-1", claims.SessionID))
+	assert.ErrorIs(t, svc.RevokeSession(context.Background(), "user-1", claims.SessionID), jwt.ErrSessionNotFound)
+
+	_, err = svc.ValidateToken(context.Background(), first.AccessToken, jwt.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+
+	_, err = svc.RefreshTokens(context.Background(), first.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+
+	sessions, err = svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	assert.NoError(t, svc.RevokeAllUserTokens(context.Background(), "user-1"))
+
+	sessions, err = svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestService_RefreshTokens(t *testing.T) {
+	svc := newService()
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, true)
+	assert.NoError(t, err)
+
+	rotated, err := svc.RefreshTokens(context.Background(), pair.RefreshToken)
+	assert.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), rotated.AccessToken, jwt.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, claims.MFA)
+
+	// The old refresh token was used once, so it is no longer valid.
+	_, err = svc.ValidateToken(context.Background(), pair.RefreshToken, jwt.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+
+	_, err = svc.RefreshTokens(context.Background(), rotated.RefreshToken)
+	assert.NoError(t, err)
+
+	sessions, err := svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, claims.SessionID, sessions[0].ID)
+		assert.Nil(t, sessions[0].ReusedAt)
+	}
+}
+
+func TestService_RefreshTokens_Reused(t *testing.T) {
+	svc := newService()
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	rotated, err := svc.RefreshTokens(context.Background(), pair.RefreshToken)
+	assert.NoError(t, err)
+
+	_, err = svc.RefreshTokens(context.Background(), pair.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrTokenReused)
+
+	// The whole family is revoked, including the tokens of the last rotation.
+	_, err = svc.ValidateToken(context.Background(), rotated.AccessToken, jwt.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+
+	_, err = svc.RefreshTokens(context.Background(), rotated.RefreshToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+
+	sessions, err := svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+
+	if assert.Len(t, sessions, 1) {
+		assert.NotNil(t, sessions[0].ReusedAt)
+	}
+}
+
+// barrierCache holds the first reads of the cache until there have been as
+// many as its barrier waits for, so that concurrent callers all read before any
+// of them writes.
+type barrierCache struct {
+	cache.RedisCache
+	barrier *sync.WaitGroup
+	reads   atomic.Int32
+	limit   int32
+}
+
+func (c *barrierCache) Get(ctx context.Context, key string, value any) error {
+	err := c.RedisCache.Get(ctx, key, value)
+
+	if c.reads.Add(1) <= c.limit {
+		c.barrier.Done()
+		c.barrier.Wait()
+	}
+
+	return err
+}
+
+func TestService_RefreshTokens_Concurrent(t *testing.T) {
+	const refreshes = 10
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	t.Cleanup(func() { client.Close() })
+
+	barrier := &sync.WaitGroup{}
+	barrier.Add(refreshes)
+
+	svc := jwt.New(newConfig(), &barrierCache{
+		RedisCache: cache.NewRedisCache(client, mocks.NewOtel()),
+		barrier:    barrier,
+		limit:      refreshes,
+	})
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		refreshed atomic.Int32
+	)
+
+	// Every refresh reads the session before any of them saves it.
+	for range refreshes {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := svc.RefreshTokens(context.Background(), pair.RefreshToken)
+			if err == nil {
+				refreshed.Add(1)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, jwt.ErrTokenReused)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), refreshed.Load())
+
+	sessions, err := svc.Sessions(context.Background(), "user-1")
+	assert.NoError(t, err)
+
+	if assert.Len(t, sessions, 1) {
+		assert.NotNil(t, sessions[0].ReusedAt)
+	}
+}
+
+func TestService_SigningKeys(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		kty       string
+	}{
+		{algorithm: jwt.AlgorithmRS256, kty: "RSA"},
+		{algorithm: jwt.AlgorithmEdDSA, kty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			dir := t.TempDir()
+			svc := jwt.New(newKeyConfig(dir, tt.algorithm), newMemoryCache())
+
+			// The first key is generated at start
+			files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+			assert.NoError(t, err)
+			assert.Len(t, files, 1)
+
+			pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+			assert.NoError(t, err)
+
+			_, err = svc.ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+			assert.NoError(t, err)
+
+			_, err = svc.RefreshTokens(context.Background(), pair.RefreshToken)
+			assert.NoError(t, err)
+
+			jwks := svc.JWKS()
+			if !assert.Len(t, jwks.Keys, 1) {
+				return
+			}
+
+			jwk := jwks.Keys[0]
+			assert.Equal(t, tt.kty, jwk.Kty)
+			assert.Equal(t, tt.algorithm, jwk.Alg)
+			assert.Equal(t, "sig", jwk.Use)
+			assert.Equal(t, jwk.Kid, tokenKeyID(t, pair.AccessToken))
+			assert.Equal(t, strings.TrimSuffix(filepath.Base(files[0]), ".pem"), jwk.Kid)
+
+			// Another service verifies the token with the published key alone
+			token, err := gojwt.ParseWithClaims(pair.AccessToken, &jwt.Claims{}, func(*gojwt.Token) (any, error) {
+				return publicKey(t, jwk), nil
+			}, gojwt.WithValidMethods([]string{tt.algorithm}))
+			assert.NoError(t, err)
+			assert.True(t, token.Valid)
+		})
+	}
+}
+
+func TestService_SigningKeys_Rotation(t *testing.T) {
+	_, old, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	_, current, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	now := time.Now()
+
+	t.Run("new key is published before it signs", func(t *testing.T) {
+		dir := t.TempDir()
+		writeKey(t, dir, "old", old, now.AddDate(0, 0, -40))
+
+		svc := jwt.New(newKeyConfig(dir, jwt.AlgorithmEdDSA), newMemoryCache())
+
+		jwks := svc.JWKS()
+		if assert.Len(t, jwks.Keys, 2) {
+			assert.Equal(t, "old", jwks.Keys[0].Kid)
+		}
+
+		pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "old", tokenKeyID(t, pair.AccessToken))
+	})
+
+	t.Run("key is retired once its tokens expired", func(t *testing.T) {
+		dir := t.TempDir()
+		writeKey(t, dir, "old", old, now.AddDate(0, 0, -40))
+		writeKey(t, dir, "current", current, now.AddDate(0, 0, -10))
+
+		svc := jwt.New(newKeyConfig(dir, jwt.AlgorithmEdDSA), newMemoryCache())
+
+		jwks := svc.JWKS()
+		if assert.Len(t, jwks.Keys, 1) {
+			assert.Equal(t, "current", jwks.Keys[0].Kid)
+		}
+
+		assert.NoFileExists(t, filepath.Join(dir, "old.pem"))
+	})
+
+	t.Run("tokens of an unknown key are rejected", func(t *testing.T) {
+		dir := t.TempDir()
+		writeKey(t, dir, "old", old, now.AddDate(0, 0, -10))
+
+		cache := newMemoryCache()
+		issuer := jwt.New(newKeyConfig(dir, jwt.AlgorithmEdDSA), cache)
+
+		pair, err := issuer.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+		assert.NoError(t, err)
+
+		other := t.TempDir()
+		writeKey(t, other, "current", current, now.AddDate(0, 0, -10))
+
+		verifier := jwt.New(newKeyConfig(other, jwt.AlgorithmEdDSA), cache)
+
+		_, err = verifier.ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+		assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+	})
+}
+
+func TestService_SigningKeys_Static(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	// Keys provided by the operator are not rotated and need no header
+	dir := t.TempDir()
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "static.pem"), pem.EncodeToMemory(block), 0o600))
+
+	cfg := newKeyConfig(dir, jwt.AlgorithmRS256)
+	cfg.JWT.Keys.RotateDays = 0
+
+	svc := jwt.New(cfg, newMemoryCache())
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "static", tokenKeyID(t, pair.AccessToken))
+
+	_, err = svc.ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+	assert.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestService_SigningKeys_Secrets(t *testing.T) {
+	cache := newMemoryCache()
+
+	pair, err := jwt.New(newConfig(), cache).GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	// While the secrets are kept, the tokens signed with them remain valid
+	dir := t.TempDir()
+
+	_, err = jwt.New(newKeyConfig(dir, jwt.AlgorithmRS256), cache).ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+	assert.NoError(t, err)
+
+	cfg := newKeyConfig(dir, jwt.AlgorithmRS256)
+	cfg.JWT.AccessSecret = ""
+	cfg.JWT.RefreshSecret = ""
+
+	_, err = jwt.New(cfg, cache).ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrInvalidToken)
+
+	// Without keys no key is published
+	assert.Empty(t, jwt.New(newConfig(), cache).JWKS().Keys)
+}
+
+func TestService_SigningKeys_TokenTypes(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	writeKey(t, dir, "static", key, time.Now().AddDate(0, 0, -10))
+
+	cfg := newKeyConfig(dir, jwt.AlgorithmEdDSA)
+	cfg.JWT.Keys.RotateDays = 0
+
+	svc := jwt.New(cfg, newMemoryCache())
+
+	typ := func(tokenString string) string {
+		token, _, err := gojwt.NewParser().ParseUnverified(tokenString, &jwt.Claims{})
+		assert.NoError(t, err)
+
+		header, _ := token.Header["typ"].(string)
+
+		return header
+	}
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	challenge, err := svc.GenerateChallengeToken(context.Background(), "user-1", "test@example.com", constant.RoleUser)
+	assert.NoError(t, err)
+
+	assert.Equal(t, jwt.HeaderTypeAccess, typ(pair.AccessToken))
+	assert.Equal(t, jwt.HeaderTypeRefresh, typ(pair.RefreshToken))
+	assert.Equal(t, jwt.HeaderTypeChallenge, typ(challenge))
+
+	_, err = svc.ValidateToken(context.Background(), pair.RefreshToken, jwt.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrInvalidClaim)
+
+	_, err = svc.ValidateToken(context.Background(), challenge, jwt.AccessToken)
+	assert.ErrorIs(t, err, jwt.ErrInvalidClaim)
+
+	sign := func(method gojwt.SigningMethod, signingKey any, header string, tokenType jwt.TokenType) string {
+		claims := jwt.Claims{
+			UserID:  "user-1",
+			TokenID: "token-1",
+			Type:    tokenType,
+			RegisteredClaims: gojwt.RegisteredClaims{
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+
+		token := gojwt.NewWithClaims(method, claims)
+		token.Header["kid"] = "static"
+		token.Header["typ"] = header
+
+		signed, err := token.SignedString(signingKey)
+		assert.NoError(t, err)
+
+		return signed
+	}
+
+	// A token signed with a published key needs the typ header of its type
+	_, err = svc.ValidateToken(context.Background(), sign(gojwt.SigningMethodEdDSA, key, "JWT", jwt.ChallengeToken), jwt.ChallengeToken)
+	assert.ErrorIs(t, err, jwt.ErrInvalidClaim)
+
+	_, err = svc.ValidateToken(context.Background(), sign(gojwt.SigningMethodEdDSA, key, jwt.HeaderTypeChallenge, jwt.ChallengeToken), jwt.ChallengeToken)
+	assert.NoError(t, err)
+
+	// Tokens signed with the secrets before the typ headers remain valid
+	_, err = svc.ValidateToken(context.Background(), sign(gojwt.SigningMethodHS256, []byte("access-secret"), "JWT", jwt.ChallengeToken), jwt.ChallengeToken)
+	assert.NoError(t, err)
+}
+
+func TestService_ValidateToken(t *testing.T) {
+	svc := newService()
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	challenge, err := svc.GenerateChallengeToken(context.Background(), "user-1", "test@example.com", constant.RoleUser)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType jwt.TokenType
+		wantErr   error
+	}{
+		{name: "access token", token: pair.AccessToken, tokenType: jwt.AccessToken},
+		{name: "refresh token", token: pair.RefreshToken, tokenType: jwt.RefreshToken},
+		{name: "challenge token without a session", token: challenge, tokenType: jwt.ChallengeToken},
+		{name: "refresh token as access token", token: pair.RefreshToken, tokenType: jwt.AccessToken, wantErr: jwt.ErrInvalidToken},
+		{name: "challenge token as access token", token: challenge, tokenType: jwt.AccessToken, wantErr: jwt.ErrInvalidClaim},
+		{name: "malformed token", token: "not-a-token", tokenType: jwt.AccessToken, wantErr: jwt.ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := svc.ValidateToken(context.Background(), tt.token, tt.tokenType)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "user-1", claims.UserID)
+		})
+	}
+
+	assert.NoError(t, svc.RevokeToken(context.Background(), challenge, jwt.ChallengeToken))
+
+	_, err = svc.ValidateToken(context.Background(), challenge, jwt.ChallengeToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+}
+
+func TestService_RedeemToken(t *testing.T) {
+	svc := newService()
+
+	challenge, err := svc.GenerateChallengeToken(context.Background(), "user-1", "test@example.com", constant.RoleUser)
+	assert.NoError(t, err)
+
+	// Of concurrent redemptions only one goes through.
+	var (
+		wg       sync.WaitGroup
+		redeemed atomic.Int32
+	)
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := svc.RedeemToken(context.Background(), challenge, jwt.ChallengeToken)
+			if err == nil {
+				redeemed.Add(1)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), redeemed.Load())
+
+	_, err = svc.ValidateToken(context.Background(), challenge, jwt.ChallengeToken)
+	assert.ErrorIs(t, err, jwt.ErrRevokedToken)
+}
+
+func TestService_IsTokenRevoked(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	t.Cleanup(func() { client.Close() })
+
+	svc := jwt.New(newConfig(), cache.NewRedisCache(client, mocks.NewOtel()))
+
+	pair, err := svc.GenerateTokenPair(context.Background(), "user-1", "test@example.com", constant.RoleUser, false)
+	assert.NoError(t, err)
+
+	claims, err := svc.ValidateToken(context.Background(), pair.AccessToken, jwt.AccessToken)
+	assert.NoError(t, err)
+
+	revoked, err := svc.IsTokenRevoked(context.Background(), claims.TokenID)
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	assert.NoError(t, svc.RevokeToken(context.Background(), pair.AccessToken, jwt.AccessToken))
+
+	revoked, err = svc.IsTokenRevoked(context.Background(), claims.TokenID)
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
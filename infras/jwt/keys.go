@@ -0,0 +1,416 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	keyFileExt       = ".pem"
+	keyFileMode      = 0o600
+	keyHeaderCreated = "Created"
+	keyIDTimeFormat  = "20060102T150405Z"
+	rsaKeyBits       = 2048
+
+	pemTypePrivateKey    = "PRIVATE KEY"
+	pemTypeRSAPrivateKey = "RSA PRIVATE KEY"
+
+	// keyReloadInterval limits how often the keys are read again for an
+	// unknown key id, which another instance may just have generated.
+	keyReloadInterval = time.Minute
+)
+
+var (
+	ErrUnknownKey         = errors.New("unknown signing key")
+	ErrNoSigningKey       = errors.New("no signing key")
+	ErrInvalidSigningKey  = errors.New("invalid signing key")
+	ErrUnsupportedKeyType = errors.New("unsupported signing key type")
+)
+
+// JWK is a public key published for other services to verify our tokens.
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is the set of published keys.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// signingKey is a private key read from a PEM file, named after its key id.
+// Keys generated by rotation record when they were created in a PEM header;
+// the others count as created long ago.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	created time.Time
+}
+
+// keyRing holds the keys of a directory. Every key verifies tokens and is
+// published, and one of them signs: the newest key that has been published for
+// the overlap window, so that services which cached the published keys fetch
+// them again before they meet tokens signed with it.
+//
+// With rotation on, the ring generates a key when the newest one is older than
+// the rotation period, and removes a key once its successor has been signing
+// for the lifetime of the longest-lived token, when every token it signed has
+// expired. Instances that share the directory pick the same signing key.
+type keyRing struct {
+	dir       string
+	algorithm string
+	rotation  time.Duration
+	overlap   time.Duration
+	lifetime  time.Duration
+
+	mu       sync.RWMutex
+	keys     []*signingKey
+	loadedAt time.Time
+}
+
+func newKeyRing(dir, algorithm string, rotation, overlap, lifetime time.Duration, now time.Time) (*keyRing, error) {
+	ring := &keyRing{
+		dir:       dir,
+		algorithm: algorithm,
+		rotation:  rotation,
+		overlap:   overlap,
+		lifetime:  lifetime,
+	}
+
+	if err := ring.rotate(now); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// signer returns the key that signs tokens at now.
+func (r *keyRing) signer(now time.Time) (*signingKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+
+	for _, key := range slices.Backward(r.keys) {
+		if r.active(key, now) {
+			return key, nil
+		}
+	}
+
+	// No key has been published long enough yet, as when the ring starts
+	// empty: the oldest one signs, which every instance picks alike.
+	return r.keys[0], nil
+}
+
+// key returns the key of a key id, reading the directory again when it is
+// unknown, as another instance may have generated it.
+func (r *keyRing) key(id string, now time.Time) (*signingKey, error) {
+	if key, ok := r.find(id); ok {
+		return key, nil
+	}
+
+	r.mu.RLock()
+	recent := now.Sub(r.loadedAt) < keyReloadInterval
+	r.mu.RUnlock()
+
+	if recent {
+		return nil, ErrUnknownKey
+	}
+
+	if err := r.load(now); err != nil {
+		return nil, err
+	}
+
+	if key, ok := r.find(id); ok {
+		return key, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+func (r *keyRing) find(id string) (*signingKey, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.id == id {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// jwks returns the public keys of the ring.
+func (r *keyRing) jwks() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	set := JWKS{Keys: make([]JWK, 0, len(r.keys))}
+
+	for _, key := range r.keys {
+		jwk := JWK{Use: "sig", Alg: key.method.Alg(), Kid: key.id}
+
+		switch public := key.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// rotate reads the directory and, with rotation on, generates and removes
+// keys as they are due.
+func (r *keyRing) rotate(now time.Time) error {
+	if err := r.load(now); err != nil {
+		return err
+	}
+
+	if r.rotation <= 0 {
+		if len(r.keys) == 0 {
+			return fmt.Errorf("%w: no keys in %s", ErrNoSigningKey, r.dir)
+		}
+
+		return nil
+	}
+
+	r.mu.RLock()
+	due := len(r.keys) == 0 || now.Sub(r.keys[len(r.keys)-1].created) >= r.rotation
+	r.mu.RUnlock()
+
+	if due {
+		if err := r.generate(now); err != nil {
+			return err
+		}
+	}
+
+	if err := r.retire(now); err != nil {
+		return err
+	}
+
+	return r.load(now)
+}
+
+// retire removes the keys whose successor has been signing for longer than any
+// token lives.
+func (r *keyRing) retire(now time.Time) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i, key := range r.keys[:max(len(r.keys)-1, 0)] {
+		successor := r.keys[i+1]
+		if !r.active(successor, now.Add(-r.lifetime)) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(r.dir, key.id+keyFileExt)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove signing key %s: %w", key.id, err)
+		}
+	}
+
+	return nil
+}
+
+// active reports whether a key may sign at now.
+func (r *keyRing) active(key *signingKey, now time.Time) bool {
+	return !key.created.Add(r.overlap).After(now)
+}
+
+// generate writes a new key to the directory. The key id carries a random
+// suffix so that instances rotating at once do not overwrite each other.
+func (r *keyRing) generate(now time.Time) error {
+	var (
+		private any
+		err     error
+	)
+
+	switch r.algorithm {
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	case AlgorithmRS256, "":
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	default:
+		return fmt.Errorf("%w: algorithm %q", ErrUnsupportedKeyType, r.algorithm)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return fmt.Errorf("failed to encode signing key: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to generate key id: %w", err)
+	}
+
+	created := now.UTC()
+	id := created.Format(keyIDTimeFormat) + "-" + hex.EncodeToString(suffix)
+
+	block := &pem.Block{
+		Type:    pemTypePrivateKey,
+		Headers: map[string]string{keyHeaderCreated: created.Format(time.RFC3339)},
+		Bytes:   der,
+	}
+
+	if err := os.MkdirAll(r.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	// Written aside and renamed, so that other instances never read half a key.
+	tmp, err := os.CreateTemp(r.dir, "."+id+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create signing key file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := pem.Encode(tmp, block); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	if err := os.Chmod(tmp.Name(), keyFileMode); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(r.dir, id+keyFileExt)); err != nil {
+		return fmt.Errorf("failed to write signing key: %w", err)
+	}
+
+	return nil
+}
+
+// load reads the keys of the directory, oldest first.
+func (r *keyRing) load(now time.Time) error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read key directory: %w", err)
+	}
+
+	keys := []*signingKey{}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != keyFileExt {
+			continue
+		}
+
+		key, err := readKey(filepath.Join(r.dir, name))
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b *signingKey) int {
+		if c := a.created.Compare(b.created); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.id, b.id)
+	})
+
+	r.mu.Lock()
+	r.keys = keys
+	r.loadedAt = now
+	r.mu.Unlock()
+
+	return nil
+}
+
+// readKey reads an RSA or Ed25519 private key from a PEM file.
+func readKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), keyFileExt)
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: %s is not PEM encoded", ErrInvalidSigningKey, id)
+	}
+
+	var private any
+
+	switch block.Type {
+	case pemTypePrivateKey:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemTypeRSAPrivateKey:
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %s holds a %s", ErrInvalidSigningKey, id, block.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidSigningKey, id, err)
+	}
+
+	key := &signingKey{id: id}
+
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.private = jwt.SigningMethodRS256, private
+	case ed25519.PrivateKey:
+		key.method, key.private = jwt.SigningMethodEdDSA, private
+	default:
+		return nil, fmt.Errorf("%w: %s holds a %T", ErrUnsupportedKeyType, id, private)
+	}
+
+	if created, ok := block.Headers[keyHeaderCreated]; ok {
+		key.created, err = time.Parse(time.RFC3339, created)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s has an invalid %s header: %w", ErrInvalidSigningKey, id, keyHeaderCreated, err)
+		}
+	}
+
+	return key, nil
+}
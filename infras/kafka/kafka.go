@@ -6,8 +6,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"net"
 	"oil/config"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	kafkaGo "github.com/segmentio/kafka-go"
@@ -59,11 +59,14 @@ type Client interface {
 	Reader(consumerGroup, topic string) *kafkaGo.Reader
 }
 
+// writerBatchTimeout bounds how long a synchronous write waits for more
+// messages to fill a batch. The default of one second would delay every send.
+const writerBatchTimeout = 10 * time.Millisecond
+
 type kafkaClientImpl struct {
-	config    *config.Config
-	dialer    *kafkaGo.Dialer
-	transport *kafkaGo.Transport
-	address   net.Addr
+	config *config.Config
+	dialer *kafkaGo.Dialer
+	writer *kafkaGo.Writer
 }
 
 func New(config *config.Config) Client {
@@ -81,13 +84,24 @@ func New(config *config.Config) Client {
 		SASL: mechanism,
 	}
 
+	// A single writer is shared by every topic, so connections and metadata
+	// are kept across sends. Writes are synchronous and acknowledged by all
+	// in-sync replicas so that callers such as the outbox relay only see
+	// success once Kafka has the message.
+	writer := &kafkaGo.Writer{
+		Addr:                   kafkaGo.TCP(config.Kafka.Brokers...),
+		Transport:              transport,
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafkaGo.RequireAll,
+		BatchTimeout:           writerBatchTimeout,
+	}
+
 	log.Info().Msg("Kafka client initialzed")
 
 	return &kafkaClientImpl{
-		config:    config,
-		dialer:    dialer,
-		transport: transport,
-		address:   kafkaGo.TCP(config.Kafka.Brokers...),
+		config: config,
+		dialer: dialer,
+		writer: writer,
 	}
 }
 
@@ -115,14 +129,6 @@ func (k *kafkaClientImpl) Reader(consumerGroup, topic string) *kafkaGo.Reader {
 func (k *kafkaClientImpl) SendMessages(ctx context.Context, topic string, messages ...Message) (err error) {
 	msgs := []kafkaGo.Message{}
 
-	writer := &kafkaGo.Writer{
-		Addr:                   k.address,
-		Topic:                  topic,
-		Transport:              k.transport,
-		AllowAutoTopicCreation: true,
-		Async:                  true,
-	}
-
 	for _, message := range messages {
 		msg, err := message.ToKafkaMessage()
 		if err != nil {
@@ -131,10 +137,11 @@ func (k *kafkaClientImpl) SendMessages(ctx context.Context, topic string, messag
 			return fmt.Errorf("failed to convert message to Kafka message: %w", err)
 		}
 
+		msg.Topic = topic
 		msgs = append(msgs, msg)
 	}
 
-	err = writer.WriteMessages(ctx, msgs...)
+	err = k.writer.WriteMessages(ctx, msgs...)
 	if err != nil {
 		log.Error().Err(err).Str("topic", topic).Msg("Failed to send message to Kafka.")
 
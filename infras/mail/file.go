@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"oil/shared/timezone"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+type fileSender struct {
+	dir  string
+	from string
+}
+
+// NewFile returns a sender writing every message as an .eml file into dir,
+// for inspecting emails in development without a mail server.
+func NewFile(dir, from string) Sender {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	return &fileSender{dir: dir, from: from}
+}
+
+func (s *fileSender) Send(_ context.Context, message Message) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	now := timezone.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405"), uuid.NewString())
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), compose(s.from, message, now), 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
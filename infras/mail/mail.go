@@ -0,0 +1,88 @@
+package mail
+
+//go:generate go run go.uber.org/mock/mockgen -source=./mail.go -destination=./mocks/mail_mock.go -package=mocks
+
+import (
+	"context"
+	"fmt"
+	"oil/config"
+	"oil/infras/otel"
+	"oil/shared/constant"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DriverSMTP   = "smtp"
+	DriverFile   = "file"
+	DriverMemory = "memory"
+
+	otelAttrSubject = "subject"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender delivers emails. The implementation is chosen by the mail driver:
+// SMTP in production, or a file or in-memory stand-in for development and
+// tests.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// New returns the sender of the configured driver, SMTP by default.
+func New(cfg *config.Config, otel otel.Otel) Sender {
+	mailCfg := cfg.External.Mail
+
+	var sender Sender
+
+	switch strings.ToLower(mailCfg.Driver) {
+	case DriverFile:
+		sender = NewFile(mailCfg.Dir, mailCfg.From)
+	case DriverMemory:
+		sender = NewMemory()
+	default:
+		sender = NewSMTP(mailCfg.SMTP.Host, mailCfg.SMTP.Port, mailCfg.SMTP.Username, mailCfg.SMTP.Password, mailCfg.From)
+	}
+
+	log.Info().Str("driver", mailCfg.Driver).Msg("mail sender initialized")
+
+	return &tracedSender{sender: sender, otel: otel}
+}
+
+type tracedSender struct {
+	sender Sender
+	otel   otel.Otel
+}
+
+func (s *tracedSender) Send(ctx context.Context, message Message) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelExternalScopeName, constant.OtelExternalScopeName+".mail.Send")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	scope.SetAttributes(map[string]any{otelAttrSubject: message.Subject})
+
+	return s.sender.Send(ctx, message) //nolint:wrapcheck
+}
+
+// compose renders a message in the Internet Message Format.
+func compose(from string, message Message, date time.Time) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", message.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(message.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	netMail "net/mail"
+	"net/smtp"
+	"oil/shared/timezone"
+)
+
+type smtpSender struct {
+	address  string
+	host     string
+	auth     smtp.Auth
+	from     string
+	envelope string
+}
+
+// NewSMTP returns a sender delivering through an SMTP server, authenticating
+// with PLAIN when a username is given. The connection is upgraded with
+// STARTTLS when the server offers it.
+func NewSMTP(host, port, username, password, from string) Sender {
+	sender := &smtpSender{
+		address:  net.JoinHostPort(host, port),
+		host:     host,
+		from:     from,
+		envelope: from,
+	}
+
+	// The envelope sender is the bare address of a "Name <address>" sender.
+	if address, err := netMail.ParseAddress(from); err == nil {
+		sender.envelope = address.Address
+	}
+
+	if username != "" {
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return sender
+}
+
+func (s *smtpSender) Send(_ context.Context, message Message) error {
+	if err := smtp.SendMail(s.address, s.auth, s.envelope, message.To, compose(s.from, message, timezone.Now())); err != nil {
+		return fmt.Errorf("failed to send mail through %s: %w", s.host, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package oidc
+
+//go:generate go run go.uber.org/mock/mockgen -source=./oidc.go -destination=./mocks/oidc_mock.go -package=mocks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"oil/config"
+	"oil/infras/otel"
+	"oil/shared/constant"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrNotConfigured   = errors.New("oidc client id is not configured")
+	ErrInvalidIDToken  = errors.New("invalid id token")
+	ErrUnknownKey      = errors.New("unknown id token signing key")
+	ErrDiscoveryFailed = errors.New("failed to discover oidc provider")
+	ErrKeysFetchFailed = errors.New("failed to fetch oidc signing keys")
+)
+
+const (
+	GoogleIssuer = "https://accounts.google.com"
+
+	// Google issues some ID tokens without the scheme in their issuer.
+	googleIssuerHost = "accounts.google.com"
+
+	httpTimeout = 10 * time.Second
+	leeway      = 30 * time.Second
+)
+
+// Claims are the identity claims of a verified ID token.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Picture       string
+}
+
+// Verifier verifies ID tokens issued to our client by an OpenID Connect
+// provider, Google by default.
+type Verifier interface {
+	Verify(ctx context.Context, rawIDToken string) (Claims, error)
+}
+
+// New returns the verifier of the configured provider. Its keys are fetched on
+// the first verification, so that the app starts without reaching it.
+func New(cfg *config.Config, otel otel.Otel) Verifier {
+	googleCfg := cfg.External.Google
+
+	issuer := googleCfg.Issuer
+	if issuer == constant.Empty {
+		issuer = GoogleIssuer
+	}
+
+	log.Info().Str("issuer", issuer).Msg("oidc verifier initialized")
+
+	return &tracedVerifier{
+		verifier: NewProvider(issuer, googleCfg.ClientID, &http.Client{Timeout: httpTimeout}),
+		otel:     otel,
+	}
+}
+
+type tracedVerifier struct {
+	verifier Verifier
+	otel     otel.Otel
+}
+
+func (v *tracedVerifier) Verify(ctx context.Context, rawIDToken string) (claims Claims, err error) {
+	ctx, scope := v.otel.NewScope(ctx, constant.OtelExternalScopeName, constant.OtelExternalScopeName+".oidc.Verify")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	return v.verifier.Verify(ctx, rawIDToken) //nolint:wrapcheck
+}
+
+// idTokenClaims are the claims of an ID token we read.
+type idTokenClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	Picture       string   `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// flexBool reads booleans that some providers encode as strings.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	*b = flexBool(bytes.Equal(bytes.Trim(data, `"`), []byte("true")))
+
+	return nil
+}
+
+// Verify checks the signature, issuer, audience and lifetime of an ID token
+// and returns its identity claims.
+func (p *Provider) Verify(ctx context.Context, rawIDToken string) (Claims, error) {
+	if p.clientID == constant.Empty {
+		return Claims{}, ErrNotConfigured
+	}
+
+	claims := idTokenClaims{}
+
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(leeway),
+	)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if !slices.Contains(p.issuers(), claims.Issuer) {
+		return Claims{}, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+
+	if claims.Subject == constant.Empty {
+		return Claims{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	return Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+		Picture:       claims.Picture,
+	}, nil
+}
+
+// issuers returns the issuers accepted in ID tokens.
+func (p *Provider) issuers() []string {
+	if p.issuer == GoogleIssuer {
+		return []string{GoogleIssuer, googleIssuerHost}
+	}
+
+	return []string{p.issuer}
+}
@@ -0,0 +1,174 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"oil/infras/oidc"
+	"oil/infras/oidc/oidctest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const clientID = "client-id.apps.example.com"
+
+func TestProvider_Verify(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := oidc.NewProvider(issuer.URL, clientID, http.DefaultClient)
+
+	tests := []struct {
+		name    string
+		token   func() string
+		want    oidc.Claims
+		wantErr bool
+	}{
+		{
+			name: "valid token",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{
+					"sub":            "google-sub-1",
+					"email":          "test@example.com",
+					"email_verified": true,
+					"name":           "Test User",
+					"picture":        "https://example.com/avatar.png",
+				})
+			},
+			want: oidc.Claims{
+				Subject:       "google-sub-1",
+				Email:         "test@example.com",
+				EmailVerified: true,
+				Name:          "Test User",
+				Picture:       "https://example.com/avatar.png",
+			},
+		},
+		{
+			name: "email verified as string",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1", "email": "test@example.com", "email_verified": "true"})
+			},
+			want: oidc.Claims{Subject: "google-sub-1", Email: "test@example.com", EmailVerified: true},
+		},
+		{
+			name: "unverified email",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1", "email": "test@example.com"})
+			},
+			want: oidc.Claims{Subject: "google-sub-1", Email: "test@example.com"},
+		},
+		{
+			name: "other audience",
+			token: func() string {
+				return issuer.IDToken("other-client", jwt.MapClaims{"sub": "google-sub-1"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1", "iss": "https://evil.example.com"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired token",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1", "exp": time.Now().Add(-time.Hour).Unix()})
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing subject",
+			token: func() string {
+				return issuer.IDToken(clientID, jwt.MapClaims{})
+			},
+			wantErr: true,
+		},
+		{
+			name: "malformed token",
+			token: func() string {
+				return "not-a-token"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.Verify(context.Background(), tt.token())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, claims)
+		})
+	}
+}
+
+func TestProvider_Verify_UnknownKey(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := oidc.NewProvider(issuer.URL, clientID, http.DefaultClient)
+
+	_, err := provider.Verify(context.Background(), issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1"}))
+	assert.NoError(t, err)
+
+	// Keys were fetched just now, so a rotated key is not fetched again yet.
+	issuer.RotateKey()
+
+	_, err = provider.Verify(context.Background(), issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1"}))
+	assert.ErrorIs(t, err, oidc.ErrUnknownKey)
+}
+
+func TestProvider_Verify_RotatedKey(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	issuer.RotateKey()
+
+	// The first verification fetches the keys, including the rotated one.
+	provider := oidc.NewProvider(issuer.URL, clientID, http.DefaultClient)
+
+	_, err := provider.Verify(context.Background(), issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1"}))
+	assert.NoError(t, err)
+}
+
+func TestProvider_Verify_NotConfigured(t *testing.T) {
+	provider := oidc.NewProvider(oidc.GoogleIssuer, "", http.DefaultClient)
+
+	_, err := provider.Verify(context.Background(), "token")
+	assert.ErrorIs(t, err, oidc.ErrNotConfigured)
+}
+
+func TestProvider_Verify_FailedFetchIsThrottled(t *testing.T) {
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := oidc.NewProvider(server.URL, clientID, http.DefaultClient)
+	idToken := issuer.IDToken(clientID, jwt.MapClaims{"sub": "google-sub-1"})
+
+	_, err := provider.Verify(context.Background(), idToken)
+	assert.ErrorIs(t, err, oidc.ErrDiscoveryFailed)
+
+	// The failed fetch is not retried right away.
+	_, err = provider.Verify(context.Background(), idToken)
+	assert.ErrorIs(t, err, oidc.ErrKeysFetchFailed)
+	assert.Equal(t, int32(1), requests.Load())
+}
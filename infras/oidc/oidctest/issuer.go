@@ -0,0 +1,127 @@
+// Package oidctest runs a local OpenID Connect issuer for tests, which
+// publishes its discovery document and signing keys and signs ID tokens.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyBits = 2048
+
+// Issuer is a fake OpenID Connect issuer served over HTTP.
+type Issuer struct {
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	key      *rsa.PrivateKey
+	kid      string
+	rotation int
+}
+
+// NewIssuer starts an issuer with a fresh signing key. It is closed with Close.
+func NewIssuer() *Issuer {
+	issuer := &Issuer{}
+	issuer.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/keys", issuer.keys)
+
+	issuer.server = httptest.NewServer(mux)
+	issuer.URL = issuer.server.URL
+
+	return issuer
+}
+
+// Close shuts the issuer down.
+func (i *Issuer) Close() {
+	i.server.Close()
+}
+
+// RotateKey replaces the signing key with a new one under a new key id.
+func (i *Issuer) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		panic("oidctest: failed to generate key: " + err.Error())
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.rotation++
+	i.key = key
+	i.kid = "key-" + strconv.Itoa(i.rotation)
+}
+
+// IDToken signs an ID token for the audience with the given claims. The issuer,
+// audience, issue time and a one hour expiry are set unless claims has them.
+func (i *Issuer) IDToken(audience string, claims jwt.MapClaims) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	token := jwt.MapClaims{
+		"iss": i.URL,
+		"aud": audience,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+
+	for name, value := range claims {
+		token[name] = value
+	}
+
+	signed := jwt.NewWithClaims(jwt.SigningMethodRS256, token)
+	signed.Header["kid"] = i.kid
+
+	raw, err := signed.SignedString(i.key)
+	if err != nil {
+		panic("oidctest: failed to sign token: " + err.Error())
+	}
+
+	return raw
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":   i.URL,
+		"jwks_uri": i.URL + "/keys",
+	})
+}
+
+func (i *Issuer) keys(w http.ResponseWriter, _ *http.Request) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	publicKey := i.key.PublicKey
+
+	writeJSON(w, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": jwt.SigningMethodRS256.Alg(),
+			"kid": i.kid,
+			"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(body)
+}
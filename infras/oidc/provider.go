@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"oil/shared/constant"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+
+	keyTypeRSA = "RSA"
+
+	// refreshInterval limits how often the keys are fetched again for an
+	// unknown key id or after a failed fetch, so that forged tokens cannot
+	// flood the provider.
+	refreshInterval = time.Minute
+)
+
+// Provider verifies ID tokens against the signing keys published by an issuer
+// through OpenID Connect discovery. Keys are cached and fetched again when a
+// token is signed with a key id we do not know, which covers key rotation.
+type Provider struct {
+	issuer   string
+	clientID string
+	client   *http.Client
+
+	mu        sync.Mutex
+	jwksURI   string
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewProvider returns a provider of the issuer for the client id.
+func NewProvider(issuer, clientID string, client *http.Client) *Provider {
+	return &Provider{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientID: clientID,
+		client:   client,
+	}
+}
+
+type discovery struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// key returns the signing key of a key id. The lock is not held while the
+// keys are fetched: the fetch is claimed by recording its time first, so that
+// tokens with unknown key ids meanwhile are not let through to the issuer.
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+
+	if key, ok := p.keys[kid]; ok {
+		p.mu.Unlock()
+
+		return key, nil
+	}
+
+	if time.Since(p.fetchedAt) < refreshInterval {
+		fetched := p.keys != nil
+		p.mu.Unlock()
+
+		if !fetched {
+			return nil, fmt.Errorf("%w: retried too soon", ErrKeysFetchFailed)
+		}
+
+		return nil, ErrUnknownKey
+	}
+
+	p.fetchedAt = time.Now()
+	jwksURI := p.jwksURI
+	p.mu.Unlock()
+
+	jwksURI, keys, err := p.fetch(ctx, jwksURI)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	p.jwksURI = jwksURI
+	p.keys = keys
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, ErrUnknownKey
+}
+
+// fetch fetches the signing keys, discovering where they are published when
+// jwksURI is not known yet. It returns where they are published with the keys.
+func (p *Provider) fetch(ctx context.Context, jwksURI string) (string, map[string]*rsa.PublicKey, error) {
+	if jwksURI == constant.Empty {
+		config := discovery{}
+		if err := p.get(ctx, p.issuer+discoveryPath, &config); err != nil {
+			return constant.Empty, nil, fmt.Errorf("%w: %w", ErrDiscoveryFailed, err)
+		}
+
+		if strings.TrimSuffix(config.Issuer, "/") != p.issuer {
+			return constant.Empty, nil, fmt.Errorf("%w: issuer %q does not match %q", ErrDiscoveryFailed, config.Issuer, p.issuer)
+		}
+
+		if config.JWKSURI == constant.Empty {
+			return constant.Empty, nil, fmt.Errorf("%w: missing jwks_uri", ErrDiscoveryFailed)
+		}
+
+		jwksURI = config.JWKSURI
+	}
+
+	set := jwks{}
+	if err := p.get(ctx, jwksURI, &set); err != nil {
+		return constant.Empty, nil, fmt.Errorf("%w: %w", ErrKeysFetchFailed, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, key := range set.Keys {
+		if key.Kty != keyTypeRSA || (key.Use != constant.Empty && key.Use != "sig") {
+			continue
+		}
+
+		publicKey, err := key.rsa()
+		if err != nil {
+			return constant.Empty, nil, fmt.Errorf("%w: key %q: %w", ErrKeysFetchFailed, key.Kid, err)
+		}
+
+		keys[key.Kid] = publicKey
+	}
+
+	return jwksURI, keys, nil
+}
+
+func (p *Provider) get(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// rsa decodes the modulus and exponent of an RSA key.
+func (k jwk) rsa() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("exponent out of range")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
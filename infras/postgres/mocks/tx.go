@@ -0,0 +1,18 @@
+package mocks
+
+import (
+	"context"
+	"oil/infras/postgres"
+)
+
+type txManagerImpl struct {
+}
+
+// Do implements postgres.TxManager by calling fn without a transaction.
+func (m *txManagerImpl) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func NewTxManager() postgres.TxManager {
+	return &txManagerImpl{}
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+)
+
+type txKey struct{}
+
+// txState is the transaction carried by a context. Savepoints are numbered per
+// transaction; a transaction is only used by one goroutine at a time.
+type txState struct {
+	tx         *sqlx.Tx
+	savepoints int
+}
+
+// TxManager runs a unit of work in a transaction on the write connection. The
+// transaction travels in the context, and the generic repository uses it for
+// every query made with that context.
+type TxManager interface {
+	// Do commits when fn returns nil and rolls back when it returns an error or
+	// panics. Called within another Do, it runs fn in a savepoint so that only
+	// the nested work is rolled back on error.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManagerImpl struct {
+	db *sqlx.DB
+}
+
+func NewTxManager(conn *Connection) TxManager {
+	return &txManagerImpl{db: conn.Write}
+}
+
+// TxFromContext returns the transaction started by TxManager.Do, if any.
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	state, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+
+	return state.tx, true
+}
+
+// Writer returns the transaction of ctx when the call is part of a unit of work,
+// and the write connection otherwise.
+func (c *Connection) Writer(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return c.Write
+}
+
+// Reader returns the transaction of ctx, so that a unit of work reads its own
+// writes, and the read connection otherwise.
+func (c *Connection) Reader(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return c.Read
+}
+
+func (m *txManagerImpl) Do(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.savepoint(ctx, state, fn)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			rollback(tx.Rollback)
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		rollback(tx.Rollback)
+
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (m *txManagerImpl) savepoint(ctx context.Context, state *txState, fn func(ctx context.Context) error) (err error) {
+	state.savepoints++
+	name := fmt.Sprintf("sp_%d", state.savepoints)
+
+	if _, err = state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	rollbackToSavepoint := func() error {
+		_, err := state.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name)
+
+		return err //nolint:wrapcheck
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			rollback(rollbackToSavepoint)
+			panic(recovered)
+		}
+	}()
+
+	if err = fn(ctx); err != nil {
+		rollback(rollbackToSavepoint)
+
+		return err
+	}
+
+	if _, err = state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+
+	return nil
+}
+
+func rollback(fn func() error) {
+	if err := fn(); err != nil {
+		log.Error().Err(err).Msg("failed to roll back transaction")
+	}
+}
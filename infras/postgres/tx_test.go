@@ -0,0 +1,217 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"oil/infras/postgres"
+)
+
+// recorder is a database/sql connector that records the transaction statements
+// it is asked to run.
+type recorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *recorder) record(statement string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statements = append(r.statements, statement)
+}
+
+func (r *recorder) Connect(_ context.Context) (driver.Conn, error) {
+	return &recorderConn{recorder: r}, nil
+}
+
+func (r *recorder) Driver() driver.Driver {
+	return nil
+}
+
+type recorderConn struct {
+	recorder *recorder
+}
+
+func (c *recorderConn) Prepare(_ string) (driver.Stmt, error) {
+	return nil, errors.New("prepare is not supported")
+}
+
+func (c *recorderConn) Close() error {
+	return nil
+}
+
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	c.recorder.record("BEGIN")
+
+	return &recorderTx{recorder: c.recorder}, nil
+}
+
+func (c *recorderConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.recorder.record(query)
+
+	return driver.RowsAffected(0), nil
+}
+
+type recorderTx struct {
+	recorder *recorder
+}
+
+func (t *recorderTx) Commit() error {
+	t.recorder.record("COMMIT")
+
+	return nil
+}
+
+func (t *recorderTx) Rollback() error {
+	t.recorder.record("ROLLBACK")
+
+	return nil
+}
+
+// newConnection returns a connection whose statements are recorded.
+func newConnection(t *testing.T) (*postgres.Connection, *recorder) {
+	t.Helper()
+
+	recorder := &recorder{}
+	db := sqlx.NewDb(sql.OpenDB(recorder), "postgres")
+
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+
+	return &postgres.Connection{Read: db, Write: db}, recorder
+}
+
+func TestTxManager_Do(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name           string
+		fn             func(tx postgres.TxManager) func(ctx context.Context) error
+		wantErr        error
+		wantPanic      bool
+		wantStatements []string
+	}{
+		{
+			name: "commits when fn succeeds",
+			fn: func(_ postgres.TxManager) func(ctx context.Context) error {
+				return func(_ context.Context) error {
+					return nil
+				}
+			},
+			wantStatements: []string{"BEGIN", "COMMIT"},
+		},
+		{
+			name: "rolls back when fn fails",
+			fn: func(_ postgres.TxManager) func(ctx context.Context) error {
+				return func(_ context.Context) error {
+					return errFailed
+				}
+			},
+			wantErr:        errFailed,
+			wantStatements: []string{"BEGIN", "ROLLBACK"},
+		},
+		{
+			name: "rolls back when fn panics",
+			fn: func(_ postgres.TxManager) func(ctx context.Context) error {
+				return func(_ context.Context) error {
+					panic("boom")
+				}
+			},
+			wantPanic:      true,
+			wantStatements: []string{"BEGIN", "ROLLBACK"},
+		},
+		{
+			name: "nested unit of work is released in a savepoint",
+			fn: func(tx postgres.TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					return tx.Do(ctx, func(_ context.Context) error {
+						return nil
+					})
+				}
+			},
+			wantStatements: []string{"BEGIN", "SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "COMMIT"},
+		},
+		{
+			name: "failed nested unit of work only rolls back its savepoint",
+			fn: func(tx postgres.TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					err := tx.Do(ctx, func(_ context.Context) error {
+						return errFailed
+					})
+					assert.ErrorIs(t, err, errFailed)
+
+					return tx.Do(ctx, func(_ context.Context) error {
+						return nil
+					})
+				}
+			},
+			wantStatements: []string{
+				"BEGIN",
+				"SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1",
+				"SAVEPOINT sp_2", "RELEASE SAVEPOINT sp_2",
+				"COMMIT",
+			},
+		},
+		{
+			name: "panic in a nested unit of work rolls back both",
+			fn: func(tx postgres.TxManager) func(ctx context.Context) error {
+				return func(ctx context.Context) error {
+					return tx.Do(ctx, func(_ context.Context) error {
+						panic("boom")
+					})
+				}
+			},
+			wantPanic:      true,
+			wantStatements: []string{"BEGIN", "SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, recorder := newConnection(t)
+			tx := postgres.NewTxManager(conn)
+
+			run := func() {
+				err := tx.Do(context.Background(), tt.fn(tx))
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+
+			if tt.wantPanic {
+				assert.Panics(t, run)
+			} else {
+				assert.NotPanics(t, run)
+			}
+
+			assert.Equal(t, tt.wantStatements, recorder.statements)
+		})
+	}
+}
+
+func TestTxFromContext(t *testing.T) {
+	conn, _ := newConnection(t)
+	tx := postgres.NewTxManager(conn)
+
+	_, ok := postgres.TxFromContext(context.Background())
+	assert.False(t, ok)
+	assert.Same(t, conn.Write, conn.Writer(context.Background()))
+	assert.Same(t, conn.Read, conn.Reader(context.Background()))
+
+	err := tx.Do(context.Background(), func(ctx context.Context) error {
+		sqlTx, ok := postgres.TxFromContext(ctx)
+		assert.True(t, ok)
+		assert.Same(t, sqlTx, conn.Writer(ctx))
+		assert.Same(t, sqlTx, conn.Reader(ctx))
+
+		return nil
+	})
+	assert.NoError(t, err)
+}
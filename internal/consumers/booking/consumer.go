@@ -0,0 +1,46 @@
+package booking
+
+import (
+	"context"
+	"oil/config"
+	"oil/internal/domains/booking/model/dto"
+	"oil/shared/event"
+	"oil/transport/consumer"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Consumer struct {
+	cfg *config.Config
+}
+
+func New(cfg *config.Config) Consumer {
+	return Consumer{cfg: cfg}
+}
+
+func (c *Consumer) Subscriptions() []consumer.Subscription {
+	return []consumer.Subscription{
+		{
+			Name:    "booking.activity",
+			Topic:   c.cfg.Kafka.Topics.BookingEvents,
+			Handler: consumer.Typed(c.LogActivity),
+		},
+	}
+}
+
+// LogActivity writes every booking event to the application log, giving
+// operators a trail of booking changes across replicas.
+func (c *Consumer) LogActivity(_ context.Context, key string, envelope event.Envelope[dto.BookingEvent]) error {
+	log.Info().
+		Str("event_id", envelope.ID).
+		Str("type", envelope.Type).
+		Int("version", envelope.Version).
+		Str("actor", envelope.Actor).
+		Str("booking_id", key).
+		Str("status", envelope.Payload.Booking.Status).
+		Str("previous_status", envelope.Payload.PreviousStatus).
+		Time("occurred_at", envelope.OccurredAt).
+		Msg("Booking event")
+
+	return nil
+}
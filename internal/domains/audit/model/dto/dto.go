@@ -0,0 +1,101 @@
+package dto
+
+import (
+	"encoding/json"
+	"oil/internal/domains/audit/model"
+	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	"oil/shared/timezone"
+	"time"
+)
+
+type GetAuditLogsRequest struct {
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id"`
+	Actor    string `json:"actor"`
+	From     string `json:"from"      validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	To       string `json:"to"        validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// ToFilter matches the audit logs of the request. From is inclusive and To
+// exclusive; both are validated as RFC 3339 times.
+func (r GetAuditLogsRequest) ToFilter() gDto.FilterGroup {
+	filter := gDto.FilterGroup{Operator: gDto.FilterGroupOperatorAnd, Filters: []any{}}
+
+	equals := []struct{ field, value string }{
+		{field: model.FieldEntity, value: r.Entity},
+		{field: model.FieldEntityID, value: r.EntityID},
+		{field: model.FieldActor, value: r.Actor},
+	}
+
+	for _, eq := range equals {
+		if eq.value == constant.Empty {
+			continue
+		}
+
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			Field:    eq.field,
+			Operator: gDto.FilterOperatorEq,
+			Value:    eq.value,
+			Table:    model.TableName,
+		})
+	}
+
+	if from, err := time.Parse(time.RFC3339, r.From); err == nil {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			ArgName:  "created_from",
+			Field:    model.FieldCreatedAt,
+			Operator: gDto.FilterOperatorGreaterEq,
+			Value:    from,
+			Table:    model.TableName,
+		})
+	}
+
+	if to, err := time.Parse(time.RFC3339, r.To); err == nil {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			ArgName:  "created_to",
+			Field:    model.FieldCreatedAt,
+			Operator: gDto.FilterOperatorLess,
+			Value:    to,
+			Table:    model.TableName,
+		})
+	}
+
+	return filter
+}
+
+type AuditLogResponse struct {
+	ID        string          `json:"id"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Action    string          `json:"action"`
+	Actor     *string         `json:"actor,omitempty"`
+	RequestID *string         `json:"request_id,omitempty"`
+	Changes   json.RawMessage `json:"changes"              swaggertype:"object"`
+	CreatedAt string          `json:"created_at"`
+}
+
+func (r *AuditLogResponse) FromModel(model model.AuditLog) {
+	r.ID = model.ID
+	r.Entity = model.Entity
+	r.EntityID = model.EntityID
+	r.Action = model.Action
+	r.Actor = model.Actor
+	r.RequestID = model.RequestID
+	r.Changes = json.RawMessage(model.Changes)
+	r.CreatedAt = timezone.Format(model.CreatedAt, constant.DateFormat)
+}
+
+type GetAuditLogsResponse struct {
+	AuditLogs []AuditLogResponse `json:"audit_logs"`
+	gDto.Pagination
+}
+
+func (r *GetAuditLogsResponse) FromPage(models []model.AuditLog, page gDto.Pagination) {
+	r.Pagination = page
+
+	r.AuditLogs = make([]AuditLogResponse, len(models))
+	for i, mod := range models {
+		r.AuditLogs[i].FromModel(mod)
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+const (
+	TableName  = "audit_logs"
+	EntityName = "audit_log"
+
+	FieldID        = "id"
+	FieldEntity    = "entity"
+	FieldEntityID  = "entity_id"
+	FieldAction    = "action"
+	FieldActor     = "actor"
+	FieldRequestID = "request_id"
+	FieldCreatedAt = "created_at"
+)
+
+// AuditLog records a write made through the generic repository, with the
+// columns it changed.
+type AuditLog struct {
+	ID        string         `db:"id"         query:"filter"`
+	Entity    string         `db:"entity"     query:"sort,filter"`
+	EntityID  string         `db:"entity_id"  query:"filter"`
+	Action    string         `db:"action"     query:"sort,filter"`
+	Actor     *string        `db:"actor"      query:"filter"`
+	RequestID *string        `db:"request_id" query:"filter"`
+	Changes   types.JSONText `db:"changes"`
+	CreatedAt time.Time      `db:"created_at" query:"sort,filter"`
+}
+
+// Unaudited keeps the audit log from auditing itself.
+func (AuditLog) Unaudited() {}
@@ -0,0 +1,31 @@
+package repository
+
+//go:generate go run go.uber.org/mock/mockgen -source=./repository.go -destination=../mocks/repository_mock.go -package=mocks
+
+import (
+	"context"
+	"oil/infras/otel"
+	"oil/infras/postgres"
+	"oil/internal/domains/audit/model"
+	gDto "oil/shared/dto"
+	gRepo "oil/shared/repository"
+)
+
+// AuditLog reads the audit log. Entries are only written by the generic
+// repository, alongside the writes they record.
+type AuditLog interface {
+	GetAll(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.AuditLog, error)
+	GetPage(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.AuditLog, gDto.Pagination, error)
+	WithFilters(filter gDto.FilterGroup, params gDto.QueryParams) (gDto.FilterGroup, error)
+	Count(ctx context.Context, filter gDto.FilterGroup) (int, error)
+}
+
+type repositoryImpl struct {
+	gRepo.Repository[model.AuditLog]
+}
+
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) AuditLog {
+	return &repositoryImpl{
+		Repository: gRepo.NewRepository[model.AuditLog](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oil/config"
+	"oil/infras/otel"
+	"oil/internal/domains/audit/model"
+	"oil/internal/domains/audit/model/dto"
+	"oil/internal/domains/audit/repository"
+	"oil/shared"
+	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	"oil/shared/failure"
+
+	"github.com/rs/zerolog/log"
+)
+
+type AuditLog interface {
+	GetAll(ctx context.Context, params gDto.QueryParams, req dto.GetAuditLogsRequest) (dto.GetAuditLogsResponse, error)
+}
+
+type serviceImpl struct {
+	repo repository.AuditLog
+	cfg  *config.Config
+	otel otel.Otel
+}
+
+func New(repo repository.AuditLog, cfg *config.Config, otel otel.Otel) AuditLog {
+	return &serviceImpl{
+		repo: repo,
+		cfg:  cfg,
+		otel: otel,
+	}
+}
+
+// GetAll lists audit logs, newest first unless another sort is requested. The
+// audit log grows with every write, so lists are not cached.
+func (s *serviceImpl) GetAll(ctx context.Context, params gDto.QueryParams, req dto.GetAuditLogsRequest) (res dto.GetAuditLogsResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GetAll")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	if len(params.Sorts()) == 0 {
+		params.SortBy = model.FieldCreatedAt
+		params.SortDir = gDto.SortDirDesc
+	}
+
+	filter, err := s.repo.WithFilters(req.ToFilter(), params)
+	if err != nil {
+		return res, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	var (
+		models []model.AuditLog
+		page   gDto.Pagination
+	)
+
+	if params.UseCursor() {
+		models, page, err = s.repo.GetPage(ctx, params, filter)
+	} else {
+		models, err = s.repo.GetAll(ctx, params, filter)
+	}
+
+	if errors.Is(err, gDto.ErrInvalidQuery) {
+		return res, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get audit logs")
+
+		return res, fmt.Errorf("failed to get audit logs: %w", err)
+	}
+
+	if !params.UseCursor() || params.IncludeTotal {
+		total, err := s.repo.Count(ctx, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to count audit logs")
+
+			return res, fmt.Errorf("failed to count audit logs: %w", err)
+		}
+
+		page.SetTotal(total, shared.CalculateTotalPage(total, params.Limit))
+	}
+
+	res.FromPage(models, page)
+
+	return res, nil
+}
@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	auditMocks "oil/internal/domains/audit/mocks"
+	"oil/internal/domains/audit/model"
+	"oil/internal/domains/audit/model/dto"
+	"oil/internal/domains/audit/service"
+	gDto "oil/shared/dto"
+	"oil/shared/failure"
+)
+
+func TestAuditLogService_GetAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := auditMocks.NewMockAuditLog(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	svc := service.New(mockRepo, &config.Config{}, mockOtel)
+
+	actor := "superadmin-1"
+	logs := []model.AuditLog{
+		{ID: "log-1", Entity: "user", EntityID: "user-1", Action: "update", Actor: &actor, Changes: types.JSONText(`{"name":{"old":"a","new":"b"}}`)},
+	}
+
+	tests := []struct {
+		name      string
+		params    gDto.QueryParams
+		req       dto.GetAuditLogsRequest
+		setupMock func()
+		wantLen   int
+		wantErr   bool
+		wantCode  int
+	}{
+		{
+			name:   "newest first by default",
+			params: gDto.QueryParams{Page: 1, Limit: 10},
+			req:    dto.GetAuditLogsRequest{Entity: "user", From: "2026-01-01T00:00:00Z"},
+			setupMock: func() {
+				mockRepo.EXPECT().
+					WithFilters(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(filter gDto.FilterGroup, params gDto.QueryParams) (gDto.FilterGroup, error) {
+						assert.Len(t, filter.Filters, 2)
+						assert.Equal(t, model.FieldCreatedAt, params.SortBy)
+						assert.Equal(t, gDto.SortDirDesc, params.SortDir)
+
+						return filter, nil
+					})
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return(logs, nil)
+				mockRepo.EXPECT().Count(gomock.Any(), gomock.Any()).Return(1, nil)
+			},
+			wantLen: 1,
+		},
+		{
+			name:   "invalid filter",
+			params: gDto.QueryParams{Page: 1, Limit: 10},
+			setupMock: func() {
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).Return(gDto.FilterGroup{}, gDto.ErrInvalidQuery)
+			},
+			wantErr:  true,
+			wantCode: 400,
+		},
+		{
+			name:   "repository error",
+			params: gDto.QueryParams{Page: 1, Limit: 10},
+			setupMock: func() {
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).Return(gDto.FilterGroup{}, nil)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			wantErr:  true,
+			wantCode: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.GetAll(context.Background(), tt.params, tt.req)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, res.AuditLogs, tt.wantLen)
+			assert.Equal(t, 1, *res.TotalData)
+			assert.JSONEq(t, `{"name":{"old":"a","new":"b"}}`, string(res.AuditLogs[0].Changes))
+		})
+	}
+}
@@ -2,7 +2,14 @@ package dto
 
 import (
 	"oil/infras/jwt"
+	"oil/infras/oidc"
+	userModel "oil/internal/domains/user/model"
+	"oil/shared/constant"
+	gModel "oil/shared/model"
+	"oil/shared/timezone"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type LoginRequest struct {
@@ -14,9 +21,14 @@ type UpdateLastLoginRequest struct {
 	LastLogin time.Time `db:"last_login" json:"last_login" validate:"required"`
 }
 
+// LoginResponse holds the tokens of a signed in user, or the challenge token
+// of a user who still has to pass multi-factor authentication.
 type LoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken      string `json:"access_token,omitempty"`
+	RefreshToken     string `json:"refresh_token,omitempty"`
+	MFARequired      bool   `json:"mfa_required,omitempty"`
+	MFAToken         string `json:"mfa_token,omitempty"`
+	MFASetupRequired bool   `json:"mfa_setup_required,omitempty"`
 }
 
 func (l *LoginResponse) FromTokenPair(tokenPair *jwt.TokenPair) {
@@ -46,3 +58,147 @@ type ChangePasswordRequest struct {
 type UpdatePasswordRequest struct {
 	Password string `db:"password" json:"password" validate:"required,min=8"`
 }
+
+type RegisterRequest struct {
+	Email    string  `json:"email"               validate:"required,email"`
+	Password string  `json:"password"            validate:"required,min=8"`
+	FullName *string `json:"full_name,omitempty"`
+}
+
+// ToModel returns the registered user, unverified and created by themselves.
+func (r *RegisterRequest) ToModel(hashedPassword string) userModel.User {
+	id := uuid.NewString()
+	now := timezone.Now()
+
+	return userModel.User{
+		ID:       id,
+		Email:    r.Email,
+		Password: &hashedPassword,
+		Level:    constant.RoleUser,
+		FullName: r.FullName,
+		Active:   true,
+		Metadata: gModel.Metadata{
+			CreatedAt:  now,
+			ModifiedAt: now,
+			CreatedBy:  id,
+			ModifiedBy: id,
+		},
+	}
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token"        validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8"`
+}
+
+type UpdateVerifiedRequest struct {
+	IsVerified bool `db:"is_verified" json:"is_verified"`
+}
+
+type GoogleLoginRequest struct {
+	IDToken string `json:"id_token" validate:"required"`
+}
+
+type LinkGoogleRequest struct {
+	GoogleID   string `db:"google_id"   json:"google_id"`
+	IsVerified bool   `db:"is_verified" json:"is_verified"`
+}
+
+// NewGoogleUser returns the user signed up with a Google account, verified by
+// Google and created by themselves. They have no password until they reset it.
+func NewGoogleUser(claims oidc.Claims) userModel.User {
+	id := uuid.NewString()
+	now := timezone.Now()
+
+	user := userModel.User{
+		ID:         id,
+		Email:      claims.Email,
+		Level:      constant.RoleUser,
+		GoogleID:   &claims.Subject,
+		IsVerified: true,
+		Active:     true,
+		Metadata: gModel.Metadata{
+			CreatedAt:  now,
+			ModifiedAt: now,
+			CreatedBy:  id,
+			ModifiedBy: id,
+		},
+	}
+
+	if claims.Name != constant.Empty {
+		user.FullName = &claims.Name
+	}
+
+	if claims.Picture != constant.Empty {
+		user.ProfileImage = &claims.Picture
+	}
+
+	return user
+}
+
+type MFASetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFAVerifyRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type MFAVerifyResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeRequest completes a login with a code of the authenticator, or
+// with a recovery code when it is lost.
+type MFAChallengeRequest struct {
+	MFAToken     string `json:"mfa_token"               validate:"required"`
+	Code         string `json:"code,omitempty"          validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code,omitempty" validate:"required_without=Code"`
+}
+
+type SessionResponse struct {
+	ID          string    `json:"id"`
+	UserAgent   string    `json:"user_agent"`
+	IP          string    `json:"ip"`
+	IssuedAt    time.Time `json:"issued_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+	// ReusedAt is set on sessions ended because one of their refresh tokens
+	// was used twice, which means it was stolen.
+	ReusedAt *time.Time `json:"reused_at,omitempty"`
+}
+
+// NewSessionResponses returns the sessions of a user, marking the one of the
+// current request.
+func NewSessionResponses(sessions []jwt.Session, currentID string) []SessionResponse {
+	res := make([]SessionResponse, 0, len(sessions))
+
+	for _, session := range sessions {
+		res = append(res, SessionResponse{
+			ID:          session.ID,
+			UserAgent:   session.UserAgent,
+			IP:          session.IP,
+			IssuedAt:    session.IssuedAt,
+			RefreshedAt: session.RefreshedAt,
+			ExpiresAt:   session.ExpiresAt,
+			Current:     session.ID == currentID,
+			ReusedAt:    session.ReusedAt,
+		})
+	}
+
+	return res
+}
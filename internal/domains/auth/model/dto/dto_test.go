@@ -2,10 +2,12 @@ package dto_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"oil/infras/jwt"
+	"oil/infras/oidc"
 	"oil/internal/domains/auth/model/dto"
 	"oil/shared/timezone"
 )
@@ -60,3 +62,44 @@ func TestUpdatePasswordRequest(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestNewGoogleUser(t *testing.T) {
+	claims := oidc.Claims{
+		Subject:       "google-sub-1",
+		Email:         "test@example.com",
+		EmailVerified: true,
+		Picture:       "https://example.com/avatar.png",
+	}
+
+	user := dto.NewGoogleUser(claims)
+
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, claims.Email, user.Email)
+	assert.Equal(t, claims.Subject, *user.GoogleID)
+	assert.Equal(t, claims.Picture, *user.ProfileImage)
+	assert.Nil(t, user.FullName)
+	assert.Nil(t, user.Password)
+	assert.True(t, user.IsVerified)
+	assert.True(t, user.Active)
+	assert.Equal(t, user.ID, user.CreatedBy)
+}
+
+func TestNewSessionResponses(t *testing.T) {
+	now := timezone.Now()
+	sessions := []jwt.Session{
+		{ID: "session-1", UserAgent: "Firefox", IP: "10.0.0.1", IssuedAt: now, RefreshedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "session-2", UserAgent: "curl", IP: "10.0.0.2", IssuedAt: now, RefreshedAt: now, ExpiresAt: now.Add(time.Hour), ReusedAt: &now},
+	}
+
+	res := dto.NewSessionResponses(sessions, "session-2")
+
+	assert.Len(t, res, 2)
+	assert.Equal(t, "session-1", res[0].ID)
+	assert.Equal(t, "Firefox", res[0].UserAgent)
+	assert.Equal(t, "10.0.0.1", res[0].IP)
+	assert.False(t, res[0].Current)
+	assert.Nil(t, res[0].ReusedAt)
+	assert.True(t, res[1].Current)
+	assert.Equal(t, &now, res[1].ReusedAt)
+	assert.Empty(t, dto.NewSessionResponses(nil, "session-1"))
+}
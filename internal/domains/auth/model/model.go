@@ -0,0 +1,66 @@
+package model
+
+import "time"
+
+const (
+	EmailVerificationTableName  = "email_verifications"
+	EmailVerificationEntityName = "email_verification"
+	PasswordResetTableName      = "password_resets"
+	PasswordResetEntityName     = "password_reset"
+	MFATableName                = "user_mfa"
+	MFAEntityName               = "user_mfa"
+	RecoveryCodeTableName       = "mfa_recovery_codes"
+	RecoveryCodeEntityName      = "mfa_recovery_code"
+
+	FieldID        = "id"
+	FieldUserID    = "user_id"
+	FieldToken     = "token"
+	FieldExpiresAt = "expires_at"
+	FieldCreatedAt = "created_at"
+	FieldSecret    = "secret"
+	FieldEnabled   = "enabled"
+	FieldEnabledAt = "enabled_at"
+	FieldLastStep  = "last_step"
+)
+
+// EmailVerification is a pending verification of a user's email address. The
+// token is stored as a hash; the plain token is only sent to the user.
+type EmailVerification struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Token     string    `db:"token"      audit:"redact"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// PasswordReset is a pending reset of a user's password. Like verifications,
+// only the hash of the token is stored and it is removed once used.
+type PasswordReset struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Token     string    `db:"token"      audit:"redact"`
+	ExpiresAt time.Time `db:"expires_at"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// MFA is the authenticator of a user. It is enabled once the user confirms
+// the secret with a code; LastStep is the time step of the last accepted code,
+// so that a code is accepted once.
+type MFA struct {
+	ID        string     `db:"id"`
+	UserID    string     `db:"user_id"`
+	Secret    string     `db:"secret"     audit:"redact"`
+	Enabled   bool       `db:"enabled"`
+	LastStep  int64      `db:"last_step"`
+	CreatedAt time.Time  `db:"created_at"`
+	EnabledAt *time.Time `db:"enabled_at"`
+}
+
+// RecoveryCode is a one-time code that replaces the authenticator of a user
+// who lost it. Codes are hashed like passwords and removed once used.
+type RecoveryCode struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	Code      string    `db:"code"       audit:"redact"`
+	CreatedAt time.Time `db:"created_at"`
+}
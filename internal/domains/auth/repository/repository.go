@@ -0,0 +1,80 @@
+package repository
+
+//go:generate go run go.uber.org/mock/mockgen -source=./repository.go -destination=../mocks/repository_mock.go -package=mocks
+
+import (
+	"context"
+	"oil/infras/otel"
+	"oil/infras/postgres"
+	"oil/internal/domains/auth/model"
+	gDto "oil/shared/dto"
+	gRepo "oil/shared/repository"
+)
+
+type EmailVerification interface {
+	Insert(ctx context.Context, model model.EmailVerification) error
+	Get(ctx context.Context, filter gDto.FilterGroup, columns ...string) (model.EmailVerification, error)
+	Delete(ctx context.Context, filter gDto.FilterGroup) error
+	DeleteRows(ctx context.Context, filter gDto.FilterGroup) (int64, error)
+}
+
+type emailVerificationImpl struct {
+	gRepo.Repository[model.EmailVerification]
+}
+
+func NewEmailVerification(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) EmailVerification {
+	return &emailVerificationImpl{
+		Repository: gRepo.NewRepository[model.EmailVerification](model.EmailVerificationEntityName, model.EmailVerificationTableName, model.FieldID, db, tx, otel),
+	}
+}
+
+type PasswordReset interface {
+	Insert(ctx context.Context, model model.PasswordReset) error
+	Get(ctx context.Context, filter gDto.FilterGroup, columns ...string) (model.PasswordReset, error)
+	Delete(ctx context.Context, filter gDto.FilterGroup) error
+	DeleteRows(ctx context.Context, filter gDto.FilterGroup) (int64, error)
+}
+
+type passwordResetImpl struct {
+	gRepo.Repository[model.PasswordReset]
+}
+
+func NewPasswordReset(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) PasswordReset {
+	return &passwordResetImpl{
+		Repository: gRepo.NewRepository[model.PasswordReset](model.PasswordResetEntityName, model.PasswordResetTableName, model.FieldID, db, tx, otel),
+	}
+}
+
+type MFA interface {
+	Insert(ctx context.Context, model model.MFA) error
+	Get(ctx context.Context, filter gDto.FilterGroup, columns ...string) (model.MFA, error)
+	Update(ctx context.Context, mod map[string]any, filter gDto.FilterGroup) error
+	UpdateRows(ctx context.Context, mod map[string]any, filter gDto.FilterGroup) (int64, error)
+}
+
+type mfaImpl struct {
+	gRepo.Repository[model.MFA]
+}
+
+func NewMFA(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) MFA {
+	return &mfaImpl{
+		Repository: gRepo.NewRepository[model.MFA](model.MFAEntityName, model.MFATableName, model.FieldID, db, tx, otel),
+	}
+}
+
+type RecoveryCode interface {
+	InsertBulk(ctx context.Context, models []model.RecoveryCode) error
+	GetAll(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.RecoveryCode, error)
+	Delete(ctx context.Context, filter gDto.FilterGroup) error
+	DeleteRows(ctx context.Context, filter gDto.FilterGroup) (int64, error)
+}
+
+type recoveryCodeImpl struct {
+	gRepo.Repository[model.RecoveryCode]
+}
+
+func NewRecoveryCode(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) RecoveryCode {
+	return &recoveryCodeImpl{
+		Repository: gRepo.NewRepository[model.RecoveryCode](model.RecoveryCodeEntityName, model.RecoveryCodeTableName, model.FieldID, db, tx, otel),
+	}
+}
@@ -2,80 +2,849 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"oil/config"
 	"oil/infras/jwt"
+	"oil/infras/mail"
+	"oil/infras/oidc"
 	"oil/infras/otel"
+	"oil/infras/postgres"
+	"oil/internal/domains/auth/model"
 	"oil/internal/domains/auth/model/dto"
+	"oil/internal/domains/auth/repository"
 	userModel "oil/internal/domains/user/model"
-	userDto "oil/internal/domains/user/model/dto"
 	userRepo "oil/internal/domains/user/repository"
 	"oil/shared"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	"oil/shared/failure"
+	"oil/shared/lockout"
 	"oil/shared/password"
 	"oil/shared/timezone"
+	"oil/shared/token"
+	"oil/shared/totp"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	defaultVerificationExpireMin = 60
+	defaultResetExpireMin        = 60
+
+	defaultLockoutAttempts   = 5
+	defaultLockoutIPAttempts = 20
+	defaultLockoutMin        = 1
+	defaultLockoutMaxMin     = 60
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+
+	// mfaChallengeAttempts is how many wrong codes a challenge token takes
+	// before it is revoked.
+	mfaChallengeAttempts = 5
+
+	verificationSubject = "Verify your email address"
+	resetSubject        = "Reset your password"
+	registeredSubject   = "You already have an account"
+
+	errInvalidVerification = "invalid or expired verification token"
+	errInvalidReset        = "invalid or expired password reset token"
+	errInvalidMFAToken     = "invalid or expired mfa token"
+	errInvalidMFACode      = "invalid mfa code"
+	errInvalidRecoveryCode = "invalid recovery code"
+	errMFAEnabled          = "multi-factor authentication is already enabled"
+	errInvalidCredentials  = "invalid email or password"
+	errLockedOut           = "too many failed login attempts, try again later"
+)
+
+// errTokenUsed reports a verification or reset token that a concurrent
+// request used up first.
+var errTokenUsed = errors.New("token already used")
+
 type Auth interface {
+	Register(ctx context.Context, req dto.RegisterRequest) error
+	VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) error
+	ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) error
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) error
 	Login(ctx context.Context, req dto.LoginRequest) (dto.LoginResponse, error)
+	GoogleLogin(ctx context.Context, req dto.GoogleLoginRequest) (dto.LoginResponse, error)
+	SetupMFA(ctx context.Context, userID string) (dto.MFASetupResponse, error)
+	VerifyMFA(ctx context.Context, req dto.MFAVerifyRequest, userID string) (dto.MFAVerifyResponse, error)
+	ChallengeMFA(ctx context.Context, req dto.MFAChallengeRequest) (dto.LoginResponse, error)
 	RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (dto.RefreshTokenResponse, error)
 	ChangePassword(ctx context.Context, req dto.ChangePasswordRequest, userID string) error
+	Logout(ctx context.Context, userID, sessionID string) error
+	LogoutAll(ctx context.Context, userID string) error
+	Sessions(ctx context.Context, userID, sessionID string) ([]dto.SessionResponse, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	JWKS(ctx context.Context) ([]byte, error)
+}
+
+type serviceImpl struct {
+	userRepo         userRepo.User
+	verificationRepo repository.EmailVerification
+	resetRepo        repository.PasswordReset
+	mfaRepo          repository.MFA
+	recoveryRepo     repository.RecoveryCode
+	tx               postgres.TxManager
+	mailer           mail.Sender
+	google           oidc.Verifier
+	lockout          lockout.Lockout
+	cfg              *config.Config
+	otel             otel.Otel
+	jwtService       jwt.JWT
+}
+
+func New(userRepo userRepo.User, verificationRepo repository.EmailVerification, resetRepo repository.PasswordReset, mfaRepo repository.MFA, recoveryRepo repository.RecoveryCode, tx postgres.TxManager, mailer mail.Sender, google oidc.Verifier, guard lockout.Lockout, cfg *config.Config, otel otel.Otel, jwt jwt.JWT) Auth {
+	return &serviceImpl{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		resetRepo:        resetRepo,
+		mfaRepo:          mfaRepo,
+		recoveryRepo:     recoveryRepo,
+		tx:               tx,
+		mailer:           mailer,
+		google:           google,
+		lockout:          guard,
+		cfg:              cfg,
+		otel:             otel,
+		jwtService:       jwt,
+	}
+}
+
+// Register creates an unverified user and emails them a verification token.
+// The user is kept when the email cannot be sent; they can ask for another one.
+// Registered emails get the same answer, and their owner is emailed a notice
+// instead, so that the endpoint does not reveal which emails are registered.
+func (s *serviceImpl) Register(ctx context.Context, req dto.RegisterRequest) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Register")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	hashedPassword, err := password.Hash(req.Password)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to hash password")
+
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	// Deleted users keep their email until they are purged.
+	emailFilter := byEmail(req.Email)
+	emailFilter.WithDeleted = true
+
+	exists, err := s.userRepo.Exist(ctx, emailFilter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check if user exists")
+
+		return fmt.Errorf("failed to check if user exists: %w", err)
+	}
+
+	if exists {
+		s.notifyRegistered(ctx, req.Email)
+
+		return nil
+	}
+
+	user := req.ToModel(hashedPassword)
+
+	var verifyToken string
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Insert(ctx, user); err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		verifyToken, err = s.issueVerification(ctx, user.ID)
+
+		return err
+	})
+	if err != nil {
+		// A concurrent registration took the email after it was checked.
+		if shared.IsPqError(err, constant.PqErrorCodeUniqueViolation) {
+			s.notifyRegistered(ctx, req.Email)
+
+			return nil
+		}
+
+		log.Error().Err(err).Msg("failed to register user")
+
+		return fmt.Errorf("failed to register user: %w", err)
+	}
+
+	if err := s.sendVerification(ctx, user.Email, verifyToken); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("failed to send verification email")
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the user of a verification token as verified and discards
+// their pending verifications.
+func (s *serviceImpl) VerifyEmail(ctx context.Context, req dto.VerifyEmailRequest) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".VerifyEmail")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	verification, err := s.verificationRepo.Get(ctx, byToken(req.Token, model.EmailVerificationTableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get email verification")
+
+		return fmt.Errorf("failed to get email verification: %w", err)
+	}
+
+	if verification.ID == constant.Empty {
+		return failure.BadRequestFromString(errInvalidVerification)
+	}
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := useToken(ctx, s.verificationRepo.DeleteRows, req.Token, model.EmailVerificationTableName); err != nil {
+			return err
+		}
+
+		verified := shared.TransformFields(dto.UpdateVerifiedRequest{IsVerified: true}, verification.UserID)
+
+		if err := s.userRepo.Update(ctx, verified, shared.FilterByID(verification.UserID, userModel.FieldID, userModel.TableName)); err != nil {
+			return fmt.Errorf("failed to verify user: %w", err)
+		}
+
+		return s.verificationRepo.Delete(ctx, byUser(verification.UserID, model.EmailVerificationTableName)) //nolint:wrapcheck
+	})
+	if err != nil {
+		if errors.Is(err, errTokenUsed) {
+			return failure.BadRequestFromString(errInvalidVerification)
+		}
+
+		log.Error().Err(err).Msg("failed to verify email")
+
+		return fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	return nil
+}
+
+// ResendVerification replaces the pending verification of an unverified user
+// and emails the new token. Unknown and verified emails and failed sends are
+// not reported, so that the endpoint does not reveal which emails are registered.
+func (s *serviceImpl) ResendVerification(ctx context.Context, req dto.ResendVerificationRequest) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".ResendVerification")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, err := s.userRepo.Get(ctx, byEmail(req.Email))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty || user.IsVerified {
+		return nil
+	}
+
+	var verifyToken string
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		verifyToken, err = s.issueVerification(ctx, user.ID)
+
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to issue email verification")
+
+		return fmt.Errorf("failed to issue email verification: %w", err)
+	}
+
+	if err := s.sendVerification(ctx, user.Email, verifyToken); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("failed to send verification email")
+	}
+
+	return nil
+}
+
+// ForgotPassword replaces the pending password reset of a user and emails the
+// new token. Unknown emails and failures past the lookup are not reported, so
+// that the endpoint does not reveal which emails are registered.
+func (s *serviceImpl) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".ForgotPassword")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, err := s.userRepo.Get(ctx, byEmail(req.Email))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty || !user.Active {
+		return nil
+	}
+
+	// The reset is issued and sent after answering, so that registered emails
+	// are answered as fast as unknown ones.
+	go s.forgotPassword(context.WithoutCancel(ctx), user)
+
+	return nil
+}
+
+// forgotPassword issues a password reset for user and emails it. Failures are
+// logged only, since ForgotPassword has already answered.
+func (s *serviceImpl) forgotPassword(ctx context.Context, user userModel.User) {
+	var resetToken string
+
+	err := s.tx.Do(ctx, func(ctx context.Context) (err error) {
+		resetToken, err = s.issueReset(ctx, user.ID)
+
+		return err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("failed to issue password reset")
+
+		return
+	}
+
+	if err := s.sendReset(ctx, user.Email, resetToken); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("failed to send password reset email")
+	}
+}
+
+// ResetPassword sets a new password with a reset token, discards the pending
+// resets of the user and revokes all their tokens. The token is single-use:
+// it is removed first in the unit of work of the password update, so that of
+// concurrent resets with it only one goes through.
+func (s *serviceImpl) ResetPassword(ctx context.Context, req dto.ResetPasswordRequest) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".ResetPassword")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	reset, err := s.resetRepo.Get(ctx, byToken(req.Token, model.PasswordResetTableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get password reset")
+
+		return fmt.Errorf("failed to get password reset: %w", err)
+	}
+
+	if reset.ID == constant.Empty {
+		return failure.BadRequestFromString(errInvalidReset)
+	}
+
+	// Deactivated users cannot ask for a reset, nor use one issued before.
+	user, err := s.userRepo.Get(ctx, shared.FilterByID(reset.UserID, userModel.FieldID, userModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty || !user.Active {
+		return failure.BadRequestFromString(errInvalidReset)
+	}
+
+	hashedPassword, err := password.Hash(req.NewPassword)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to hash new password")
+
+		return fmt.Errorf("failed to hash new password: %w", err)
+	}
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		if err := useToken(ctx, s.resetRepo.DeleteRows, req.Token, model.PasswordResetTableName); err != nil {
+			return err
+		}
+
+		updatedFields := shared.TransformFields(dto.UpdatePasswordRequest{Password: hashedPassword}, reset.UserID)
+
+		if err := s.userRepo.Update(ctx, updatedFields, shared.FilterByID(reset.UserID, userModel.FieldID, userModel.TableName)); err != nil {
+			return fmt.Errorf("failed to update password: %w", err)
+		}
+
+		if err := s.resetRepo.Delete(ctx, byUser(reset.UserID, model.PasswordResetTableName)); err != nil {
+			return fmt.Errorf("failed to delete password resets: %w", err)
+		}
+
+		// Revoked last, so that the reset is rolled back when sessions stay valid.
+		if err := s.jwtService.RevokeAllUserTokens(ctx, reset.UserID); err != nil {
+			return fmt.Errorf("failed to revoke user tokens: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errTokenUsed) {
+			return failure.BadRequestFromString(errInvalidReset)
+		}
+
+		log.Error().Err(err).Str("user_id", reset.UserID).Msg("failed to reset password")
+
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceImpl) Login(ctx context.Context, req dto.LoginRequest) (res dto.LoginResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Login")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	ip, _ := ctx.Value(constant.ContextKeyClientIP).(string)
+
+	if err := s.checkLockout(ctx, req.Email, ip); err != nil {
+		return res, err
+	}
+
+	emailFilter := gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    userModel.FieldEmail,
+				Operator: gDto.FilterOperatorEq,
+				Value:    req.Email,
+				Table:    userModel.TableName,
+			},
+		},
+	}
+
+	user, err := s.userRepo.Get(ctx, emailFilter)
+	if err != nil {
+		log.Warn().Str("email", req.Email).Msg("login attempt with non-existent email")
+		s.failLogin(ctx, req.Email, ip)
+
+		return res, failure.BadRequestFromString(errInvalidCredentials)
+	}
+
+	if err := password.Verify(req.Password, user.PasswordHash()); err != nil {
+		log.Warn().Str("email", req.Email).Msg("login attempt with wrong password")
+		s.failLogin(ctx, req.Email, ip)
+
+		return res, failure.BadRequestFromString(errInvalidCredentials)
+	}
+
+	if !user.Active {
+		return res, failure.BadRequestFromString("user account is deactivated")
+	}
+
+	if s.cfg.App.Auth.RequireVerifiedEmail && !user.IsVerified {
+		return res, failure.Forbidden("email address is not verified")
+	}
+
+	res, err = s.login(ctx, user)
+	if err != nil {
+		return res, err
+	}
+
+	// The failures of a user with a second factor count until they pass it.
+	if !res.MFARequired {
+		s.resetLockout(ctx, req.Email)
+	}
+
+	return res, nil
+}
+
+// GoogleLogin signs a user in with a Google ID token. The Google account is
+// linked to the user with its verified email, who is created when there is
+// none.
+func (s *serviceImpl) GoogleLogin(ctx context.Context, req dto.GoogleLoginRequest) (res dto.LoginResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GoogleLogin")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	claims, err := s.google.Verify(ctx, req.IDToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("google login attempt with invalid id token")
+
+		return res, failure.Unauthorized("invalid google id token")
+	}
+
+	if claims.Email == constant.Empty || !claims.EmailVerified {
+		return res, failure.Forbidden("google account email is not verified")
+	}
+
+	user, err := s.googleUser(ctx, claims)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get google user")
+
+		return res, fmt.Errorf("failed to get google user: %w", err)
+	}
+
+	if !user.Active {
+		return res, failure.BadRequestFromString("user account is deactivated")
+	}
+
+	return s.login(ctx, user)
+}
+
+// SetupMFA starts the enrolment of a user in multi-factor authentication with a
+// new authenticator secret, which replaces the one of an unfinished enrolment.
+func (s *serviceImpl) SetupMFA(ctx context.Context, userID string) (res dto.MFASetupResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".SetupMFA")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, err := s.userRepo.Get(ctx, shared.FilterByID(userID, userModel.FieldID, userModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return res, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty {
+		return res, failure.NotFound("user not found")
+	}
+
+	current, err := s.mfaRepo.Get(ctx, byUser(userID, model.MFATableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get mfa")
+
+		return res, fmt.Errorf("failed to get mfa: %w", err)
+	}
+
+	if current.Enabled {
+		return res, failure.Conflict(errMFAEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate mfa secret")
+
+		return res, fmt.Errorf("failed to generate mfa secret: %w", err)
+	}
+
+	if current.ID == constant.Empty {
+		err = s.mfaRepo.Insert(ctx, model.MFA{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Secret:    secret,
+			CreatedAt: timezone.Now(),
+		})
+	} else {
+		err = s.mfaRepo.Update(ctx, map[string]any{model.FieldSecret: secret, model.FieldLastStep: 0}, shared.FilterByID(current.ID, model.FieldID, model.MFATableName))
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to save mfa secret")
+
+		return res, fmt.Errorf("failed to save mfa secret: %w", err)
+	}
+
+	res.Secret = secret
+	res.URI = totp.URI(s.cfg.App.Name, user.Email, secret)
+
+	return res, nil
+}
+
+// VerifyMFA finishes the enrolment of a user with a code of their authenticator
+// and returns their recovery codes, which are only shown this once.
+func (s *serviceImpl) VerifyMFA(ctx context.Context, req dto.MFAVerifyRequest, userID string) (res dto.MFAVerifyResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".VerifyMFA")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	current, err := s.mfaRepo.Get(ctx, byUser(userID, model.MFATableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get mfa")
+
+		return res, fmt.Errorf("failed to get mfa: %w", err)
+	}
+
+	if current.ID == constant.Empty {
+		return res, failure.BadRequestFromString("multi-factor authentication setup has not been started")
+	}
+
+	if current.Enabled {
+		return res, failure.Conflict(errMFAEnabled)
+	}
+
+	now := timezone.Now()
+
+	step, err := totp.Validate(current.Secret, req.Code, now, current.LastStep)
+	if err != nil {
+		return res, failure.BadRequestFromString(errInvalidMFACode)
+	}
+
+	codes, hashed, err := newRecoveryCodes(userID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate recovery codes")
+
+		return res, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		enabled := map[string]any{model.FieldEnabled: true, model.FieldEnabledAt: now, model.FieldLastStep: step}
+
+		if err := s.mfaRepo.Update(ctx, enabled, shared.FilterByID(current.ID, model.FieldID, model.MFATableName)); err != nil {
+			return fmt.Errorf("failed to enable mfa: %w", err)
+		}
+
+		if err := s.recoveryRepo.Delete(ctx, byUser(userID, model.RecoveryCodeTableName)); err != nil {
+			return fmt.Errorf("failed to delete recovery codes: %w", err)
+		}
+
+		if err := s.recoveryRepo.InsertBulk(ctx, hashed); err != nil {
+			return fmt.Errorf("failed to create recovery codes: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to enable mfa")
+
+		return res, fmt.Errorf("failed to enable mfa: %w", err)
+	}
+
+	res.RecoveryCodes = codes
+
+	return res, nil
+}
+
+// ChallengeMFA completes the login of a user with MFA enabled with the
+// challenge token of the first step and a code of their authenticator or one
+// of their recovery codes. The challenge token is single-use.
+func (s *serviceImpl) ChallengeMFA(ctx context.Context, req dto.MFAChallengeRequest) (res dto.LoginResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".ChallengeMFA")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	claims, err := s.jwtService.ValidateToken(ctx, req.MFAToken, jwt.ChallengeToken)
+	if err != nil {
+		log.Warn().Err(err).Msg("mfa challenge with invalid token")
+
+		return res, failure.Unauthorized(errInvalidMFAToken)
+	}
+
+	user, err := s.userRepo.Get(ctx, shared.FilterByID(claims.UserID, userModel.FieldID, userModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return res, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty {
+		return res, failure.Unauthorized(errInvalidMFAToken)
+	}
+
+	if !user.Active {
+		return res, failure.BadRequestFromString("user account is deactivated")
+	}
+
+	ip, _ := ctx.Value(constant.ContextKeyClientIP).(string)
+
+	if err := s.checkLockout(ctx, user.Email, ip); err != nil {
+		return res, err
+	}
+
+	current, err := s.mfaRepo.Get(ctx, byUser(user.ID, model.MFATableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get mfa")
+
+		return res, fmt.Errorf("failed to get mfa: %w", err)
+	}
+
+	if !current.Enabled {
+		return res, failure.Unauthorized(errInvalidMFAToken)
+	}
+
+	if req.Code != constant.Empty {
+		err = s.useCode(ctx, current, req.Code)
+	} else {
+		err = s.useRecoveryCode(ctx, user.ID, req.RecoveryCode)
+	}
+
+	if err != nil {
+		log.Warn().Err(err).Str("user_id", user.ID).Msg("mfa challenge failed")
+
+		if failure.GetCode(err) == http.StatusBadRequest {
+			s.failChallenge(ctx, req.MFAToken, claims.TokenID, user.Email, ip)
+		}
+
+		return res, err
+	}
+
+	// Redeemed last, so that wrong codes do not use the token up, and atomically,
+	// so that of concurrent challenges with it only one signs in.
+	if err := s.jwtService.RedeemToken(ctx, req.MFAToken, jwt.ChallengeToken); err != nil {
+		if errors.Is(err, jwt.ErrRevokedToken) || errors.Is(err, jwt.ErrExpiredToken) {
+			return res, failure.Unauthorized(errInvalidMFAToken)
+		}
+
+		log.Error().Err(err).Msg("failed to redeem mfa token")
+
+		return res, fmt.Errorf("failed to redeem mfa token: %w", err)
+	}
+
+	s.resetLockout(ctx, user.Email)
+
+	return s.signIn(ctx, user, true)
+}
+
+// checkLockout refuses logins to an account or from an IP address that failed
+// too often. Accounts are counted by email whether they exist or not, so that
+// the answer does not tell. Logins go on when the cache fails, as requests do
+// in the rate limiter.
+func (s *serviceImpl) checkLockout(ctx context.Context, email, ip string) error {
+	keys := []string{lockout.AccountKey(email)}
+	if ip != constant.Empty {
+		keys = append(keys, lockout.IPKey(ip))
+	}
+
+	now := timezone.Now()
+
+	for _, key := range keys {
+		status, err := s.lockout.Status(ctx, key)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to get lockout")
+
+			continue
+		}
+
+		if status.Locked(now) {
+			log.Warn().Str("key", key).Time("locked_until", *status.LockedUntil).Msg("login attempt while locked out")
+
+			return failure.TooManyRequests(errLockedOut)
+		}
+	}
+
+	return nil
 }
 
-type serviceImpl struct {
-	userRepo   userRepo.User
-	cfg        *config.Config
-	otel       otel.Otel
-	jwtService jwt.JWT
+// failLogin counts a failed login against the account and the IP address.
+func (s *serviceImpl) failLogin(ctx context.Context, email, ip string) {
+	account, address := s.lockoutPolicies()
+
+	status, err := s.lockout.Fail(ctx, lockout.AccountKey(email), account)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count failed login")
+	} else if status.LockedUntil != nil {
+		log.Warn().Str("email", email).Int("failed_attempts", status.FailedAttempts).Time("locked_until", *status.LockedUntil).Msg("account locked out")
+	}
+
+	if ip == constant.Empty {
+		return
+	}
+
+	status, err = s.lockout.Fail(ctx, lockout.IPKey(ip), address)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count failed login")
+	} else if status.LockedUntil != nil {
+		log.Warn().Str("ip", ip).Int("failed_attempts", status.FailedAttempts).Time("locked_until", *status.LockedUntil).Msg("ip address locked out")
+	}
+}
+
+// failChallenge counts a wrong code against the account and the IP address,
+// as a failed login, and revokes the challenge token once it took too many, so
+// that a token cannot be used to try every code.
+func (s *serviceImpl) failChallenge(ctx context.Context, challengeToken, tokenID, email, ip string) {
+	s.failLogin(ctx, email, ip)
+
+	policy, _ := s.lockoutPolicies()
+	policy.Attempts = mfaChallengeAttempts
+
+	status, err := s.lockout.Fail(ctx, lockout.ChallengeKey(tokenID), policy)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count failed mfa challenge")
+
+		return
+	}
+
+	if status.LockedUntil == nil {
+		return
+	}
+
+	if err := s.jwtService.RevokeToken(ctx, challengeToken, jwt.ChallengeToken); err != nil {
+		log.Error().Err(err).Msg("failed to revoke mfa token")
+	}
 }
 
-func New(userRepo userRepo.User, cfg *config.Config, otel otel.Otel, jwt jwt.JWT) Auth {
-	return &serviceImpl{
-		userRepo:   userRepo,
-		cfg:        cfg,
-		otel:       otel,
-		jwtService: jwt,
+// resetLockout forgets the failed logins of an account once its user signed in.
+func (s *serviceImpl) resetLockout(ctx context.Context, email string) {
+	if err := s.lockout.Reset(ctx, lockout.AccountKey(email)); err != nil {
+		log.Error().Err(err).Msg("failed to reset lockout")
 	}
 }
 
-func (s *serviceImpl) Login(ctx context.Context, req dto.LoginRequest) (res dto.LoginResponse, err error) {
-	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Login")
-	defer scope.End()
-	defer scope.TraceIfError(err)
+// lockoutPolicies returns the lockout policies of accounts and IP addresses.
+// IP addresses are allowed more attempts, as users may share one.
+func (s *serviceImpl) lockoutPolicies() (account, ip lockout.Policy) {
+	cfg := s.cfg.App.Auth.Lockout
 
-	emailFilter := gDto.FilterGroup{
-		Filters: []any{
-			gDto.Filter{
-				Field:    userModel.FieldEmail,
-				Operator: gDto.FilterOperatorEq,
-				Value:    req.Email,
-				Table:    userModel.TableName,
-			},
-		},
+	attempts := cfg.Attempts
+	if attempts <= 0 {
+		attempts = defaultLockoutAttempts
 	}
 
-	user, err := s.userRepo.Get(ctx, emailFilter)
+	ipAttempts := cfg.IPAttempts
+	if ipAttempts <= 0 {
+		ipAttempts = defaultLockoutIPAttempts
+	}
+
+	lockoutMin := cfg.Min
+	if lockoutMin <= 0 {
+		lockoutMin = defaultLockoutMin
+	}
+
+	maxMin := cfg.MaxMin
+	if maxMin <= 0 {
+		maxMin = defaultLockoutMaxMin
+	}
+
+	account = lockout.Policy{
+		Attempts: attempts,
+		Lockout:  time.Duration(lockoutMin) * time.Minute,
+		Max:      time.Duration(max(maxMin, lockoutMin)) * time.Minute,
+	}
+
+	ip = account
+	ip.Attempts = ipAttempts
+
+	return account, ip
+}
+
+// login finishes the first login step of a user: users with MFA enabled get a
+// challenge token to pass it, the others their tokens.
+func (s *serviceImpl) login(ctx context.Context, user userModel.User) (res dto.LoginResponse, err error) {
+	current, err := s.mfaRepo.Get(ctx, byUser(user.ID, model.MFATableName))
 	if err != nil {
-		log.Warn().Str("email", req.Email).Msg("login attempt with non-existent email")
+		log.Error().Err(err).Msg("failed to get mfa")
 
-		return res, failure.BadRequestFromString("invalid email or password")
+		return res, fmt.Errorf("failed to get mfa: %w", err)
 	}
 
-	if err := password.Verify(req.Password, user.Password); err != nil {
-		log.Warn().Str("email", req.Email).Msg("login attempt with wrong password")
+	if current.Enabled {
+		challengeToken, err := s.jwtService.GenerateChallengeToken(ctx, user.ID, user.Email, user.Level)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to generate mfa token")
+
+			return res, fmt.Errorf("failed to generate mfa token: %w", err)
+		}
 
-		return res, failure.BadRequestFromString("invalid email or password")
+		res.MFARequired = true
+		res.MFAToken = challengeToken
+
+		return res, nil
 	}
 
-	if !user.Active {
-		return res, failure.BadRequestFromString("user account is deactivated")
+	res, err = s.signIn(ctx, user, false)
+	if err != nil {
+		return res, err
 	}
 
-	tokenPair, err := s.jwtService.GenerateTokenPair(ctx, user.ID, user.Email, user.Level)
+	// Their tokens only let them enrol until they do.
+	res.MFASetupRequired = shared.RequiresMFA(s.cfg, user.Level)
+
+	return res, nil
+}
+
+// signIn issues the token pair of a signed in user and records the login.
+func (s *serviceImpl) signIn(ctx context.Context, user userModel.User, mfa bool) (res dto.LoginResponse, err error) {
+	tokenPair, err := s.jwtService.GenerateTokenPair(ctx, user.ID, user.Email, user.Level, mfa)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to generate tokens")
 
@@ -85,21 +854,110 @@ func (s *serviceImpl) Login(ctx context.Context, req dto.LoginRequest) (res dto.
 	lastLogin := dto.UpdateLastLoginRequest{LastLogin: timezone.Now()}
 	updatedFields := shared.TransformFields(lastLogin, user.ID)
 
-	if err := s.userRepo.Update(ctx, updatedFields, emailFilter); err != nil {
+	if err := s.userRepo.Update(ctx, updatedFields, shared.FilterByID(user.ID, userModel.FieldID, userModel.TableName)); err != nil {
 		log.Warn().Err(err).Str("user_id", user.ID).Msg("failed to update last login")
 
 		return res, fmt.Errorf("failed to update last login: %w", err)
 	}
 
-	var userResponse userDto.UserResponse
-
-	userResponse.FromModel(user)
-
 	res.FromTokenPair(tokenPair)
 
 	return res, nil
 }
 
+// googleUser returns the user of a Google account: the user it is linked to,
+// else the user with its email, whom it is linked to, else a new user.
+func (s *serviceImpl) googleUser(ctx context.Context, claims oidc.Claims) (userModel.User, error) {
+	user, err := s.existingGoogleUser(ctx, claims)
+	if err != nil || user.ID != constant.Empty {
+		return user, err
+	}
+
+	user = dto.NewGoogleUser(claims)
+
+	if err := s.userRepo.Insert(ctx, user); err != nil {
+		if !shared.IsPqError(err, constant.PqErrorCodeUniqueViolation) {
+			return user, fmt.Errorf("failed to create user: %w", err)
+		}
+
+		// A concurrent login or registration took the Google account or the
+		// email after they were looked up, so the user is read again.
+		return s.existingGoogleUser(ctx, claims)
+	}
+
+	return user, nil
+}
+
+// existingGoogleUser returns the user a Google account is linked to, else the
+// user with its email, whom it is linked to. The user is empty when there is
+// neither.
+func (s *serviceImpl) existingGoogleUser(ctx context.Context, claims oidc.Claims) (userModel.User, error) {
+	user, err := s.userRepo.Get(ctx, byGoogleID(claims.Subject))
+	if err != nil {
+		return user, fmt.Errorf("failed to get user by google id: %w", err)
+	}
+
+	if user.ID != constant.Empty {
+		return user, nil
+	}
+
+	// Deleted users keep their email until they are purged.
+	emailFilter := byEmail(claims.Email)
+	emailFilter.WithDeleted = true
+
+	user, err = s.userRepo.Get(ctx, emailFilter)
+	if err != nil {
+		return user, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	if user.ID == constant.Empty {
+		return user, nil
+	}
+
+	if user.DeletedAt != nil {
+		return user, failure.Forbidden("user account is deleted")
+	}
+
+	if user.GoogleID != nil {
+		return user, failure.Conflict("email is linked to another google account")
+	}
+
+	if err := s.linkGoogle(ctx, user, claims.Subject); err != nil {
+		return user, err
+	}
+
+	user.GoogleID = &claims.Subject
+	user.IsVerified = true
+
+	return user, nil
+}
+
+// linkGoogle links a Google account to a user, whose email it verifies. An
+// unverified user may have been registered by someone else with the email, so
+// their password and tokens stop working once the owner proves it is theirs.
+func (s *serviceImpl) linkGoogle(ctx context.Context, user userModel.User, googleID string) error {
+	linked := shared.TransformFields(dto.LinkGoogleRequest{GoogleID: googleID, IsVerified: true}, user.ID)
+	if !user.IsVerified {
+		linked[userModel.FieldPassword] = nil
+	}
+
+	return s.tx.Do(ctx, func(ctx context.Context) error { //nolint:wrapcheck
+		if err := s.userRepo.Update(ctx, linked, shared.FilterByID(user.ID, userModel.FieldID, userModel.TableName)); err != nil {
+			return fmt.Errorf("failed to link google account: %w", err)
+		}
+
+		if user.IsVerified {
+			return nil
+		}
+
+		if err := s.jwtService.RevokeAllUserTokens(ctx, user.ID); err != nil {
+			return fmt.Errorf("failed to revoke user tokens: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (s *serviceImpl) RefreshToken(ctx context.Context, req dto.RefreshTokenRequest) (res dto.RefreshTokenResponse, err error) {
 	_, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".RefreshToken")
 	defer scope.End()
@@ -109,6 +967,10 @@ func (s *serviceImpl) RefreshToken(ctx context.Context, req dto.RefreshTokenRequ
 	if err != nil {
 		log.Warn().Err(err).Msg("failed to refresh tokens")
 
+		if errors.Is(err, jwt.ErrTokenReused) {
+			return res, failure.Unauthorized("refresh token was already used, the session has been revoked")
+		}
+
 		return res, failure.Unauthorized("invalid refresh token")
 	}
 
@@ -145,7 +1007,7 @@ func (s *serviceImpl) ChangePassword(ctx context.Context, req dto.ChangePassword
 		return failure.NotFound("user not found")
 	}
 
-	if err := password.Verify(req.CurrentPassword, model.Password); err != nil {
+	if err := password.Verify(req.CurrentPassword, model.PasswordHash()); err != nil {
 		return failure.BadRequestFromString("current password is incorrect")
 	}
 
@@ -167,3 +1029,378 @@ func (s *serviceImpl) ChangePassword(ctx context.Context, req dto.ChangePassword
 
 	return nil
 }
+
+// useCode accepts a code of the authenticator once. The last step only moves
+// forward, so that of concurrent logins with a code only one goes through.
+func (s *serviceImpl) useCode(ctx context.Context, current model.MFA, code string) error {
+	step, err := totp.Validate(current.Secret, code, timezone.Now(), current.LastStep)
+	if err != nil {
+		return failure.BadRequestFromString(errInvalidMFACode)
+	}
+
+	unused := gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{Field: model.FieldID, Operator: gDto.FilterOperatorEq, Value: current.ID, Table: model.MFATableName},
+			gDto.Filter{ArgName: "used_step", Field: model.FieldLastStep, Operator: gDto.FilterOperatorLess, Value: step, Table: model.MFATableName},
+		},
+		Operator: gDto.FilterGroupOperatorAnd,
+	}
+
+	updated, err := s.mfaRepo.UpdateRows(ctx, map[string]any{model.FieldLastStep: step}, unused)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa: %w", err)
+	}
+
+	if updated != 1 {
+		return failure.BadRequestFromString(errInvalidMFACode)
+	}
+
+	return nil
+}
+
+// useRecoveryCode accepts a recovery code of a user and removes it, so that of
+// concurrent logins with it only one goes through.
+func (s *serviceImpl) useRecoveryCode(ctx context.Context, userID, code string) error {
+	codes, err := s.recoveryRepo.GetAll(ctx, gDto.QueryParams{}, byUser(userID, model.RecoveryCodeTableName))
+	if err != nil {
+		return fmt.Errorf("failed to get recovery codes: %w", err)
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	for _, recovery := range codes {
+		if password.Verify(code, recovery.Code) != nil {
+			continue
+		}
+
+		deleted, err := s.recoveryRepo.DeleteRows(ctx, shared.FilterByID(recovery.ID, model.FieldID, model.RecoveryCodeTableName))
+		if err != nil {
+			return fmt.Errorf("failed to delete recovery code: %w", err)
+		}
+
+		// A concurrent login used the code first.
+		if deleted != 1 {
+			break
+		}
+
+		return nil
+	}
+
+	return failure.BadRequestFromString(errInvalidRecoveryCode)
+}
+
+// newRecoveryCodes returns a new set of recovery codes of a user, in plain text
+// for the user and hashed for storage.
+func newRecoveryCodes(userID string) ([]string, []model.RecoveryCode, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashed := make([]model.RecoveryCode, recoveryCodeCount)
+	now := timezone.Now()
+
+	for i := range codes {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		encoded := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+		codes[i] = encoded[:len(encoded)/2] + "-" + encoded[len(encoded)/2:]
+
+		hash, err := password.Hash(codes[i])
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+
+		hashed[i] = model.RecoveryCode{ID: uuid.NewString(), UserID: userID, Code: hash, CreatedAt: now}
+	}
+
+	return codes, hashed, nil
+}
+
+// issueVerification replaces the pending verifications of a user with a new
+// one and returns its token. It is meant to run in a unit of work.
+func (s *serviceImpl) issueVerification(ctx context.Context, userID string) (string, error) {
+	if err := s.verificationRepo.Delete(ctx, byUser(userID, model.EmailVerificationTableName)); err != nil {
+		return constant.Empty, fmt.Errorf("failed to delete email verifications: %w", err)
+	}
+
+	verifyToken, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return constant.Empty, fmt.Errorf("failed to generate verification token: %w", err)
+	}
+
+	expireMin := s.cfg.App.Auth.VerificationExpireMin
+	if expireMin <= 0 {
+		expireMin = defaultVerificationExpireMin
+	}
+
+	now := timezone.Now()
+	verification := model.EmailVerification{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Token:     token.Hash(verifyToken),
+		ExpiresAt: now.Add(time.Duration(expireMin) * time.Minute),
+		CreatedAt: now,
+	}
+
+	if err := s.verificationRepo.Insert(ctx, verification); err != nil {
+		return constant.Empty, fmt.Errorf("failed to create email verification: %w", err)
+	}
+
+	return verifyToken, nil
+}
+
+// sendVerification emails a verification token, as a link when the frontend
+// verification page is configured.
+func (s *serviceImpl) sendVerification(ctx context.Context, email, verifyToken string) error {
+	body := "Use this token to verify your email address: " + verifyToken
+
+	if verifyURL := s.cfg.App.Auth.VerifyEmailURL; verifyURL != constant.Empty {
+		body = "Open this link to verify your email address: " + withToken(verifyURL, verifyToken)
+	}
+
+	body += "\n\nIf you did not sign up, you can ignore this email."
+
+	return s.mailer.Send(ctx, mail.Message{ //nolint:wrapcheck
+		To:      []string{email},
+		Subject: verificationSubject,
+		Body:    body,
+	})
+}
+
+// notifyRegistered tells the owner of a registered email that someone tried to
+// sign up with it. A failed send is logged only, like the other emails whose
+// outcome must not be reported.
+func (s *serviceImpl) notifyRegistered(ctx context.Context, email string) {
+	body := "Someone tried to sign up with this email address, which already has an account." +
+		"\n\nIf it was you, log in or reset your password instead. Otherwise, you can ignore this email."
+
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      []string{email},
+		Subject: registeredSubject,
+		Body:    body,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to send already registered email")
+	}
+}
+
+// issueReset replaces the pending password resets of a user with a new one and
+// returns its token. It is meant to run in a unit of work.
+func (s *serviceImpl) issueReset(ctx context.Context, userID string) (string, error) {
+	if err := s.resetRepo.Delete(ctx, byUser(userID, model.PasswordResetTableName)); err != nil {
+		return constant.Empty, fmt.Errorf("failed to delete password resets: %w", err)
+	}
+
+	resetToken, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		return constant.Empty, fmt.Errorf("failed to generate password reset token: %w", err)
+	}
+
+	expireMin := s.cfg.App.Auth.ResetExpireMin
+	if expireMin <= 0 {
+		expireMin = defaultResetExpireMin
+	}
+
+	now := timezone.Now()
+	reset := model.PasswordReset{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Token:     token.Hash(resetToken),
+		ExpiresAt: now.Add(time.Duration(expireMin) * time.Minute),
+		CreatedAt: now,
+	}
+
+	if err := s.resetRepo.Insert(ctx, reset); err != nil {
+		return constant.Empty, fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	return resetToken, nil
+}
+
+// sendReset emails a password reset token, as a link when the frontend reset
+// page is configured.
+func (s *serviceImpl) sendReset(ctx context.Context, email, resetToken string) error {
+	body := "Use this token to reset your password: " + resetToken
+
+	if resetURL := s.cfg.App.Auth.ResetPasswordURL; resetURL != constant.Empty {
+		body = "Open this link to reset your password: " + withToken(resetURL, resetToken)
+	}
+
+	body += "\n\nIf you did not ask for a password reset, you can ignore this email."
+
+	return s.mailer.Send(ctx, mail.Message{ //nolint:wrapcheck
+		To:      []string{email},
+		Subject: resetSubject,
+		Body:    body,
+	})
+}
+
+// withToken appends a token to the query of a frontend link.
+func withToken(link, plainToken string) string {
+	separator := "?"
+	if strings.Contains(link, "?") {
+		separator = "&"
+	}
+
+	return link + separator + "token=" + plainToken
+}
+
+func byEmail(email string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    userModel.FieldEmail,
+				Operator: gDto.FilterOperatorEq,
+				Value:    email,
+				Table:    userModel.TableName,
+			},
+		},
+	}
+}
+
+func byGoogleID(googleID string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    userModel.FieldGoogleID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    googleID,
+				Table:    userModel.TableName,
+			},
+		},
+	}
+}
+
+func byUser(userID, table string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldUserID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    userID,
+				Table:    table,
+			},
+		},
+	}
+}
+
+// useToken removes the row of a plain token with deleteRows, failing with
+// errTokenUsed when it is already gone.
+func useToken(ctx context.Context, deleteRows func(context.Context, gDto.FilterGroup) (int64, error), plainToken, table string) error {
+	deleted, err := deleteRows(ctx, byToken(plainToken, table))
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	if deleted == 0 {
+		return errTokenUsed
+	}
+
+	return nil
+}
+
+// byToken matches the unexpired row of a plain token in a token table.
+func byToken(plainToken, table string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldToken,
+				Operator: gDto.FilterOperatorEq,
+				Value:    token.Hash(plainToken),
+				Table:    table,
+			},
+			gDto.Filter{
+				Field:    model.FieldExpiresAt,
+				Operator: gDto.FilterOperatorGreater,
+				Value:    timezone.Now(),
+				Table:    table,
+			},
+		},
+	}
+}
+
+// Logout ends the session of the current request, which revokes its access and
+// refresh tokens.
+func (s *serviceImpl) Logout(ctx context.Context, userID, sessionID string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Logout")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	// A session ended by another request in the meantime is logged out already.
+	if err := s.jwtService.RevokeSession(ctx, userID, sessionID); err != nil && !errors.Is(err, jwt.ErrSessionNotFound) {
+		log.Error().Err(err).Msg("failed to revoke session")
+
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// LogoutAll ends all sessions of a user, on every device.
+func (s *serviceImpl) LogoutAll(ctx context.Context, userID string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".LogoutAll")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	if err := s.jwtService.RevokeAllUserTokens(ctx, userID); err != nil {
+		log.Error().Err(err).Msg("failed to revoke user tokens")
+
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// Sessions returns the sessions of a user, marking the current one and those
+// ended because a refresh token was reused.
+func (s *serviceImpl) Sessions(ctx context.Context, userID, sessionID string) (res []dto.SessionResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Sessions")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	sessions, err := s.jwtService.Sessions(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get sessions")
+
+		return res, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	return dto.NewSessionResponses(sessions, sessionID), nil
+}
+
+// RevokeSession ends one session of a user, such as that of a lost device.
+func (s *serviceImpl) RevokeSession(ctx context.Context, userID, sessionID string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".RevokeSession")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	if err := s.jwtService.RevokeSession(ctx, userID, sessionID); err != nil {
+		if errors.Is(err, jwt.ErrSessionNotFound) {
+			return failure.NotFound("session not found")
+		}
+
+		log.Error().Err(err).Msg("failed to revoke session")
+
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return nil
+}
+
+// JWKS returns the public keys that verify access tokens as a JSON Web Key Set,
+// for other services to verify the tokens without a shared secret.
+func (s *serviceImpl) JWKS(ctx context.Context) (res []byte, err error) {
+	_, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".JWKS")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	res, err = json.Marshal(s.jwtService.JWKS())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encode JWKS")
+
+		return res, fmt.Errorf("failed to encode JWKS: %w", err)
+	}
+
+	return res, nil
+}
@@ -3,41 +3,123 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
 	"oil/config"
 	"oil/infras/jwt"
 	jwtMocks "oil/infras/jwt/mocks"
+	"oil/infras/mail"
+	mailMocks "oil/infras/mail/mocks"
+	"oil/infras/oidc"
+	oidcMocks "oil/infras/oidc/mocks"
 	"oil/infras/otel/mocks"
+	postgresMocks "oil/infras/postgres/mocks"
+	authMocks "oil/internal/domains/auth/mocks"
+	"oil/internal/domains/auth/model"
 	"oil/internal/domains/auth/model/dto"
+	"oil/internal/domains/auth/repository"
 	"oil/internal/domains/auth/service"
 	userMocks "oil/internal/domains/user/mocks"
 	userModel "oil/internal/domains/user/model"
+	userRepo "oil/internal/domains/user/repository"
 	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	"oil/shared/failure"
+	"oil/shared/lockout"
+	lockoutMocks "oil/shared/lockout/mocks"
 	gModel "oil/shared/model"
+	"oil/shared/password"
 	"oil/shared/timezone"
+	"oil/shared/token"
+	"oil/shared/totp"
 )
 
+// deps are the dependencies of the service under test that a test sets up.
+// The others are mocks expecting no calls, or harmless fakes.
+type deps struct {
+	userRepo         userRepo.User
+	verificationRepo repository.EmailVerification
+	resetRepo        repository.PasswordReset
+	mfaRepo          repository.MFA
+	recoveryRepo     repository.RecoveryCode
+	mailer           mail.Sender
+	google           oidc.Verifier
+	lockout          lockout.Lockout
+	cfg              *config.Config
+	jwt              jwt.JWT
+}
+
+func newService(ctrl *gomock.Controller, d deps) service.Auth {
+	if d.userRepo == nil {
+		d.userRepo = userMocks.NewMockUser(ctrl)
+	}
+
+	if d.verificationRepo == nil {
+		d.verificationRepo = authMocks.NewMockEmailVerification(ctrl)
+	}
+
+	if d.resetRepo == nil {
+		d.resetRepo = authMocks.NewMockPasswordReset(ctrl)
+	}
+
+	if d.mfaRepo == nil {
+		d.mfaRepo = authMocks.NewMockMFA(ctrl)
+	}
+
+	if d.recoveryRepo == nil {
+		d.recoveryRepo = authMocks.NewMockRecoveryCode(ctrl)
+	}
+
+	if d.mailer == nil {
+		d.mailer = mail.NewMemory()
+	}
+
+	if d.google == nil {
+		d.google = oidcMocks.NewMockVerifier(ctrl)
+	}
+
+	if d.lockout == nil {
+		d.lockout = lockoutMocks.NewMockLockout(ctrl)
+	}
+
+	if d.cfg == nil {
+		d.cfg = &config.Config{}
+	}
+
+	if d.jwt == nil {
+		d.jwt = jwtMocks.NewMockJWT(ctrl)
+	}
+
+	return service.New(d.userRepo, d.verificationRepo, d.resetRepo, d.mfaRepo, d.recoveryRepo, postgresMocks.NewTxManager(), d.mailer, d.google, d.lockout, d.cfg, mocks.NewOtel(), d.jwt)
+}
+
 func TestAuthService_Login(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := userMocks.NewMockUser(ctrl)
 	mockJWT := jwtMocks.NewMockJWT(ctrl)
-	mockOtel := mocks.NewOtel()
+
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
 
 	cfg := &config.Config{}
+	cfg.App.Auth.RequireVerifiedEmail = true
 
-	svc := service.New(mockUserRepo, cfg, mockOtel, mockJWT)
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, mfaRepo: mockMFARepo, lockout: mockLockout, cfg: cfg, jwt: mockJWT})
 
 	// Valid user for successful login
 	validUser := userModel.User{
 		ID:         "user-id-123",
 		Email:      "test@example.com",
-		Password:   "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // "password" hashed
+		Password:   stringPtr("$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"), // "password" hashed
 		Level:      constant.RoleUser,
 		FullName:   stringPtr("Test User"),
 		IsVerified: true,
@@ -50,6 +132,18 @@ func TestAuthService_Login(t *testing.T) {
 		},
 	}
 
+	allowed := func() {
+		mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, nil)
+	}
+
+	succeeded := func() {
+		mockLockout.EXPECT().Reset(gomock.Any(), lockout.AccountKey(validUser.Email)).Return(nil)
+	}
+
+	failed := func(email string) {
+		mockLockout.EXPECT().Fail(gomock.Any(), lockout.AccountKey(email), gomock.Any()).Return(lockout.Status{FailedAttempts: 1}, nil)
+	}
+
 	tests := []struct {
 		name      string
 		req       dto.LoginRequest
@@ -63,12 +157,19 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMock: func() {
+				allowed()
+				succeeded()
+
 				mockUserRepo.EXPECT().
 					Get(gomock.Any(), gomock.Any()).
 					Return(validUser, nil)
 
+				mockMFARepo.EXPECT().
+					Get(gomock.Any(), gomock.Any()).
+					Return(model.MFA{}, nil)
+
 				mockJWT.EXPECT().
-					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level).
+					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level, false).
 					Return(&jwt.TokenPair{
 						AccessToken:  "access-token",
 						RefreshToken: "refresh-token",
@@ -87,6 +188,9 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMock: func() {
+				allowed()
+				failed("nonexistent@example.com")
+
 				mockUserRepo.EXPECT().
 					Get(gomock.Any(), gomock.Any()).
 					Return(userModel.User{}, errors.New("user not found"))
@@ -100,6 +204,9 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "wrongpassword",
 			},
 			setupMock: func() {
+				allowed()
+				failed("test@example.com")
+
 				mockUserRepo.EXPECT().
 					Get(gomock.Any(), gomock.Any()).
 					Return(validUser, nil)
@@ -113,6 +220,8 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMock: func() {
+				allowed()
+
 				inactiveUser := validUser
 				inactiveUser.Active = false
 
@@ -122,6 +231,24 @@ func TestAuthService_Login(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "unverified email",
+			req: dto.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password",
+			},
+			setupMock: func() {
+				allowed()
+
+				unverifiedUser := validUser
+				unverifiedUser.IsVerified = false
+
+				mockUserRepo.EXPECT().
+					Get(gomock.Any(), gomock.Any()).
+					Return(unverifiedUser, nil)
+			},
+			wantErr: true,
+		},
 		{
 			name: "token generation error",
 			req: dto.LoginRequest{
@@ -129,12 +256,18 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMock: func() {
+				allowed()
+
 				mockUserRepo.EXPECT().
 					Get(gomock.Any(), gomock.Any()).
 					Return(validUser, nil)
 
+				mockMFARepo.EXPECT().
+					Get(gomock.Any(), gomock.Any()).
+					Return(model.MFA{}, nil)
+
 				mockJWT.EXPECT().
-					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level).
+					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level, false).
 					Return(nil, errors.New("token generation failed"))
 			},
 			wantErr: true,
@@ -146,12 +279,18 @@ func TestAuthService_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMock: func() {
+				allowed()
+
 				mockUserRepo.EXPECT().
 					Get(gomock.Any(), gomock.Any()).
 					Return(validUser, nil)
 
+				mockMFARepo.EXPECT().
+					Get(gomock.Any(), gomock.Any()).
+					Return(model.MFA{}, nil)
+
 				mockJWT.EXPECT().
-					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level).
+					GenerateTokenPair(gomock.Any(), validUser.ID, validUser.Email, validUser.Level, false).
 					Return(&jwt.TokenPair{
 						AccessToken:  "access-token",
 						RefreshToken: "refresh-token",
@@ -183,17 +322,172 @@ func TestAuthService_Login(t *testing.T) {
 	}
 }
 
+func TestAuthService_Login_MFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Auth.MFARequiredRoles = []string{constant.RoleAdmin, constant.RoleSuperAdmin}
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, mfaRepo: mockMFARepo, lockout: mockLockout, cfg: cfg, jwt: mockJWT})
+
+	hashed, err := password.Hash("password")
+	assert.NoError(t, err)
+
+	admin := userModel.User{ID: "user-id-123", Email: "admin@example.com", Password: &hashed, Level: constant.RoleAdmin, IsVerified: true, Active: true}
+	req := dto.LoginRequest{Email: admin.Email, Password: "password"}
+
+	t.Run("mfa enabled returns a challenge", func(t *testing.T) {
+		mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, nil)
+		mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(admin, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{ID: "mfa-id", UserID: admin.ID, Enabled: true}, nil)
+		mockJWT.EXPECT().GenerateChallengeToken(gomock.Any(), admin.ID, admin.Email, admin.Level).Return("mfa-token", nil)
+
+		res, err := svc.Login(context.Background(), req)
+
+		assert.NoError(t, err)
+		assert.True(t, res.MFARequired)
+		assert.Equal(t, "mfa-token", res.MFAToken)
+		assert.Empty(t, res.AccessToken)
+		assert.Empty(t, res.RefreshToken)
+	})
+
+	t.Run("required role without mfa is told to enrol", func(t *testing.T) {
+		mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, nil)
+		mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(admin, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{}, nil)
+		mockJWT.EXPECT().
+			GenerateTokenPair(gomock.Any(), admin.ID, admin.Email, admin.Level, false).
+			Return(&jwt.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockLockout.EXPECT().Reset(gomock.Any(), lockout.AccountKey(admin.Email)).Return(nil)
+
+		res, err := svc.Login(context.Background(), req)
+
+		assert.NoError(t, err)
+		assert.False(t, res.MFARequired)
+		assert.True(t, res.MFASetupRequired)
+		assert.Equal(t, "access-token", res.AccessToken)
+	})
+}
+
+func TestAuthService_Login_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Auth.Lockout.Attempts = 3
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, lockout: mockLockout, cfg: cfg, jwt: mockJWT})
+
+	hashed, err := password.Hash("password")
+	assert.NoError(t, err)
+
+	user := userModel.User{ID: "user-id-123", Email: "test@example.com", Password: &hashed, Level: constant.RoleUser, IsVerified: true, Active: true}
+	ctx := context.WithValue(context.Background(), constant.ContextKeyClientIP, "10.0.0.1")
+
+	lockedUntil := timezone.Now().Add(time.Minute)
+	locked := lockout.Status{FailedAttempts: 3, LockedUntil: &lockedUntil}
+
+	tests := []struct {
+		name      string
+		req       dto.LoginRequest
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "locked out account",
+			req:  dto.LoginRequest{Email: user.Email, Password: "password"},
+			setupMock: func() {
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey(user.Email)).Return(locked, nil)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "unknown email is locked out alike",
+			req:  dto.LoginRequest{Email: "nonexistent@example.com", Password: "password"},
+			setupMock: func() {
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey("nonexistent@example.com")).Return(locked, nil)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "locked out ip address",
+			req:  dto.LoginRequest{Email: user.Email, Password: "password"},
+			setupMock: func() {
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey(user.Email)).Return(lockout.Status{}, nil)
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.IPKey("10.0.0.1")).Return(locked, nil)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "wrong password counts against account and ip address",
+			req:  dto.LoginRequest{Email: user.Email, Password: "wrongpassword"},
+			setupMock: func() {
+				mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, nil).Times(2)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().
+					Fail(gomock.Any(), lockout.AccountKey(user.Email), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, policy lockout.Policy) (lockout.Status, error) {
+						assert.Equal(t, 3, policy.Attempts)
+						assert.Equal(t, time.Minute, policy.Lockout)
+						assert.Equal(t, time.Hour, policy.Max)
+
+						return locked, nil
+					})
+				mockLockout.EXPECT().
+					Fail(gomock.Any(), lockout.IPKey("10.0.0.1"), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, policy lockout.Policy) (lockout.Status, error) {
+						assert.Equal(t, 20, policy.Attempts)
+
+						return lockout.Status{FailedAttempts: 1}, nil
+					})
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "cache failure lets the login go on",
+			req:  dto.LoginRequest{Email: user.Email, Password: "wrongpassword"},
+			setupMock: func() {
+				mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, errors.New("connection refused")).Times(2)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Fail(gomock.Any(), gomock.Any(), gomock.Any()).Return(lockout.Status{}, errors.New("connection refused")).Times(2)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			_, err := svc.Login(ctx, tt.req)
+
+			assert.Error(t, err)
+			assert.Equal(t, tt.wantCode, failure.GetCode(err))
+		})
+	}
+}
+
 func TestAuthService_RefreshToken(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := userMocks.NewMockUser(ctrl)
 	mockJWT := jwtMocks.NewMockJWT(ctrl)
-	mockOtel := mocks.NewOtel()
 
 	cfg := &config.Config{}
 
-	svc := service.New(mockUserRepo, cfg, mockOtel, mockJWT)
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, cfg: cfg, jwt: mockJWT})
 
 	tests := []struct {
 		name      string
@@ -228,6 +522,18 @@ func TestAuthService_RefreshToken(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "reused refresh token",
+			req: dto.RefreshTokenRequest{
+				RefreshToken: "used-refresh-token",
+			},
+			setupMock: func() {
+				mockJWT.EXPECT().
+					RefreshTokens(gomock.Any(), "used-refresh-token").
+					Return(nil, jwt.ErrTokenReused)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -254,17 +560,16 @@ func TestAuthService_ChangePassword(t *testing.T) {
 
 	mockUserRepo := userMocks.NewMockUser(ctrl)
 	mockJWT := jwtMocks.NewMockJWT(ctrl)
-	mockOtel := mocks.NewOtel()
 
 	cfg := &config.Config{}
 
-	svc := service.New(mockUserRepo, cfg, mockOtel, mockJWT)
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, cfg: cfg, jwt: mockJWT})
 
 	// Valid user for password change
 	validUser := userModel.User{
 		ID:         "user-id-123",
 		Email:      "test@example.com",
-		Password:   "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi", // "password" hashed
+		Password:   stringPtr("$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"), // "password" hashed
 		Level:      constant.RoleUser,
 		FullName:   stringPtr("Test User"),
 		IsVerified: true,
@@ -386,3 +691,1152 @@ func TestAuthService_ChangePassword(t *testing.T) {
 func stringPtr(s string) *string {
 	return &s
 }
+
+func TestAuthService_Register(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockVerificationRepo := authMocks.NewMockEmailVerification(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Auth.VerifyEmailURL = "https://app.example.com/verify"
+
+	tests := []struct {
+		name       string
+		req        dto.RegisterRequest
+		setupMock  func()
+		wantCode   int
+		wantMails  int
+		wantNotice bool
+	}{
+		{
+			name: "new user",
+			req:  dto.RegisterRequest{Email: "new@example.com", Password: "password123"},
+			setupMock: func() {
+				mockUserRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(false, nil)
+				mockUserRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, user userModel.User) error {
+						assert.Equal(t, "new@example.com", user.Email)
+						assert.Equal(t, constant.RoleUser, user.Level)
+						assert.False(t, user.IsVerified)
+						assert.Equal(t, user.ID, user.CreatedBy)
+
+						return nil
+					})
+				mockVerificationRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockVerificationRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, verification model.EmailVerification) error {
+						assert.WithinDuration(t, timezone.Now().Add(time.Hour), verification.ExpiresAt, time.Minute)
+
+						return nil
+					})
+			},
+			wantMails: 1,
+		},
+		{
+			name: "email already registered",
+			req:  dto.RegisterRequest{Email: "test@example.com", Password: "password123"},
+			setupMock: func() {
+				mockUserRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+			},
+			wantMails:  1,
+			wantNotice: true,
+		},
+		{
+			name: "email registered concurrently",
+			req:  dto.RegisterRequest{Email: "test@example.com", Password: "password123"},
+			setupMock: func() {
+				mockUserRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(false, nil)
+				mockUserRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("failed to insert: %w", &pq.Error{Code: constant.PqErrorCodeUniqueViolation}))
+			},
+			wantMails:  1,
+			wantNotice: true,
+		},
+		{
+			name: "user insert error",
+			req:  dto.RegisterRequest{Email: "new@example.com", Password: "password123"},
+			setupMock: func() {
+				mockUserRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(false, nil)
+				mockUserRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(errors.New("insert error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			mailer := mail.NewMemory()
+			svc := newService(ctrl, deps{userRepo: mockUserRepo, verificationRepo: mockVerificationRepo, mailer: mailer, cfg: cfg, jwt: mockJWT})
+
+			err := svc.Register(context.Background(), tt.req)
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+
+			messages := mailer.Messages()
+			assert.Len(t, messages, tt.wantMails)
+
+			if tt.wantMails > 0 {
+				assert.Equal(t, []string{tt.req.Email}, messages[0].To)
+
+				if tt.wantNotice {
+					assert.Equal(t, "You already have an account", messages[0].Subject)
+				} else {
+					assert.Contains(t, messages[0].Body, "https://app.example.com/verify?token=")
+				}
+			}
+		})
+	}
+}
+
+func TestAuthService_VerifyEmail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockVerificationRepo := authMocks.NewMockEmailVerification(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, verificationRepo: mockVerificationRepo, jwt: mockJWT})
+
+	verification := model.EmailVerification{ID: "verification-id", UserID: "user-id-123", Token: token.Hash("verify-token")}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "valid token",
+			setupMock: func() {
+				mockVerificationRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(verification, nil)
+				mockVerificationRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockUserRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, true, fields[userModel.FieldIsVerified])
+
+						return nil
+					})
+				mockVerificationRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "unknown or expired token",
+			setupMock: func() {
+				mockVerificationRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.EmailVerification{}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "token used concurrently",
+			setupMock: func() {
+				mockVerificationRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(verification, nil)
+				mockVerificationRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := svc.VerifyEmail(context.Background(), dto.VerifyEmailRequest{Token: "verify-token"})
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthService_ResendVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockVerificationRepo := authMocks.NewMockEmailVerification(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	unverified := userModel.User{ID: "user-id-123", Email: "test@example.com"}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		sendErr   error
+		wantMails int
+	}{
+		{
+			name: "unverified user",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(unverified, nil)
+				mockVerificationRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockVerificationRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantMails: 1,
+		},
+		{
+			name: "failed send",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(unverified, nil)
+				mockVerificationRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockVerificationRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			sendErr: errors.New("smtp error"),
+		},
+		{
+			name: "verified user",
+			setupMock: func() {
+				verified := unverified
+				verified.IsVerified = true
+
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(verified, nil)
+			},
+		},
+		{
+			name: "unknown email",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			mailer := mail.NewMemory()
+
+			var sender mail.Sender = mailer
+			if tt.sendErr != nil {
+				failing := mailMocks.NewMockSender(ctrl)
+				failing.EXPECT().Send(gomock.Any(), gomock.Any()).Return(tt.sendErr)
+				sender = failing
+			}
+
+			svc := newService(ctrl, deps{userRepo: mockUserRepo, verificationRepo: mockVerificationRepo, mailer: sender, jwt: mockJWT})
+
+			err := svc.ResendVerification(context.Background(), dto.ResendVerificationRequest{Email: unverified.Email})
+
+			assert.NoError(t, err)
+			assert.Len(t, mailer.Messages(), tt.wantMails)
+		})
+	}
+}
+
+func TestAuthService_ForgotPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockResetRepo := authMocks.NewMockPasswordReset(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Auth.ResetPasswordURL = "https://app.example.com/reset?source=email"
+
+	user := userModel.User{ID: "user-id-123", Email: "test@example.com", Active: true}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantMails int
+	}{
+		{
+			name: "registered user",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockResetRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockResetRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, reset model.PasswordReset) error {
+						assert.Equal(t, user.ID, reset.UserID)
+						assert.Len(t, reset.Token, 64)
+						assert.WithinDuration(t, timezone.Now().Add(time.Hour), reset.ExpiresAt, time.Minute)
+
+						return nil
+					})
+			},
+			wantMails: 1,
+		},
+		{
+			name: "deactivated user",
+			setupMock: func() {
+				inactive := user
+				inactive.Active = false
+
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(inactive, nil)
+			},
+		},
+		{
+			name: "unknown email",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			mailer := mail.NewMemory()
+			svc := newService(ctrl, deps{userRepo: mockUserRepo, resetRepo: mockResetRepo, mailer: mailer, cfg: cfg, jwt: mockJWT})
+
+			err := svc.ForgotPassword(context.Background(), dto.ForgotPasswordRequest{Email: user.Email})
+
+			assert.NoError(t, err)
+
+			// Resets are sent after answering.
+			assert.Eventually(t, func() bool { return len(mailer.Messages()) == tt.wantMails }, time.Second, 10*time.Millisecond)
+
+			messages := mailer.Messages()
+			assert.Len(t, messages, tt.wantMails)
+
+			if tt.wantMails > 0 {
+				assert.Contains(t, messages[0].Body, "https://app.example.com/reset?source=email&token=")
+			}
+		})
+	}
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockResetRepo := authMocks.NewMockPasswordReset(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, resetRepo: mockResetRepo, jwt: mockJWT})
+
+	reset := model.PasswordReset{ID: "reset-id", UserID: "user-id-123", Token: token.Hash("reset-token")}
+	user := userModel.User{ID: reset.UserID, Active: true}
+	req := dto.ResetPasswordRequest{Token: "reset-token", NewPassword: "newpassword123"}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "valid token",
+			setupMock: func() {
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(reset, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockResetRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockUserRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						hashed, ok := fields[userModel.FieldPassword].(string)
+						assert.True(t, ok)
+						assert.NoError(t, password.Verify(req.NewPassword, hashed))
+
+						return nil
+					})
+				mockResetRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockJWT.EXPECT().RevokeAllUserTokens(gomock.Any(), reset.UserID).Return(nil)
+			},
+		},
+		{
+			name: "unknown, used or expired token",
+			setupMock: func() {
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.PasswordReset{}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "deactivated user",
+			setupMock: func() {
+				inactive := user
+				inactive.Active = false
+
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(reset, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(inactive, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "deleted user",
+			setupMock: func() {
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(reset, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "token used concurrently",
+			setupMock: func() {
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(reset, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockResetRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "revoke error",
+			setupMock: func() {
+				mockResetRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(reset, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockResetRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockUserRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockResetRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockJWT.EXPECT().RevokeAllUserTokens(gomock.Any(), reset.UserID).Return(jwt.ErrCacheOperationFailed)
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := svc.ResetPassword(context.Background(), req)
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthService_GoogleLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockGoogle := oidcMocks.NewMockVerifier(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, mfaRepo: mockMFARepo, google: mockGoogle, jwt: mockJWT})
+
+	claims := oidc.Claims{Subject: "google-sub-1", Email: "test@example.com", EmailVerified: true, Name: "Test User"}
+	tokenPair := &jwt.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+	googleID := claims.Subject
+	otherGoogleID := "google-sub-2"
+	deletedAt := timezone.Now()
+
+	user := userModel.User{ID: "user-id-123", Email: claims.Email, Level: constant.RoleUser, IsVerified: true, Active: true}
+
+	expectLogin := func() {
+		mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{}, nil)
+		mockJWT.EXPECT().GenerateTokenPair(gomock.Any(), gomock.Any(), claims.Email, constant.RoleUser, false).Return(tokenPair, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "invalid id token",
+			setupMock: func() {
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(oidc.Claims{}, oidc.ErrInvalidIDToken)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "unverified google email",
+			setupMock: func() {
+				unverified := claims
+				unverified.EmailVerified = false
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(unverified, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "linked user",
+			setupMock: func() {
+				linked := user
+				linked.GoogleID = &googleID
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(linked, nil)
+				expectLogin()
+			},
+		},
+		{
+			name: "verified user with the email is linked",
+			setupMock: func() {
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockUserRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, claims.Subject, fields[userModel.FieldGoogleID])
+						assert.NotContains(t, fields, userModel.FieldPassword)
+
+						return nil
+					})
+				expectLogin()
+			},
+		},
+		{
+			name: "unverified user with the email loses their password when linked",
+			setupMock: func() {
+				unverified := user
+				unverified.IsVerified = false
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(unverified, nil)
+				mockUserRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, true, fields[userModel.FieldIsVerified])
+						assert.Contains(t, fields, userModel.FieldPassword)
+						assert.Nil(t, fields[userModel.FieldPassword])
+
+						return nil
+					})
+				mockJWT.EXPECT().RevokeAllUserTokens(gomock.Any(), user.ID).Return(nil)
+				expectLogin()
+			},
+		},
+		{
+			name: "new user is created",
+			setupMock: func() {
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil).Times(2)
+				mockUserRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, created userModel.User) error {
+						assert.Equal(t, claims.Email, created.Email)
+						assert.Equal(t, &googleID, created.GoogleID)
+						assert.Equal(t, &claims.Name, created.FullName)
+						assert.True(t, created.IsVerified)
+						assert.Nil(t, created.Password)
+
+						return nil
+					})
+				expectLogin()
+			},
+		},
+		{
+			name: "user created by a concurrent login",
+			setupMock: func() {
+				linked := user
+				linked.GoogleID = &googleID
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil).Times(2)
+				mockUserRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					Return(fmt.Errorf("failed to insert: %w", &pq.Error{Code: constant.PqErrorCodeUniqueViolation}))
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(linked, nil)
+				expectLogin()
+			},
+		},
+		{
+			name: "email linked to another google account",
+			setupMock: func() {
+				linked := user
+				linked.GoogleID = &otherGoogleID
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(linked, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "deleted user with the email",
+			setupMock: func() {
+				deleted := user
+				deleted.DeletedAt = &deletedAt
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(deleted, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name: "deactivated user",
+			setupMock: func() {
+				inactive := user
+				inactive.GoogleID = &googleID
+				inactive.Active = false
+
+				mockGoogle.EXPECT().Verify(gomock.Any(), gomock.Any()).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(inactive, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.GoogleLogin(context.Background(), dto.GoogleLoginRequest{IDToken: "id-token"})
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tokenPair.AccessToken, res.AccessToken)
+			assert.Equal(t, tokenPair.RefreshToken, res.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_SetupMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Name = "oil"
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, mfaRepo: mockMFARepo, cfg: cfg, jwt: mockJWT})
+
+	user := userModel.User{ID: "user-id-123", Email: "test@example.com"}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "first setup",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{}, nil)
+				mockMFARepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, mfa model.MFA) error {
+						assert.Equal(t, user.ID, mfa.UserID)
+						assert.NotEmpty(t, mfa.Secret)
+						assert.False(t, mfa.Enabled)
+
+						return nil
+					})
+			},
+		},
+		{
+			name: "unfinished setup gets a new secret",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{ID: "mfa-id", UserID: user.ID, Secret: "OLDSECRET"}, nil)
+				mockMFARepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.NotEqual(t, "OLDSECRET", fields[model.FieldSecret])
+
+						return nil
+					})
+			},
+		},
+		{
+			name: "already enabled",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{ID: "mfa-id", Enabled: true}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "user not found",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.SetupMFA(context.Background(), user.ID)
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, res.Secret)
+			assert.Contains(t, res.URI, "otpauth://totp/oil:test@example.com?")
+			assert.Contains(t, res.URI, "secret="+res.Secret)
+		})
+	}
+}
+
+func TestAuthService_VerifyMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+	mockRecoveryRepo := authMocks.NewMockRecoveryCode(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	svc := newService(ctrl, deps{mfaRepo: mockMFARepo, recoveryRepo: mockRecoveryRepo, jwt: mockJWT})
+
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	step := totp.Step(timezone.Now())
+	code, err := totp.Code(secret, step)
+	assert.NoError(t, err)
+
+	pending := model.MFA{ID: "mfa-id", UserID: "user-id-123", Secret: secret}
+
+	tests := []struct {
+		name      string
+		code      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "valid code",
+			code: code,
+			setupMock: func() {
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(pending, nil)
+				mockMFARepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, true, fields[model.FieldEnabled])
+						assert.Equal(t, step, fields[model.FieldLastStep])
+
+						return nil
+					})
+				mockRecoveryRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockRecoveryRepo.EXPECT().
+					InsertBulk(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, codes []model.RecoveryCode) error {
+						assert.Len(t, codes, 10)
+
+						return nil
+					})
+			},
+		},
+		{
+			name: "invalid code",
+			code: "000000",
+			setupMock: func() {
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(pending, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "setup not started",
+			code: code,
+			setupMock: func() {
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.MFA{}, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "already enabled",
+			code: code,
+			setupMock: func() {
+				enabled := pending
+				enabled.Enabled = true
+
+				mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(enabled, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.VerifyMFA(context.Background(), dto.MFAVerifyRequest{Code: tt.code}, pending.UserID)
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, res.RecoveryCodes, 10)
+		})
+	}
+}
+
+func TestAuthService_ChallengeMFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockMFARepo := authMocks.NewMockMFA(ctrl)
+	mockRecoveryRepo := authMocks.NewMockRecoveryCode(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	svc := newService(ctrl, deps{userRepo: mockUserRepo, mfaRepo: mockMFARepo, recoveryRepo: mockRecoveryRepo, lockout: mockLockout, jwt: mockJWT})
+
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	step := totp.Step(timezone.Now())
+
+	code, err := totp.Code(secret, step)
+	assert.NoError(t, err)
+
+	recoveryHash, err := password.Hash("abcd-efgh")
+	assert.NoError(t, err)
+
+	user := userModel.User{ID: "user-id-123", Email: "test@example.com", Level: constant.RoleAdmin, Active: true}
+	enabled := model.MFA{ID: "mfa-id", UserID: user.ID, Secret: secret, Enabled: true}
+	claims := &jwt.Claims{UserID: user.ID, Email: user.Email, Role: user.Level, TokenID: "challenge-id", Type: jwt.ChallengeToken}
+	tokenPair := &jwt.TokenPair{AccessToken: "access-token", RefreshToken: "refresh-token"}
+
+	lockedUntil := timezone.Now().Add(time.Minute)
+	locked := lockout.Status{FailedAttempts: 5, LockedUntil: &lockedUntil}
+
+	expectStart := func(mfa model.MFA) {
+		mockJWT.EXPECT().ValidateToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(claims, nil)
+		mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+		mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey(user.Email)).Return(lockout.Status{}, nil)
+		mockMFARepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(mfa, nil)
+	}
+
+	expectSignIn := func() {
+		mockJWT.EXPECT().RedeemToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(nil)
+		mockLockout.EXPECT().Reset(gomock.Any(), lockout.AccountKey(user.Email)).Return(nil)
+		mockJWT.EXPECT().GenerateTokenPair(gomock.Any(), user.ID, user.Email, user.Level, true).Return(tokenPair, nil)
+		mockUserRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	}
+
+	expectFail := func(challenge lockout.Status) {
+		mockLockout.EXPECT().Fail(gomock.Any(), lockout.AccountKey(user.Email), gomock.Any()).Return(lockout.Status{FailedAttempts: 1}, nil)
+		mockLockout.EXPECT().
+			Fail(gomock.Any(), lockout.ChallengeKey(claims.TokenID), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, policy lockout.Policy) (lockout.Status, error) {
+				assert.Equal(t, 5, policy.Attempts)
+
+				return challenge, nil
+			})
+	}
+
+	tests := []struct {
+		name      string
+		req       dto.MFAChallengeRequest
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "authenticator code",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				expectStart(enabled)
+				mockMFARepo.EXPECT().
+					UpdateRows(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) (int64, error) {
+						assert.Equal(t, step, fields[model.FieldLastStep])
+
+						return 1, nil
+					})
+				expectSignIn()
+			},
+		},
+		{
+			name: "code used concurrently",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				expectStart(enabled)
+				mockMFARepo.EXPECT().UpdateRows(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(0), nil)
+				expectFail(lockout.Status{FailedAttempts: 1})
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "mfa token redeemed concurrently",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				expectStart(enabled)
+				mockMFARepo.EXPECT().UpdateRows(gomock.Any(), gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				mockJWT.EXPECT().RedeemToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(jwt.ErrRevokedToken)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name: "code already used",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				used := enabled
+				used.LastStep = step
+
+				expectStart(used)
+				expectFail(lockout.Status{FailedAttempts: 1})
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "too many wrong codes revoke the mfa token",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: "000000"},
+			setupMock: func() {
+				expectStart(enabled)
+				expectFail(locked)
+				mockJWT.EXPECT().RevokeToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "locked out account",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				mockJWT.EXPECT().ValidateToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(claims, nil)
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey(user.Email)).Return(locked, nil)
+			},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name: "recovery code",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", RecoveryCode: " ABCD-EFGH "},
+			setupMock: func() {
+				expectStart(enabled)
+				mockRecoveryRepo.EXPECT().
+					GetAll(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]model.RecoveryCode{{ID: "recovery-id", UserID: user.ID, Code: recoveryHash}}, nil)
+				mockRecoveryRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(1), nil)
+				expectSignIn()
+			},
+		},
+		{
+			name: "recovery code used concurrently",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", RecoveryCode: "abcd-efgh"},
+			setupMock: func() {
+				expectStart(enabled)
+				mockRecoveryRepo.EXPECT().
+					GetAll(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]model.RecoveryCode{{ID: "recovery-id", UserID: user.ID, Code: recoveryHash}}, nil)
+				mockRecoveryRepo.EXPECT().DeleteRows(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+				expectFail(lockout.Status{FailedAttempts: 1})
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown recovery code",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", RecoveryCode: "zzzz-zzzz"},
+			setupMock: func() {
+				expectStart(enabled)
+				mockRecoveryRepo.EXPECT().
+					GetAll(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]model.RecoveryCode{{ID: "recovery-id", UserID: user.ID, Code: recoveryHash}}, nil)
+				expectFail(lockout.Status{FailedAttempts: 1})
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid or used mfa token",
+			req:  dto.MFAChallengeRequest{MFAToken: "mfa-token", Code: code},
+			setupMock: func() {
+				mockJWT.EXPECT().ValidateToken(gomock.Any(), "mfa-token", jwt.ChallengeToken).Return(nil, jwt.ErrInvalidToken)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.ChallengeMFA(context.Background(), tt.req)
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tokenPair.AccessToken, res.AccessToken)
+			assert.False(t, res.MFARequired)
+		})
+	}
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+
+	svc := newService(ctrl, deps{cfg: cfg, jwt: mockJWT})
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantErr   bool
+	}{
+		{
+			name: "session ended",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(nil)
+			},
+		},
+		{
+			name: "session already ended",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(jwt.ErrSessionNotFound)
+			},
+		},
+		{
+			name: "cache failure",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(jwt.ErrCacheOperationFailed)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := svc.Logout(context.Background(), "user-id-123", "session-1")
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthService_LogoutAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+
+	svc := newService(ctrl, deps{cfg: cfg, jwt: mockJWT})
+
+	mockJWT.EXPECT().RevokeAllUserTokens(gomock.Any(), "user-id-123").Return(nil)
+	assert.NoError(t, svc.LogoutAll(context.Background(), "user-id-123"))
+
+	mockJWT.EXPECT().RevokeAllUserTokens(gomock.Any(), "user-id-123").Return(jwt.ErrCacheOperationFailed)
+	assert.Error(t, svc.LogoutAll(context.Background(), "user-id-123"))
+}
+
+func TestAuthService_Sessions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+
+	svc := newService(ctrl, deps{cfg: cfg, jwt: mockJWT})
+
+	now := timezone.Now()
+	sessions := []jwt.Session{
+		{ID: "session-2", UserAgent: "curl", IP: "10.0.0.2", IssuedAt: now, RefreshedAt: now},
+		{ID: "session-1", UserAgent: "Firefox", IP: "10.0.0.1", IssuedAt: now.Add(-time.Hour), RefreshedAt: now.Add(-time.Hour)},
+	}
+
+	mockJWT.EXPECT().Sessions(gomock.Any(), "user-id-123").Return(sessions, nil)
+
+	res, err := svc.Sessions(context.Background(), "user-id-123", "session-1")
+	assert.NoError(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "session-2", res[0].ID)
+	assert.False(t, res[0].Current)
+	assert.Equal(t, "Firefox", res[1].UserAgent)
+	assert.True(t, res[1].Current)
+
+	mockJWT.EXPECT().Sessions(gomock.Any(), "user-id-123").Return(nil, jwt.ErrCacheOperationFailed)
+
+	_, err = svc.Sessions(context.Background(), "user-id-123", "session-1")
+	assert.Error(t, err)
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+
+	svc := newService(ctrl, deps{cfg: cfg, jwt: mockJWT})
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantErr   bool
+		wantCode  int
+	}{
+		{
+			name: "session ended",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(nil)
+			},
+		},
+		{
+			name: "unknown session",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(jwt.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "cache failure",
+			setupMock: func() {
+				mockJWT.EXPECT().RevokeSession(gomock.Any(), "user-id-123", "session-1").Return(jwt.ErrCacheOperationFailed)
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := svc.RevokeSession(context.Background(), "user-id-123", "session-1")
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package dto
+
+import (
+	"fmt"
+	"oil/internal/domains/booking/model"
+	"oil/shared/ical"
+	"oil/shared/timezone"
+	"strings"
+	"time"
+)
+
+// CalendarTokenResponse returns a newly issued personal calendar feed token.
+// The token is only shown once; issuing a new one revokes the previous feed.
+type CalendarTokenResponse struct {
+	Token   string `json:"token"`
+	FeedURL string `json:"feed_url"`
+}
+
+// CalendarOptions describes how bookings are rendered into a calendar feed.
+type CalendarOptions struct {
+	AppName string
+	Name    string
+	// Rooms maps room IDs to names used as the event location.
+	Rooms map[string]string
+	// Detailed includes purpose and guest details, which public feeds must not expose.
+	Detailed bool
+}
+
+// ToCalendar renders bookings as a calendar whose event times are interpreted
+// in the application timezone.
+func ToCalendar(bookings []model.Booking, opts CalendarOptions) ical.Calendar {
+	calendar := ical.Calendar{
+		ProdID:   fmt.Sprintf("-//%s//Room Bookings//EN", opts.AppName),
+		Name:     opts.Name,
+		Timezone: timezone.GetLocation().String(),
+		Events:   make([]ical.Event, len(bookings)),
+	}
+
+	domain := strings.ToLower(strings.ReplaceAll(opts.AppName, " ", "-"))
+
+	for i, booking := range bookings {
+		location := opts.Rooms[booking.RoomID]
+
+		event := ical.Event{
+			UID:          booking.ID + "@" + domain,
+			Start:        wallClock(booking.BookingDate, booking.StartTime),
+			End:          wallClock(booking.BookingDate, booking.EndTime),
+			Summary:      "Booked",
+			Location:     location,
+			Status:       calendarStatus(booking.Status),
+			Created:      booking.CreatedAt,
+			LastModified: booking.ModifiedAt,
+		}
+
+		if opts.Detailed {
+			event.Summary = booking.Purpose
+			if event.Summary == "" {
+				event.Summary = strings.TrimSpace("Booking " + location)
+			}
+
+			event.Description = bookingDescription(booking)
+		}
+
+		calendar.Events[i] = event
+	}
+
+	return calendar
+}
+
+// wallClock combines a booking date and a time of day in the application timezone.
+func wallClock(date, clock time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, timezone.GetLocation())
+}
+
+func calendarStatus(status string) string {
+	switch status {
+	case model.StatusPending:
+		return ical.StatusTentative
+	case model.StatusConfirmed, model.StatusCompleted:
+		return ical.StatusConfirmed
+	default:
+		return ical.StatusCancelled
+	}
+}
+
+func bookingDescription(booking model.Booking) string {
+	lines := []string{"Guest: " + booking.GuestName}
+
+	if booking.GuestEmail != "" {
+		lines = append(lines, "Email: "+booking.GuestEmail)
+	}
+
+	if booking.GuestPhone != "" {
+		lines = append(lines, "Phone: "+booking.GuestPhone)
+	}
+
+	lines = append(lines, "Status: "+booking.Status)
+
+	return strings.Join(lines, "\n")
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/google/uuid"
 	"oil/internal/domains/booking/model"
 	"oil/shared"
+	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	gModel "oil/shared/model"
 	"oil/shared/timezone"
@@ -11,38 +12,33 @@ import (
 )
 
 type CreateBookingRequest struct {
-	RoomID      string `json:"room_id"      validate:"required"`
-	GuestName   string `json:"guest_name"   validate:"required,max=100"`
-	GuestEmail  string `json:"guest_email"  validate:"omitempty,email,max=100"`
-	GuestPhone  string `json:"guest_phone"  validate:"omitempty,max=20"`
-	BookingDate string `json:"booking_date" validate:"required"`
-	StartTime   string `json:"start_time"   validate:"required"`
-	EndTime     string `json:"end_time"     validate:"required"`
-	Purpose     string `json:"purpose"      validate:"omitempty"`
-	Status      string `json:"status"       validate:"omitempty,oneof=pending confirmed cancelled"`
+	RoomID      string             `json:"room_id"      validate:"required"`
+	GuestName   string             `json:"guest_name"   validate:"required,max=100"`
+	GuestEmail  string             `json:"guest_email"  validate:"omitempty,email,max=100"`
+	GuestPhone  string             `json:"guest_phone"  validate:"omitempty,max=20"`
+	BookingDate string             `json:"booking_date" validate:"required"`
+	StartTime   string             `json:"start_time"   validate:"required"`
+	EndTime     string             `json:"end_time"     validate:"required"`
+	Purpose     string             `json:"purpose"      validate:"omitempty"`
+	Recurrence  *RecurrenceRequest `json:"recurrence"   validate:"omitempty"`
 }
 
 func (c *CreateBookingRequest) ToModel(user string) (model.Booking, error) {
-	bookingDate, err := time.Parse("2006-01-02", c.BookingDate)
+	bookingDate, err := time.Parse(model.DateFormat, c.BookingDate)
 	if err != nil {
 		return model.Booking{}, err
 	}
 
-	startTime, err := time.Parse("15:04", c.StartTime)
+	startTime, err := time.Parse(model.TimeFormat, c.StartTime)
 	if err != nil {
 		return model.Booking{}, err
 	}
 
-	endTime, err := time.Parse("15:04", c.EndTime)
+	endTime, err := time.Parse(model.TimeFormat, c.EndTime)
 	if err != nil {
 		return model.Booking{}, err
 	}
 
-	status := "pending"
-	if c.Status != "" {
-		status = c.Status
-	}
-
 	return model.Booking{
 		ID:          uuid.NewString(),
 		RoomID:      c.RoomID,
@@ -53,7 +49,7 @@ func (c *CreateBookingRequest) ToModel(user string) (model.Booking, error) {
 		StartTime:   startTime,
 		EndTime:     endTime,
 		Purpose:     c.Purpose,
-		Status:      status,
+		Status:      model.StatusPending,
 		Metadata: gModel.Metadata{
 			CreatedAt:  timezone.Now(),
 			ModifiedAt: timezone.Now(),
@@ -63,6 +59,36 @@ func (c *CreateBookingRequest) ToModel(user string) (model.Booking, error) {
 	}, nil
 }
 
+// ToSeries builds the series and its occurrences from a recurring booking request.
+func (c *CreateBookingRequest) ToSeries(user string) (model.Series, []model.Booking, error) {
+	first, err := c.ToModel(user)
+	if err != nil {
+		return model.Series{}, nil, err
+	}
+
+	dates, err := c.Recurrence.Occurrences(first.BookingDate)
+	if err != nil {
+		return model.Series{}, nil, err
+	}
+
+	series, err := c.Recurrence.ToModel(first, user)
+	if err != nil {
+		return model.Series{}, nil, err
+	}
+
+	bookings := make([]model.Booking, len(dates))
+	for i, date := range dates {
+		booking := first
+		booking.ID = uuid.NewString()
+		booking.BookingDate = date
+		booking.SeriesID = &series.ID
+
+		bookings[i] = booking
+	}
+
+	return series, bookings, nil
+}
+
 type UpdateBookingRequest struct {
 	GuestName   string `db:"guest_name"     json:"guest_name"    validate:"omitempty,max=100"`
 	GuestEmail  string `db:"guest_email"    json:"guest_email"   validate:"omitempty,email,max=100"`
@@ -71,20 +97,112 @@ type UpdateBookingRequest struct {
 	StartTime   string `json:"start_time"   validate:"omitempty"`
 	EndTime     string `json:"end_time"     validate:"omitempty"`
 	Purpose     string `db:"purpose"        json:"purpose"       validate:"omitempty"`
-	Status      string `db:"status"         json:"status"        validate:"omitempty,oneof=pending confirmed cancelled"`
+}
+
+// HasSchedule reports whether the request changes the booking date or time.
+func (u *UpdateBookingRequest) HasSchedule() bool {
+	return u.BookingDate != "" || u.StartTime != "" || u.EndTime != ""
+}
+
+// ScheduleFields adds the requested date and time columns of booking to fields.
+func (u *UpdateBookingRequest) ScheduleFields(booking model.Booking, fields map[string]any) {
+	if u.BookingDate != "" {
+		fields[model.FieldBookingDate] = booking.BookingDate
+	}
+
+	if u.StartTime != "" {
+		fields[model.FieldStartTime] = booking.StartTime
+	}
+
+	if u.EndTime != "" {
+		fields[model.FieldEndTime] = booking.EndTime
+	}
+}
+
+// ApplySchedule returns a copy of booking with the requested date and time applied.
+func (u *UpdateBookingRequest) ApplySchedule(booking model.Booking) (model.Booking, error) {
+	if u.BookingDate != "" {
+		bookingDate, err := time.Parse(model.DateFormat, u.BookingDate)
+		if err != nil {
+			return booking, err
+		}
+
+		booking.BookingDate = bookingDate
+	}
+
+	if u.StartTime != "" {
+		startTime, err := time.Parse(model.TimeFormat, u.StartTime)
+		if err != nil {
+			return booking, err
+		}
+
+		booking.StartTime = startTime
+	}
+
+	if u.EndTime != "" {
+		endTime, err := time.Parse(model.TimeFormat, u.EndTime)
+		if err != nil {
+			return booking, err
+		}
+
+		booking.EndTime = endTime
+	}
+
+	return booking, nil
+}
+
+// Apply returns a copy of booking with every field of the request applied, as
+// it is stored after the update.
+func (u *UpdateBookingRequest) Apply(booking model.Booking, user string) (model.Booking, error) {
+	booking, err := u.ApplySchedule(booking)
+	if err != nil {
+		return booking, err
+	}
+
+	if u.GuestName != "" {
+		booking.GuestName = u.GuestName
+	}
+
+	if u.GuestEmail != "" {
+		booking.GuestEmail = u.GuestEmail
+	}
+
+	if u.GuestPhone != "" {
+		booking.GuestPhone = u.GuestPhone
+	}
+
+	if u.Purpose != "" {
+		booking.Purpose = u.Purpose
+	}
+
+	booking.ModifiedAt = timezone.Now()
+	booking.ModifiedBy = user
+
+	return booking, nil
+}
+
+// ChangeStatusRequest carries the optional reason for a booking status change.
+type ChangeStatusRequest struct {
+	Reason string `db:"status_reason" json:"reason" validate:"omitempty,max=500"`
 }
 
 type BookingResponse struct {
-	ID          string `json:"id"`
-	RoomID      string `json:"room_id"`
-	GuestName   string `json:"guest_name"`
-	GuestEmail  string `json:"guest_email"`
-	GuestPhone  string `json:"guest_phone"`
-	BookingDate string `json:"booking_date"`
-	StartTime   string `json:"start_time"`
-	EndTime     string `json:"end_time"`
-	Purpose     string `json:"purpose"`
-	Status      string `json:"status"`
+	ID          string  `json:"id"`
+	RoomID      string  `json:"room_id"`
+	GuestName   string  `json:"guest_name"`
+	GuestEmail  string  `json:"guest_email"`
+	GuestPhone  string  `json:"guest_phone"`
+	BookingDate string  `json:"booking_date"`
+	StartTime   string  `json:"start_time"`
+	EndTime     string  `json:"end_time"`
+	Purpose     string  `json:"purpose"`
+	Status      string  `json:"status"`
+	SeriesID    *string `json:"series_id,omitempty"`
+
+	StatusReason    *string `json:"status_reason,omitempty"`
+	StatusChangedBy *string `json:"status_changed_by,omitempty"`
+	StatusChangedAt string  `json:"status_changed_at,omitempty"`
+	Version         int     `json:"version"`
 	gDto.Metadata
 }
 
@@ -99,18 +217,30 @@ func (r *BookingResponse) FromModel(model model.Booking) {
 	r.EndTime = model.EndTime.Format("15:04")
 	r.Purpose = model.Purpose
 	r.Status = model.Status
+	r.SeriesID = model.SeriesID
+	r.StatusReason = model.StatusReason
+	r.StatusChangedBy = model.StatusChangedBy
+	if model.StatusChangedAt != nil {
+		r.StatusChangedAt = timezone.Format(*model.StatusChangedAt, constant.DateFormat)
+	}
+	r.Version = model.Version
 	r.Metadata.FromModel(model.Metadata)
 }
 
 type GetBookingsResponse struct {
-	Bookings  []BookingResponse `json:"bookings"`
-	TotalPage int               `json:"total_page"`
-	TotalData int               `json:"total_data"`
+	Bookings []BookingResponse `json:"bookings"`
+	gDto.Pagination
 }
 
 func (r *GetBookingsResponse) FromModels(models []model.Booking, totalData, limit int) {
-	r.TotalData = totalData
-	r.TotalPage = shared.CalculateTotalPage(totalData, limit)
+	page := gDto.Pagination{}
+	page.SetTotal(totalData, shared.CalculateTotalPage(totalData, limit))
+
+	r.FromPage(models, page)
+}
+
+func (r *GetBookingsResponse) FromPage(models []model.Booking, page gDto.Pagination) {
+	r.Pagination = page
 
 	r.Bookings = make([]BookingResponse, len(models))
 	for i, mod := range models {
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"oil/internal/domains/booking/model"
+	outboxModel "oil/internal/domains/outbox/model"
+	"oil/shared/event"
+)
+
+// BookingEvent is the payload of every booking event. PreviousStatus is only
+// set for status changes.
+type BookingEvent struct {
+	Booking        BookingResponse `json:"booking"`
+	PreviousStatus string          `json:"previous_status,omitempty"`
+}
+
+// ToEventMessages wraps each booking in an event envelope for the outbox.
+// Messages are keyed by booking ID so that events of one booking stay ordered
+// within a partition.
+func ToEventMessages(topic, eventType, actor, previousStatus string, bookings ...model.Booking) ([]outboxModel.Message, error) {
+	messages := make([]outboxModel.Message, len(bookings))
+
+	for i, booking := range bookings {
+		payload := BookingEvent{PreviousStatus: previousStatus}
+		payload.Booking.FromModel(booking)
+
+		message, err := outboxModel.NewMessage(topic, booking.ID, eventType, event.New(eventType, model.EventVersion, actor, payload))
+		if err != nil {
+			return nil, err
+		}
+
+		messages[i] = message
+	}
+
+	return messages, nil
+}
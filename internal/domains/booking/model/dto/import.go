@@ -0,0 +1,128 @@
+package dto
+
+import (
+	"errors"
+	"mime/multipart"
+	"oil/internal/domains/booking/model"
+	"oil/shared/ical"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ImportStatusCreated  = "created"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusConflict = "conflict"
+
+	defaultImportGuestName = "Imported booking"
+	maxGuestNameLength     = 100
+)
+
+var (
+	errImportAllDay     = errors.New("all-day events are not supported")
+	errImportRecurring  = errors.New("recurring events are not supported")
+	errImportCancelled  = errors.New("event is cancelled")
+	errImportMultiDay   = errors.New("event spans multiple days")
+	errImportNoDuration = errors.New("event must end after it starts")
+)
+
+type ImportBookingsRequest struct {
+	RoomID   string                `json:"room_id" validate:"required"`
+	DryRun   bool                  `json:"dry_run"`
+	File     *multipart.FileHeader `json:"file"    validate:"required,mimetypes=text/calendar application/ics,maxfilesize=2"`
+	Calendar multipart.File        `json:"-"`
+}
+
+// ImportItem reports the outcome of importing a single VEVENT.
+type ImportItem struct {
+	UID         string `json:"uid"`
+	Summary     string `json:"summary"`
+	BookingDate string `json:"booking_date,omitempty"`
+	StartTime   string `json:"start_time,omitempty"`
+	EndTime     string `json:"end_time,omitempty"`
+	Status      string `json:"status"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+type ImportBookingsResponse struct {
+	DryRun    bool         `json:"dry_run"`
+	Created   int          `json:"created"`
+	Skipped   int          `json:"skipped"`
+	Conflicts int          `json:"conflicts"`
+	Items     []ImportItem `json:"items"`
+}
+
+// Add records the outcome of an imported event.
+func (r *ImportBookingsResponse) Add(item ImportItem) {
+	switch item.Status {
+	case ImportStatusCreated:
+		r.Created++
+	case ImportStatusSkipped:
+		r.Skipped++
+	case ImportStatusConflict:
+		r.Conflicts++
+	}
+
+	r.Items = append(r.Items, item)
+}
+
+// FromEvent maps a VEVENT to a booking request for roomID. Event times are
+// converted to loc, the application timezone. The returned item describes the
+// event for the import report.
+func FromEvent(event ical.Event, roomID string, loc *time.Location) (CreateBookingRequest, ImportItem, error) {
+	item := ImportItem{UID: event.UID, Summary: event.Summary}
+
+	switch {
+	case event.AllDay:
+		return CreateBookingRequest{}, item, errImportAllDay
+	case event.RRule != "":
+		return CreateBookingRequest{}, item, errImportRecurring
+	case event.Status == ical.StatusCancelled:
+		return CreateBookingRequest{}, item, errImportCancelled
+	}
+
+	start := event.Start.In(loc)
+	end := event.End.In(loc)
+
+	item.BookingDate = start.Format(model.DateFormat)
+	item.StartTime = start.Format(model.TimeFormat)
+	item.EndTime = end.Format(model.TimeFormat)
+
+	if !end.After(start) {
+		return CreateBookingRequest{}, item, errImportNoDuration
+	}
+
+	if end.Format(model.DateFormat) != item.BookingDate {
+		return CreateBookingRequest{}, item, errImportMultiDay
+	}
+
+	guestName := strings.TrimSpace(event.OrganizerName)
+	if guestName == "" {
+		guestName = defaultImportGuestName
+	}
+
+	if utf8.RuneCountInString(guestName) > maxGuestNameLength {
+		guestName = string([]rune(guestName)[:maxGuestNameLength])
+	}
+
+	return CreateBookingRequest{
+		RoomID:      roomID,
+		GuestName:   guestName,
+		GuestEmail:  event.OrganizerEmail,
+		BookingDate: item.BookingDate,
+		StartTime:   item.StartTime,
+		EndTime:     item.EndTime,
+		Purpose:     event.Summary,
+	}, item, nil
+}
+
+// ImportedStatus maps a VEVENT status to the booking status. Events from the
+// previous system were already approved unless they are marked tentative.
+func ImportedStatus(event ical.Event) string {
+	if event.Status == ical.StatusTentative {
+		return model.StatusPending
+	}
+
+	return model.StatusConfirmed
+}
@@ -0,0 +1,211 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"oil/internal/domains/booking/model"
+	gModel "oil/shared/model"
+	"oil/shared/timezone"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errRecurrenceEnd      = errors.New("recurrence requires either until or count")
+	errRecurrenceTooLarge = fmt.Errorf("recurrence must not exceed %d occurrences", model.MaxOccurrences)
+)
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// RecurrenceRequest describes an RRULE-like repetition of a booking.
+type RecurrenceRequest struct {
+	Frequency string   `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	Interval  int      `json:"interval"  validate:"omitempty,min=1,max=12"`
+	Until     string   `json:"until"     validate:"required_without=Count,omitempty,datetime=2006-01-02"`
+	Count     int      `json:"count"     validate:"required_without=Until,omitempty,min=1,max=366"`
+	Weekdays  []string `json:"weekdays"  validate:"omitempty,dive,oneof=MO TU WE TH FR SA SU"`
+}
+
+func (r *RecurrenceRequest) ToModel(first model.Booking, user string) (model.Series, error) {
+	series := model.Series{
+		ID:             uuid.NewString(),
+		RoomID:         first.RoomID,
+		Frequency:      r.Frequency,
+		RepeatInterval: r.interval(),
+		Weekdays:       strings.Join(r.Weekdays, ","),
+		StartDate:      first.BookingDate,
+		StartTime:      first.StartTime,
+		EndTime:        first.EndTime,
+		Metadata: gModel.Metadata{
+			CreatedAt:  timezone.Now(),
+			ModifiedAt: timezone.Now(),
+			CreatedBy:  user,
+			ModifiedBy: user,
+		},
+	}
+
+	if r.Until != "" {
+		until, err := time.Parse(model.DateFormat, r.Until)
+		if err != nil {
+			return model.Series{}, err
+		}
+
+		series.UntilDate = &until
+	}
+
+	if r.Count > 0 {
+		count := r.Count
+		series.OccurrenceCount = &count
+	}
+
+	return series, nil
+}
+
+// Occurrences expands the recurrence into the booking dates starting at start, which is
+// always the first occurrence for daily and monthly rules.
+func (r *RecurrenceRequest) Occurrences(start time.Time) ([]time.Time, error) {
+	if r.Until == "" && r.Count == 0 {
+		return nil, errRecurrenceEnd
+	}
+
+	var until time.Time
+
+	if r.Until != "" {
+		parsed, err := time.Parse(model.DateFormat, r.Until)
+		if err != nil {
+			return nil, err
+		}
+
+		until = parsed
+	}
+
+	dates := []time.Time{}
+
+	// done reports whether date ends the expansion; otherwise date is collected.
+	done := func(date time.Time) bool {
+		if !until.IsZero() && date.After(until) {
+			return true
+		}
+
+		dates = append(dates, date)
+
+		return r.Count > 0 && len(dates) >= r.Count
+	}
+
+	var err error
+
+	switch r.Frequency {
+	case model.FrequencyDaily:
+		err = r.expandDaily(start, done)
+	case model.FrequencyWeekly:
+		err = r.expandWeekly(start, done)
+	case model.FrequencyMonthly:
+		err = r.expandMonthly(start, done)
+	default:
+		err = fmt.Errorf("unsupported frequency: %s", r.Frequency)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return dates, nil
+}
+
+func (r *RecurrenceRequest) expandDaily(start time.Time, done func(time.Time) bool) error {
+	for step := 0; ; step++ {
+		if step >= model.MaxOccurrences {
+			return errRecurrenceTooLarge
+		}
+
+		if done(start.AddDate(0, 0, step*r.interval())) {
+			return nil
+		}
+	}
+}
+
+func (r *RecurrenceRequest) expandWeekly(start time.Time, done func(time.Time) bool) error {
+	days := []time.Weekday{start.Weekday()}
+	if len(r.Weekdays) > 0 {
+		days = days[:0]
+		for _, day := range r.Weekdays {
+			days = append(days, weekdays[day])
+		}
+	}
+
+	// Order the days Monday first, matching the RRULE default week start.
+	slices.SortFunc(days, func(a, b time.Weekday) int {
+		return mondayOffset(a) - mondayOffset(b)
+	})
+	days = slices.Compact(days)
+
+	weekStart := start.AddDate(0, 0, -mondayOffset(start.Weekday()))
+	count := 0
+
+	for week := 0; ; week += r.interval() {
+		base := weekStart.AddDate(0, 0, 7*week)
+
+		for _, day := range days {
+			date := base.AddDate(0, 0, mondayOffset(day))
+			if date.Before(start) {
+				continue
+			}
+
+			if count >= model.MaxOccurrences {
+				return errRecurrenceTooLarge
+			}
+
+			count++
+
+			if done(date) {
+				return nil
+			}
+		}
+	}
+}
+
+func (r *RecurrenceRequest) expandMonthly(start time.Time, done func(time.Time) bool) error {
+	count := 0
+
+	for step := 0; ; step++ {
+		date := time.Date(start.Year(), start.Month()+time.Month(step*r.interval()), start.Day(), 0, 0, 0, 0, start.Location())
+
+		// Months without this day of month are skipped, as RFC 5545 does.
+		if date.Day() != start.Day() {
+			continue
+		}
+
+		if count >= model.MaxOccurrences {
+			return errRecurrenceTooLarge
+		}
+
+		count++
+
+		if done(date) {
+			return nil
+		}
+	}
+}
+
+func (r *RecurrenceRequest) interval() int {
+	if r.Interval < 1 {
+		return 1
+	}
+
+	return r.Interval
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7 //nolint:mnd
+}
@@ -0,0 +1,93 @@
+package dto_test
+
+import (
+	"testing"
+	"time"
+
+	"oil/internal/domains/booking/model"
+	"oil/internal/domains/booking/model/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecurrenceRequest_Occurrences(t *testing.T) {
+	// 2025-01-06 is a Monday.
+	start := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		recurrence dto.RecurrenceRequest
+		expected   []string
+		wantErr    bool
+	}{
+		{
+			name:       "daily with count",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyDaily, Count: 3},
+			expected:   []string{"2025-01-06", "2025-01-07", "2025-01-08"},
+		},
+		{
+			name:       "daily every other day until date",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyDaily, Interval: 2, Until: "2025-01-10"},
+			expected:   []string{"2025-01-06", "2025-01-08", "2025-01-10"},
+		},
+		{
+			name:       "weekly on the start weekday",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyWeekly, Count: 3},
+			expected:   []string{"2025-01-06", "2025-01-13", "2025-01-20"},
+		},
+		{
+			name:       "weekly on several weekdays skips days before start",
+			start:      start.AddDate(0, 0, 2),
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyWeekly, Weekdays: []string{"FR", "MO"}, Until: "2025-01-17"},
+			expected:   []string{"2025-01-10", "2025-01-13", "2025-01-17"},
+		},
+		{
+			name:       "biweekly",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyWeekly, Interval: 2, Count: 2},
+			expected:   []string{"2025-01-06", "2025-01-20"},
+		},
+		{
+			name:       "monthly skips months without the day",
+			start:      time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyMonthly, Count: 3},
+			expected:   []string{"2025-01-31", "2025-03-31", "2025-05-31"},
+		},
+		{
+			name:       "missing end",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyDaily},
+			wantErr:    true,
+		},
+		{
+			name:       "too many occurrences",
+			start:      start,
+			recurrence: dto.RecurrenceRequest{Frequency: model.FrequencyDaily, Until: "2027-01-01"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := tt.recurrence.Occurrences(tt.start)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			actual := make([]string, len(dates))
+			for i, date := range dates {
+				actual[i] = date.Format(model.DateFormat)
+			}
+
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
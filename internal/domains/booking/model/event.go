@@ -0,0 +1,13 @@
+package model
+
+const (
+	EventCreated       = "booking.created"
+	EventUpdated       = "booking.updated"
+	EventStatusChanged = "booking.status_changed"
+	EventDeleted       = "booking.deleted"
+	EventRestored      = "booking.restored"
+
+	// EventVersion is the version of the booking event payload. Bump it when a
+	// change to the payload would break existing consumers.
+	EventVersion = 1
+)
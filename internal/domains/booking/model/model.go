@@ -2,6 +2,7 @@ package model
 
 import (
 	"oil/shared/model"
+	"slices"
 	"time"
 )
 
@@ -20,18 +21,68 @@ const (
 	FieldPurpose     = "purpose"
 	FieldStatus      = "status"
 	FieldCreatedBy   = "created_by"
+
+	FieldStatusReason    = "status_reason"
+	FieldStatusChangedBy = "status_changed_by"
+	FieldStatusChangedAt = "status_changed_at"
+
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusRejected  = "rejected"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+	StatusNoShow    = "no_show"
+
+	DateFormat = "2006-01-02"
+	TimeFormat = "15:04"
+
+	// CalendarPastDays is how far back calendar feeds include bookings.
+	CalendarPastDays = 30
 )
 
+// ActiveStatuses lists the statuses that occupy a room's time slot.
+var ActiveStatuses = []string{StatusPending, StatusConfirmed}
+
+// CalendarStatuses lists the statuses exported to calendar feeds.
+var CalendarStatuses = []string{StatusPending, StatusConfirmed, StatusCompleted}
+
+// transitions lists the statuses each status may move to. Statuses without an
+// entry are final.
+var transitions = map[string][]string{
+	StatusPending:   {StatusConfirmed, StatusRejected, StatusCancelled},
+	StatusConfirmed: {StatusCancelled, StatusCompleted, StatusNoShow},
+}
+
+// CanTransition reports whether a booking may move from one status to another.
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
 type Booking struct {
-	ID          string    `db:"id"`
-	RoomID      string    `db:"room_id"`
-	GuestName   string    `db:"guest_name"`
-	GuestEmail  string    `db:"guest_email"`
+	ID          string    `db:"id"           query:"filter"`
+	RoomID      string    `db:"room_id"      query:"filter"`
+	GuestName   string    `db:"guest_name"   query:"sort,filter"`
+	GuestEmail  string    `db:"guest_email"  query:"filter"`
 	GuestPhone  string    `db:"guest_phone"`
-	BookingDate time.Time `db:"booking_date"`
-	StartTime   time.Time `db:"start_time"`
-	EndTime     time.Time `db:"end_time"`
+	BookingDate time.Time `db:"booking_date" query:"sort,filter"`
+	StartTime   time.Time `db:"start_time"   query:"sort,filter"`
+	EndTime     time.Time `db:"end_time"     query:"sort,filter"`
 	Purpose     string    `db:"purpose"`
-	Status      string    `db:"status"`
+	Status      string    `db:"status"       query:"sort,filter"`
+	SeriesID    *string   `db:"series_id"    query:"filter"`
+
+	StatusReason    *string    `db:"status_reason"`
+	StatusChangedBy *string    `db:"status_changed_by" query:"filter"`
+	StatusChangedAt *time.Time `db:"status_changed_at" query:"sort,filter"`
 	model.Metadata
+	model.SoftDelete
+	model.Versioned
+}
+
+// Overlaps reports whether both bookings occupy the same room at the same time.
+func (b Booking) Overlaps(other Booking) bool {
+	return b.RoomID == other.RoomID &&
+		b.BookingDate.Equal(other.BookingDate) &&
+		b.StartTime.Before(other.EndTime) &&
+		other.StartTime.Before(b.EndTime)
 }
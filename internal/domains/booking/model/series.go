@@ -0,0 +1,37 @@
+package model
+
+import (
+	"oil/shared/model"
+	"time"
+)
+
+const (
+	SeriesTableName  = "booking_series"
+	SeriesEntityName = "booking_series"
+
+	FieldSeriesID = "series_id"
+
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+
+	ScopeThis      = "this"
+	ScopeFollowing = "following"
+	ScopeAll       = "all"
+
+	MaxOccurrences = 366
+)
+
+type Series struct {
+	ID              string     `db:"id"`
+	RoomID          string     `db:"room_id"`
+	Frequency       string     `db:"frequency"`
+	RepeatInterval  int        `db:"repeat_interval"`
+	Weekdays        string     `db:"weekdays"`
+	UntilDate       *time.Time `db:"until_date"`
+	OccurrenceCount *int       `db:"occurrence_count"`
+	StartDate       time.Time  `db:"start_date"`
+	StartTime       time.Time  `db:"start_time"`
+	EndTime         time.Time  `db:"end_time"`
+	model.Metadata
+}
@@ -4,33 +4,220 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"oil/infras/otel"
 	"oil/infras/postgres"
 	"oil/internal/domains/booking/model"
+	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	gRepo "oil/shared/repository"
+	"time"
 )
 
 type Booking interface {
 	Insert(ctx context.Context, model model.Booking) error
+	InsertBulk(ctx context.Context, models []model.Booking) error
 	Get(ctx context.Context, filter gDto.FilterGroup, columns ...string) (model.Booking, error)
 	GetAll(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.Booking, error)
+	GetPage(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.Booking, gDto.Pagination, error)
+	WithFilters(filter gDto.FilterGroup, params gDto.QueryParams) (gDto.FilterGroup, error)
 	Exist(ctx context.Context, filter gDto.FilterGroup) (bool, error)
 	Count(ctx context.Context, filter gDto.FilterGroup) (int, error)
 	Update(ctx context.Context, req map[string]any, filter gDto.FilterGroup) error
 	Delete(ctx context.Context, filter gDto.FilterGroup) error
+	Restore(ctx context.Context, filter gDto.FilterGroup) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+	Overlaps(ctx context.Context, booking model.Booking, excludeID string) (bool, error)
+	InsertSeries(ctx context.Context, series model.Series, bookings []model.Booking) error
 }
 
 type repositoryImpl struct {
 	gRepo.Repository[model.Booking]
-	db   *postgres.Connection
-	otel otel.Otel
+	series gRepo.Repository[model.Series]
+	tx     postgres.TxManager
+	otel   otel.Otel
 }
 
-func New(db *postgres.Connection, otel otel.Otel) Booking {
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) Booking {
 	return &repositoryImpl{
-		Repository: gRepo.NewRepository[model.Booking](model.EntityName, model.TableName, model.FieldID, db, otel),
-		db:         db,
+		Repository: gRepo.NewRepository[model.Booking](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
+		series:     gRepo.NewRepository[model.Series](model.SeriesEntityName, model.SeriesTableName, model.FieldID, db, tx, otel),
+		tx:         tx,
 		otel:       otel,
 	}
 }
+
+// InsertSeries stores a booking series together with all of its occurrences
+// in a single unit of work.
+func (r *repositoryImpl) InsertSeries(ctx context.Context, series model.Series, bookings []model.Booking) error {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".booking.InsertSeries")
+	defer scope.End()
+
+	return r.tx.Do(ctx, func(ctx context.Context) error { //nolint:wrapcheck
+		if err := r.series.Insert(ctx, series); err != nil {
+			return fmt.Errorf("failed to insert booking series: %w", err)
+		}
+
+		if err := r.InsertBulk(ctx, bookings); err != nil {
+			return fmt.Errorf("failed to insert series bookings: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// Purge permanently removes the bookings soft deleted before the given time,
+// together with the series left without any booking.
+func (r *repositoryImpl) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".booking.Purge")
+	defer scope.End()
+
+	var purged int64
+
+	err := r.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+
+		if purged, err = r.Repository.Purge(ctx, before); err != nil {
+			return fmt.Errorf("failed to purge bookings: %w", err)
+		}
+
+		if err = r.series.Delete(ctx, emptySeriesFilter()); err != nil {
+			return fmt.Errorf("failed to delete empty booking series: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		scope.TraceError(err)
+
+		return 0, err //nolint:wrapcheck
+	}
+
+	return purged, nil
+}
+
+// emptySeriesFilter matches series without any booking, deleted or not.
+func emptySeriesFilter() gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Operator: gDto.FilterNotExists,
+				Table:    model.TableName,
+				Value: gDto.FilterGroup{
+					Filters: []any{
+						gDto.Filter{
+							Operator: gDto.FilterPlainQuery,
+							Value:    fmt.Sprintf("%s.%s = %s.%s", model.TableName, model.FieldSeriesID, model.SeriesTableName, model.FieldID),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Overlaps reports whether an active booking of the same room on the same date
+// intersects the time range of the given booking. excludeID skips the booking
+// being updated.
+func (r *repositoryImpl) Overlaps(ctx context.Context, booking model.Booking, excludeID string) (bool, error) {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".booking.Overlaps")
+	defer scope.End()
+
+	filter := OverlapFilter(booking.RoomID, booking.BookingDate.Format(model.DateFormat), booking.StartTime.Format(model.TimeFormat), booking.EndTime.Format(model.TimeFormat))
+
+	if excludeID != constant.Empty {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			Field:    model.FieldID,
+			Operator: gDto.FilterOperatorNotEq,
+			Value:    excludeID,
+			Table:    model.TableName,
+		})
+	}
+
+	exist, err := r.Exist(ctx, filter)
+	if err != nil {
+		scope.TraceError(err)
+
+		return false, fmt.Errorf("failed to check overlapping bookings: %w", err)
+	}
+
+	return exist, nil
+}
+
+// OverlapFilter matches active bookings of a room on a date whose time range
+// intersects [start, end).
+func OverlapFilter(roomID, date, start, end string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldRoomID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    roomID,
+				Table:    model.TableName,
+			},
+			WindowFilter(date, start, end),
+		},
+	}
+}
+
+// WindowFilter matches active bookings on a date whose time range intersects [start, end).
+// Deleted bookings are excluded explicitly, as the filter is also used in
+// subqueries that the repository does not scope.
+func WindowFilter(date, start, end string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    constant.FieldDeletedAt,
+				Operator: gDto.FilterIsNull,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Field:    model.FieldBookingDate,
+				Operator: gDto.FilterOperatorEq,
+				Value:    date,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Field:    model.FieldStatus,
+				Operator: gDto.FilterOperatorIn,
+				Value:    model.ActiveStatuses,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Field:    model.FieldStartTime,
+				Operator: gDto.FilterOperatorLess,
+				Value:    end,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Field:    model.FieldEndTime,
+				Operator: gDto.FilterOperatorGreater,
+				Value:    start,
+				Table:    model.TableName,
+			},
+		},
+	}
+}
+
+// CalendarFilter matches bookings exported to calendar feeds from the given date on.
+func CalendarFilter(from string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldStatus,
+				Operator: gDto.FilterOperatorIn,
+				Value:    model.CalendarStatuses,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Field:    model.FieldBookingDate,
+				Operator: gDto.FilterOperatorGreaterEq,
+				Value:    from,
+				Table:    model.TableName,
+			},
+		},
+	}
+}
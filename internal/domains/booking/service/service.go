@@ -2,19 +2,32 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"oil/config"
 	"oil/infras/otel"
+	"oil/infras/postgres"
 	"oil/internal/domains/booking/model"
 	"oil/internal/domains/booking/model/dto"
 	"oil/internal/domains/booking/repository"
+	outboxModel "oil/internal/domains/outbox/model"
+	outboxRepo "oil/internal/domains/outbox/repository"
 	roomModel "oil/internal/domains/room/model"
 	roomRepo "oil/internal/domains/room/repository"
+	userModel "oil/internal/domains/user/model"
+	userRepo "oil/internal/domains/user/repository"
 	"oil/shared"
 	"oil/shared/cache"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	"oil/shared/failure"
+	"oil/shared/ical"
+	"oil/shared/timezone"
+	"oil/shared/token"
+	"oil/shared/validator"
+	"slices"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -23,32 +36,62 @@ const (
 	cacheGetBooking    = "booking:get"
 	cacheGetAllBooking = "booking:gets"
 	cacheCountBooking  = "booking:count"
+
+	errBookingOverlap       = "room is already booked for the requested time"
+	errBookingModified      = "booking has been modified"
+	errBookingStatusChanged = "booking status has been changed concurrently"
+
+	calendarFeedPath = "/v1/bookings/mybookings/calendar.ics"
+
+	maxImportEvents = 1000
 )
 
+var errInvalidScope = fmt.Sprintf("scope must be one of %s, %s or %s", model.ScopeThis, model.ScopeFollowing, model.ScopeAll)
+
 type Booking interface {
 	Create(ctx context.Context, req dto.CreateBookingRequest) error
 	GetAll(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (dto.GetBookingsResponse, error)
 	Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (int, error)
 	Get(ctx context.Context, id string) (dto.BookingResponse, error)
-	Update(ctx context.Context, req dto.UpdateBookingRequest, id string) error
-	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, req dto.UpdateBookingRequest, id, editScope string, version *int) error
+	Delete(ctx context.Context, id, editScope string, version *int) error
+	Restore(ctx context.Context, id string) error
+	ChangeStatus(ctx context.Context, id, status string, req dto.ChangeStatusRequest) error
+	IssueCalendarToken(ctx context.Context) (dto.CalendarTokenResponse, error)
+	GetUserCalendar(ctx context.Context, feedToken string) ([]byte, error)
+	Import(ctx context.Context, req dto.ImportBookingsRequest) (dto.ImportBookingsResponse, error)
 }
 
 type serviceImpl struct {
-	repo     repository.Booking
-	roomRepo roomRepo.Room
-	cfg      *config.Config
-	cache    cache.RedisCache
-	otel     otel.Otel
+	repo       repository.Booking
+	roomRepo   roomRepo.Room
+	userRepo   userRepo.User
+	outboxRepo outboxRepo.Outbox
+	tx         postgres.TxManager
+	cfg        *config.Config
+	cache      cache.RedisCache
+	otel       otel.Otel
 }
 
-func New(repo repository.Booking, roomRepo roomRepo.Room, cfg *config.Config, cache cache.RedisCache, otel otel.Otel) Booking {
+func New(
+	repo repository.Booking,
+	roomRepo roomRepo.Room,
+	userRepo userRepo.User,
+	outboxRepo outboxRepo.Outbox,
+	tx postgres.TxManager,
+	cfg *config.Config,
+	cache cache.RedisCache,
+	otel otel.Otel,
+) Booking {
 	return &serviceImpl{
-		repo:     repo,
-		roomRepo: roomRepo,
-		cfg:      cfg,
-		cache:    cache,
-		otel:     otel,
+		repo:       repo,
+		roomRepo:   roomRepo,
+		userRepo:   userRepo,
+		outboxRepo: outboxRepo,
+		tx:         tx,
+		cfg:        cfg,
+		cache:      cache,
+		otel:       otel,
 	}
 }
 
@@ -71,6 +114,10 @@ func (s *serviceImpl) Create(ctx context.Context, req dto.CreateBookingRequest)
 		return failure.BadRequestFromString("room does not exist") // nolint:wrapcheck
 	}
 
+	if req.Recurrence != nil {
+		return s.createSeries(ctx, req, user)
+	}
+
 	booking, err := req.ToModel(user)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to parse booking request")
@@ -78,7 +125,23 @@ func (s *serviceImpl) Create(ctx context.Context, req dto.CreateBookingRequest)
 		return failure.BadRequestFromString(fmt.Sprintf("invalid date/time format: %v", err)) // nolint:wrapcheck
 	}
 
-	if err = s.repo.Insert(ctx, booking); err != nil {
+	if err = s.ensureAvailable(ctx, booking, constant.Empty); err != nil {
+		return err
+	}
+
+	events, err := s.events(ctx, model.EventCreated, constant.Empty, booking)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.Insert(ctx, booking) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
 		log.Error().Err(err).Msg("failed to create booking")
 
 		return fmt.Errorf("failed to create booking: %w", err)
@@ -99,6 +162,7 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 	defer scope.End()
 	defer scope.TraceIfError(err)
 
+	filter = ownBookings(ctx, filter)
 	cacheKey := shared.BuildCacheKeyWithQuery(cacheGetAllBooking, req, filter)
 
 	err = s.cache.Get(ctx, cacheKey, &res)
@@ -108,21 +172,12 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 		return res, nil
 	}
 
-	total, err := s.Count(ctx, req, filter)
+	models, page, err := s.list(ctx, req, filter)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to count bookings")
-
-		return res, fmt.Errorf("failed to count bookings: %w", err)
-	}
-
-	models, err := s.repo.GetAll(ctx, req, filter)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to get bookings")
-
-		return res, fmt.Errorf("failed to get bookings: %w", err)
+		return res, err
 	}
 
-	res.FromModels(models, total, req.Limit)
+	res.FromPage(models, page)
 
 	go func() {
 		c := context.WithoutCancel(ctx)
@@ -135,6 +190,75 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 	return res, nil
 }
 
+// ownBookings narrows filter to the bookings of the authenticated user, unless
+// they are an admin and may list every booking.
+func ownBookings(ctx context.Context, filter gDto.FilterGroup) gDto.FilterGroup {
+	if shared.IsAdmin(ctx) {
+		return filter
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			filter,
+			gDto.Filter{
+				ArgName:  "owner",
+				Field:    model.FieldCreatedBy,
+				Operator: gDto.FilterOperatorEq,
+				Value:    user,
+				Table:    model.TableName,
+			},
+		},
+		WithDeleted: filter.WithDeleted,
+	}
+}
+
+// list loads bookings by cursor or by page number, narrowed by the filters of
+// the query string. Totals are counted in page mode and, when asked for, in
+// cursor mode.
+func (s *serviceImpl) list(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) ([]model.Booking, gDto.Pagination, error) {
+	var (
+		models []model.Booking
+		page   gDto.Pagination
+	)
+
+	filter, err := s.repo.WithFilters(filter, req)
+	if err != nil {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if req.UseCursor() {
+		models, page, err = s.repo.GetPage(ctx, req, filter)
+	} else {
+		models, err = s.repo.GetAll(ctx, req, filter)
+	}
+
+	if errors.Is(err, gDto.ErrInvalidQuery) {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get bookings")
+
+		return nil, page, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	if !req.UseCursor() || req.IncludeTotal {
+		total, err := s.Count(ctx, req, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to count bookings")
+
+			return nil, page, fmt.Errorf("failed to count bookings: %w", err)
+		}
+
+		page.SetTotal(total, shared.CalculateTotalPage(total, req.Limit))
+	}
+
+	return models, page, nil
+}
+
 func (s *serviceImpl) Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (res int, err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Count")
 	defer scope.End()
@@ -178,6 +302,10 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (res dto.BookingRespon
 	if err == nil {
 		log.Info().Str("cacheKey", cacheKey).Msg("cache hit for booking")
 
+		if !shared.CanAccess(ctx, res.CreatedBy) {
+			return dto.BookingResponse{}, failure.ResourceRestrictedError
+		}
+
 		return res, nil
 	}
 
@@ -192,6 +320,10 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (res dto.BookingRespon
 		return res, failure.NotFound("booking not found") // nolint:wrapcheck
 	}
 
+	if !shared.CanAccess(ctx, booking.CreatedBy) {
+		return res, failure.ResourceRestrictedError
+	}
+
 	res.FromModel(booking)
 
 	go func() {
@@ -205,7 +337,11 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (res dto.BookingRespon
 	return res, nil
 }
 
-func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateBookingRequest, id string) error {
+// Update applies the request to the booking, or to the occurrences of its
+// series in scope, provided the booking is still at version when one is given.
+// Only writes to the booking alone are conditioned on it; series writes check
+// the version of the booking they were addressed through beforehand.
+func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateBookingRequest, id, editScope string, version *int) error {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Update")
 	defer scope.End()
 	defer scope.TraceIfError(nil)
@@ -214,24 +350,58 @@ func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateBookingRequest,
 		return failure.BadRequestFromString("update request cannot be empty") // nolint:wrapcheck
 	}
 
+	current, editScope, err := s.getForScope(ctx, id, editScope)
+	if err != nil {
+		return err
+	}
+
+	if version != nil && *version != current.Version {
+		return failure.PreconditionFailed(errBookingModified) // nolint:wrapcheck
+	}
+
+	if editScope != model.ScopeThis {
+		return s.updateSeries(ctx, req, current, editScope)
+	}
+
 	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
 	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.Version = version
 
-	exist, err := s.repo.Exist(ctx, filter)
+	updated, err := req.Apply(current, user)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to check if booking exists")
+		log.Error().Err(err).Msg("failed to parse booking request")
 
-		return fmt.Errorf("failed to check if booking exists: %w", err)
+		return failure.BadRequestFromString(fmt.Sprintf("invalid date/time format: %v", err)) // nolint:wrapcheck
 	}
 
-	if !exist {
-		log.Error().Msg("booking not found")
+	updatedFields := shared.TransformFields(req, user)
+	req.ScheduleFields(updated, updatedFields)
 
-		return failure.NotFound("booking not found") // nolint:wrapcheck
+	if req.HasSchedule() {
+		if err := s.ensureAvailable(ctx, updated, current.ID); err != nil {
+			return err
+		}
 	}
 
-	updatedFields := shared.TransformFields(req, user)
-	if err := s.repo.Update(ctx, updatedFields, filter); err != nil {
+	events, err := s.events(ctx, model.EventUpdated, constant.Empty, updated)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.Update(ctx, updatedFields, filter) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.PreconditionFailed(errBookingModified) // nolint:wrapcheck
+		}
+
 		log.Error().Err(err).Msg("failed to update booking")
 
 		return fmt.Errorf("failed to update booking: %w", err)
@@ -251,28 +421,239 @@ func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateBookingRequest,
 	return nil
 }
 
-func (s *serviceImpl) Delete(ctx context.Context, id string) error {
+// Delete deletes the booking, or the occurrences of its series in scope,
+// conditioned on version like Update.
+func (s *serviceImpl) Delete(ctx context.Context, id, editScope string, version *int) error {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Delete")
 	defer scope.End()
 	defer scope.TraceIfError(nil)
 
-	exist, err := s.repo.Exist(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	current, editScope, err := s.getForScope(ctx, id, editScope)
+	if err != nil {
+		return err
+	}
+
+	if version != nil && *version != current.Version {
+		return failure.PreconditionFailed(errBookingModified) // nolint:wrapcheck
+	}
+
+	err = s.tx.Do(ctx, func(ctx context.Context) error {
+		deleted := []model.Booking{current}
+
+		// Deleted occurrences are loaded in the transaction so their events
+		// carry the full booking.
+		if editScope != model.ScopeThis && s.eventsEnabled() {
+			occurrences, err := s.repo.GetAll(ctx, gDto.QueryParams{}, seriesFilter(current, editScope))
+			if err != nil {
+				return fmt.Errorf("failed to get series bookings: %w", err)
+			}
+
+			deleted = occurrences
+		}
+
+		events, err := s.events(ctx, model.EventDeleted, constant.Empty, deleted...)
+		if err != nil {
+			return err
+		}
+
+		return s.withEvents(ctx, events, func(ctx context.Context) error {
+			if editScope == model.ScopeThis {
+				filter := shared.FilterByID(id, model.FieldID, model.TableName)
+				filter.Version = version
+
+				return s.repo.Delete(ctx, filter) //nolint:wrapcheck
+			}
+
+			return s.repo.Delete(ctx, seriesFilter(current, editScope)) //nolint:wrapcheck
+		})
+	})
+	if err != nil {
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.PreconditionFailed(errBookingModified) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to delete booking")
+
+		return fmt.Errorf("failed to delete booking: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		if editScope == model.ScopeThis {
+			if err := s.cache.Delete(c, shared.BuildCacheKey(cacheGetBooking, id)); err != nil {
+				log.Error().Err(err).Msg("failed to delete booking from cache")
+			}
+		} else {
+			shared.InvalidateCaches(c, s.cache, cacheGetBooking)
+		}
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllBooking)
+		shared.InvalidateCaches(c, s.cache, cacheCountBooking)
+	}()
+
+	return nil
+}
+
+// Restore brings back a deleted booking. Its room must not be deleted and, for
+// an active booking, its time slot must still be free.
+func (s *serviceImpl) Restore(ctx context.Context, id string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Restore")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.WithDeleted = true
+
+	current, err := s.repo.Get(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get booking")
+
+		return fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if current.ID == constant.Empty {
+		return failure.NotFound("booking not found") // nolint:wrapcheck
+	}
+
+	if current.DeletedAt == nil {
+		return failure.Conflict("booking is not deleted") // nolint:wrapcheck
+	}
+
+	roomExists, err := s.roomRepo.Exist(ctx, shared.FilterByID(current.RoomID, roomModel.FieldID, roomModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check if room exists")
+
+		return fmt.Errorf("failed to check if room exists: %w", err)
+	}
+
+	if !roomExists {
+		return failure.Conflict("room of the booking is deleted, restore the room first") // nolint:wrapcheck
+	}
+
+	if err = s.ensureAvailable(ctx, current, current.ID); err != nil {
+		return err
+	}
+
+	events, err := s.events(ctx, model.EventRestored, constant.Empty, current)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.Restore(ctx, filter) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to restore booking")
+
+		return fmt.Errorf("failed to restore booking: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllBooking)
+		shared.InvalidateCaches(c, s.cache, cacheCountBooking)
+	}()
+
+	return nil
+}
+
+// ChangeStatus moves a booking to the given status when the transition is
+// allowed, recording who changed it, when and why.
+func (s *serviceImpl) ChangeStatus(ctx context.Context, id, status string, req dto.ChangeStatusRequest) error {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".ChangeStatus")
+	defer scope.End()
+	defer scope.TraceIfError(nil)
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	current, err := s.repo.Get(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
 	if err != nil {
-		log.Error().Err(err).Msg("failed to check if booking exists")
+		log.Error().Err(err).Msg("failed to get booking")
 
-		return fmt.Errorf("failed to check if booking exists: %w", err)
+		return fmt.Errorf("failed to get booking: %w", err)
 	}
 
-	if !exist {
+	if current.ID == constant.Empty {
 		log.Error().Msg("booking not found")
 
 		return failure.NotFound("booking not found") // nolint:wrapcheck
 	}
 
-	if err := s.repo.Delete(ctx, shared.FilterByID(id, model.FieldID, model.TableName)); err != nil {
-		log.Error().Err(err).Msg("failed to delete booking")
+	if !shared.CanAccess(ctx, current.CreatedBy) {
+		return failure.ResourceRestrictedError
+	}
 
-		return fmt.Errorf("failed to delete booking: %w", err)
+	if !model.CanTransition(current.Status, status) {
+		return failure.Conflict(fmt.Sprintf("cannot change booking status from %s to %s", current.Status, status)) // nolint:wrapcheck
+	}
+
+	changedAt := timezone.Now()
+
+	changed := current
+	changed.Status = status
+	changed.StatusChangedBy = &user
+	changed.StatusChangedAt = &changedAt
+	changed.ModifiedAt = changedAt
+	changed.ModifiedBy = user
+
+	if req.Reason != constant.Empty {
+		changed.StatusReason = &req.Reason
+	}
+
+	updatedFields := shared.TransformFields(req, user)
+	updatedFields[model.FieldStatus] = status
+	updatedFields[model.FieldStatusChangedBy] = user
+	updatedFields[model.FieldStatusChangedAt] = changedAt
+	updatedFields[constant.FieldModifiedAt] = changedAt
+
+	// Matching on the current status and version keeps concurrent transitions
+	// from overwriting each other: the one that lost matches no row and fails.
+	filter := gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Version:  &current.Version,
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    id,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				ArgName:  "current_status",
+				Field:    model.FieldStatus,
+				Operator: gDto.FilterOperatorEq,
+				Value:    current.Status,
+				Table:    model.TableName,
+			},
+		},
+	}
+
+	events, err := s.events(ctx, model.EventStatusChanged, current.Status, changed)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.Update(ctx, updatedFields, filter) //nolint:wrapcheck
+	})
+	if err != nil {
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.Conflict(errBookingStatusChanged) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to change booking status")
+
+		return fmt.Errorf("failed to change booking status: %w", err)
 	}
 
 	go func() {
@@ -288,3 +669,528 @@ func (s *serviceImpl) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// IssueCalendarToken creates a new secret token for the caller's personal
+// calendar feed. Only its hash is stored, so issuing a token revokes the previous one.
+func (s *serviceImpl) IssueCalendarToken(ctx context.Context) (res dto.CalendarTokenResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".IssueCalendarToken")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	feedToken, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate calendar token")
+
+		return res, fmt.Errorf("failed to generate calendar token: %w", err)
+	}
+
+	updatedFields := map[string]any{
+		userModel.FieldCalendarToken: token.Hash(feedToken),
+		constant.FieldModifiedAt:     timezone.Now(),
+		constant.FieldModifiedBy:     user,
+	}
+
+	if err = s.userRepo.Update(ctx, updatedFields, shared.FilterByID(user, userModel.FieldID, userModel.TableName)); err != nil {
+		log.Error().Err(err).Msg("failed to save calendar token")
+
+		return res, fmt.Errorf("failed to save calendar token: %w", err)
+	}
+
+	res.Token = feedToken
+	res.FeedURL = calendarFeedPath + "?" + constant.RequestParamToken + "=" + feedToken
+
+	return res, nil
+}
+
+// GetUserCalendar renders the bookings of the user owning feedToken as an
+// iCalendar feed. Calendar clients cannot send bearer tokens, so the feed token
+// authenticates the request.
+func (s *serviceImpl) GetUserCalendar(ctx context.Context, feedToken string) (res []byte, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GetUserCalendar")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	if feedToken == constant.Empty {
+		return res, failure.Unauthorized("missing calendar token") // nolint:wrapcheck
+	}
+
+	user, err := s.userRepo.Get(ctx, shared.FilterByID(token.Hash(feedToken), userModel.FieldCalendarToken, userModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get calendar owner")
+
+		return res, fmt.Errorf("failed to get calendar owner: %w", err)
+	}
+
+	if user.ID == constant.Empty || !user.Active {
+		return res, failure.Unauthorized("invalid calendar token") // nolint:wrapcheck
+	}
+
+	from := timezone.Now().AddDate(0, 0, -model.CalendarPastDays).Format(model.DateFormat)
+
+	filter := repository.CalendarFilter(from)
+	filter.Filters = append(filter.Filters, gDto.Filter{
+		Field:    model.FieldCreatedBy,
+		Operator: gDto.FilterOperatorEq,
+		Value:    user.ID,
+		Table:    model.TableName,
+	})
+
+	params := gDto.QueryParams{SortBy: model.FieldBookingDate, SortDir: gDto.SortDirAsc}
+
+	bookings, err := s.repo.GetAll(ctx, params, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user bookings")
+
+		return res, fmt.Errorf("failed to get user bookings: %w", err)
+	}
+
+	rooms, err := s.roomNames(ctx, bookings)
+	if err != nil {
+		return res, err
+	}
+
+	calendar := dto.ToCalendar(bookings, dto.CalendarOptions{
+		AppName:  s.cfg.App.Name,
+		Name:     "My bookings",
+		Rooms:    rooms,
+		Detailed: true,
+	})
+
+	return calendar.Marshal(), nil
+}
+
+// Import creates bookings in a room from the VEVENTs of an iCalendar file.
+// Every event is checked against existing bookings and the other events of the
+// file; the accepted ones are inserted together unless req.DryRun is set.
+func (s *serviceImpl) Import(ctx context.Context, req dto.ImportBookingsRequest) (res dto.ImportBookingsResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Import")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	roomExists, err := s.roomRepo.Exist(ctx, shared.FilterByID(req.RoomID, roomModel.FieldID, roomModel.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check if room exists")
+
+		return res, fmt.Errorf("failed to check if room exists: %w", err)
+	}
+
+	if !roomExists {
+		return res, failure.BadRequestFromString("room does not exist") // nolint:wrapcheck
+	}
+
+	calendar, err := ical.Parse(req.Calendar, timezone.GetLocation())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to parse calendar file")
+
+		return res, failure.BadRequestFromString(fmt.Sprintf("invalid calendar file: %v", err)) // nolint:wrapcheck
+	}
+
+	if len(calendar.Events) > maxImportEvents {
+		return res, failure.BadRequestFromString(fmt.Sprintf("calendar must not contain more than %d events", maxImportEvents)) // nolint:wrapcheck
+	}
+
+	res.DryRun = req.DryRun
+	res.Items = []dto.ImportItem{}
+	accepted := []model.Booking{}
+
+	for _, event := range calendar.Events {
+		bookingReq, item, err := dto.FromEvent(event, req.RoomID, timezone.GetLocation())
+		if err == nil {
+			err = validator.ValidateStruct(&bookingReq)
+		}
+
+		if err != nil {
+			item.Status = dto.ImportStatusSkipped
+			item.Reason = err.Error()
+			res.Add(item)
+
+			continue
+		}
+
+		booking, err := bookingReq.ToModel(user)
+		if err != nil {
+			return res, fmt.Errorf("failed to map imported event: %w", err)
+		}
+
+		booking.Status = dto.ImportedStatus(event)
+
+		if slices.ContainsFunc(accepted, booking.Overlaps) {
+			item.Status = dto.ImportStatusConflict
+			item.Reason = "overlaps another event in the file"
+			res.Add(item)
+
+			continue
+		}
+
+		overlaps, err := s.repo.Overlaps(ctx, booking, constant.Empty)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to check overlapping bookings")
+
+			return res, fmt.Errorf("failed to check overlapping bookings: %w", err)
+		}
+
+		if overlaps {
+			item.Status = dto.ImportStatusConflict
+			item.Reason = errBookingOverlap
+			res.Add(item)
+
+			continue
+		}
+
+		item.Status = dto.ImportStatusCreated
+		res.Add(item)
+
+		accepted = append(accepted, booking)
+	}
+
+	if req.DryRun || len(accepted) == 0 {
+		return res, nil
+	}
+
+	events, err := s.events(ctx, model.EventCreated, constant.Empty, accepted...)
+	if err != nil {
+		return res, err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.InsertBulk(ctx, accepted) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return res, failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to import bookings")
+
+		return res, fmt.Errorf("failed to import bookings: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllBooking)
+		shared.InvalidateCaches(c, s.cache, cacheCountBooking)
+	}()
+
+	return res, nil
+}
+
+// roomNames maps the rooms referenced by bookings to their names.
+func (s *serviceImpl) roomNames(ctx context.Context, bookings []model.Booking) (map[string]string, error) {
+	names := map[string]string{}
+	if len(bookings) == 0 {
+		return names, nil
+	}
+
+	ids := []string{}
+	for _, booking := range bookings {
+		if !slices.Contains(ids, booking.RoomID) {
+			ids = append(ids, booking.RoomID)
+		}
+	}
+
+	rooms, err := s.roomRepo.GetAll(ctx, gDto.QueryParams{}, gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    roomModel.FieldID,
+				Operator: gDto.FilterOperatorIn,
+				Value:    ids,
+				Table:    roomModel.TableName,
+			},
+		},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get booked rooms")
+
+		return nil, fmt.Errorf("failed to get booked rooms: %w", err)
+	}
+
+	for _, room := range rooms {
+		names[room.ID] = room.Name
+	}
+
+	return names, nil
+}
+
+// createSeries expands a recurring booking request and stores the series with
+// all occurrences, rejecting the whole series when any occurrence is taken.
+func (s *serviceImpl) createSeries(ctx context.Context, req dto.CreateBookingRequest, user string) error {
+	series, bookings, err := req.ToSeries(user)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to parse recurring booking request")
+
+		return failure.BadRequestFromString(fmt.Sprintf("invalid recurrence: %v", err)) // nolint:wrapcheck
+	}
+
+	if err = s.ensureAllAvailable(ctx, bookings); err != nil {
+		return err
+	}
+
+	events, err := s.events(ctx, model.EventCreated, constant.Empty, bookings...)
+	if err != nil {
+		return err
+	}
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.InsertSeries(ctx, series, bookings) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to create booking series")
+
+		return fmt.Errorf("failed to create booking series: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllBooking)
+		shared.InvalidateCaches(c, s.cache, cacheCountBooking)
+	}()
+
+	return nil
+}
+
+// updateSeries applies the request to every occurrence selected by editScope. The
+// booking date is per occurrence and cannot be moved for several at once.
+func (s *serviceImpl) updateSeries(ctx context.Context, req dto.UpdateBookingRequest, current model.Booking, editScope string) error {
+	if req.BookingDate != constant.Empty {
+		return failure.BadRequestFromString("booking_date can only be changed for a single occurrence") // nolint:wrapcheck
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	filter := seriesFilter(current, editScope)
+
+	occurrences, err := s.repo.GetAll(ctx, gDto.QueryParams{}, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get series bookings")
+
+		return fmt.Errorf("failed to get series bookings: %w", err)
+	}
+
+	// Occurrences keep their dates, so the requested times are the same for
+	// each of them and are written to all in one update.
+	scheduled, err := req.ApplySchedule(current)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to parse booking request")
+
+		return failure.BadRequestFromString(fmt.Sprintf("invalid date/time format: %v", err)) // nolint:wrapcheck
+	}
+
+	updated := make([]model.Booking, len(occurrences))
+
+	for i, occurrence := range occurrences {
+		if updated[i], err = req.Apply(occurrence, user); err != nil {
+			log.Error().Err(err).Msg("failed to parse booking request")
+
+			return failure.BadRequestFromString(fmt.Sprintf("invalid date/time format: %v", err)) // nolint:wrapcheck
+		}
+	}
+
+	if req.HasSchedule() {
+		if err = s.ensureAllAvailable(ctx, updated); err != nil {
+			return err
+		}
+	}
+
+	events, err := s.events(ctx, model.EventUpdated, constant.Empty, updated...)
+	if err != nil {
+		return err
+	}
+
+	updatedFields := shared.TransformFields(req, user)
+	req.ScheduleFields(scheduled, updatedFields)
+
+	err = s.withEvents(ctx, events, func(ctx context.Context) error {
+		return s.repo.Update(ctx, updatedFields, filter) //nolint:wrapcheck
+	})
+	if err != nil {
+		if isOverlapViolation(err) {
+			return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to update series bookings")
+
+		return fmt.Errorf("failed to update series bookings: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetBooking)
+		shared.InvalidateCaches(c, s.cache, cacheGetAllBooking)
+		shared.InvalidateCaches(c, s.cache, cacheCountBooking)
+	}()
+
+	return nil
+}
+
+// eventsEnabled reports whether a topic is configured for booking events.
+func (s *serviceImpl) eventsEnabled() bool {
+	return s.cfg.Kafka.Topics.BookingEvents != constant.Empty
+}
+
+// events builds the outbox messages announcing a change to bookings. They are
+// stored in the transaction of the change and published by the outbox relay.
+func (s *serviceImpl) events(ctx context.Context, eventType, previousStatus string, bookings ...model.Booking) ([]outboxModel.Message, error) {
+	if !s.eventsEnabled() {
+		return nil, nil
+	}
+
+	actor, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	messages, err := dto.ToEventMessages(s.cfg.Kafka.Topics.BookingEvents, eventType, actor, previousStatus, bookings...)
+	if err != nil {
+		log.Error().Err(err).Str("event", eventType).Msg("failed to build booking events")
+
+		return nil, fmt.Errorf("failed to build booking events: %w", err)
+	}
+
+	return messages, nil
+}
+
+// withEvents runs write and adds events to the outbox in one unit of work, so
+// an event is recorded if and only if its change is committed.
+func (s *serviceImpl) withEvents(ctx context.Context, events []outboxModel.Message, write func(ctx context.Context) error) error {
+	return s.tx.Do(ctx, func(ctx context.Context) error { //nolint:wrapcheck
+		if err := write(ctx); err != nil {
+			return err
+		}
+
+		if err := s.outboxRepo.Add(ctx, events...); err != nil {
+			return fmt.Errorf("failed to add booking events to outbox: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// forget drops the cached copy of a booking whose write lost a race, so that
+// clients read the version that won instead of failing to match it again.
+func (s *serviceImpl) forget(ctx context.Context, id string) {
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		if err := s.cache.Delete(c, shared.BuildCacheKey(cacheGetBooking, id)); err != nil {
+			log.Error().Err(err).Msg("failed to delete booking from cache")
+		}
+	}()
+}
+
+// getForScope loads a booking the caller may change and normalises the edit
+// scope. Bookings that are not part of a series are always edited on their own.
+func (s *serviceImpl) getForScope(ctx context.Context, id, editScope string) (model.Booking, string, error) {
+	if editScope == constant.Empty {
+		editScope = model.ScopeThis
+	}
+
+	if !slices.Contains([]string{model.ScopeThis, model.ScopeFollowing, model.ScopeAll}, editScope) {
+		return model.Booking{}, editScope, failure.BadRequestFromString(errInvalidScope) // nolint:wrapcheck
+	}
+
+	current, err := s.repo.Get(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get booking")
+
+		return current, editScope, fmt.Errorf("failed to get booking: %w", err)
+	}
+
+	if current.ID == constant.Empty {
+		log.Error().Msg("booking not found")
+
+		return current, editScope, failure.NotFound("booking not found") // nolint:wrapcheck
+	}
+
+	if !shared.CanAccess(ctx, current.CreatedBy) {
+		return current, editScope, failure.ResourceRestrictedError
+	}
+
+	if current.SeriesID == nil {
+		editScope = model.ScopeThis
+	}
+
+	return current, editScope, nil
+}
+
+// ensureAvailable validates the booking time range and rejects it when it
+// overlaps another active booking of the same room. The exclusion constraint on
+// room_bookings remains the final guard against concurrent writers.
+func (s *serviceImpl) ensureAvailable(ctx context.Context, booking model.Booking, excludeID string) error {
+	if !booking.EndTime.After(booking.StartTime) {
+		return failure.BadRequestFromString("end_time must be after start_time") // nolint:wrapcheck
+	}
+
+	if !slices.Contains(model.ActiveStatuses, booking.Status) {
+		return nil
+	}
+
+	overlaps, err := s.repo.Overlaps(ctx, booking, excludeID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check overlapping bookings")
+
+		return fmt.Errorf("failed to check overlapping bookings: %w", err)
+	}
+
+	if overlaps {
+		return failure.Conflict(errBookingOverlap) // nolint:wrapcheck
+	}
+
+	return nil
+}
+
+// ensureAllAvailable checks every booking and reports all conflicting
+// occurrences in a single error.
+func (s *serviceImpl) ensureAllAvailable(ctx context.Context, bookings []model.Booking) error {
+	conflicts := []string{}
+
+	for _, booking := range bookings {
+		err := s.ensureAvailable(ctx, booking, booking.ID)
+		if err == nil {
+			continue
+		}
+
+		if failure.GetCode(err) != http.StatusConflict {
+			return err
+		}
+
+		conflicts = append(conflicts, fmt.Sprintf("%s %s-%s",
+			booking.BookingDate.Format(model.DateFormat),
+			booking.StartTime.Format(model.TimeFormat),
+			booking.EndTime.Format(model.TimeFormat),
+		))
+	}
+
+	if len(conflicts) > 0 {
+		return failure.Conflict(fmt.Sprintf("%s: %s", errBookingOverlap, strings.Join(conflicts, ", "))) // nolint:wrapcheck
+	}
+
+	return nil
+}
+
+// seriesFilter selects the occurrences of the booking's series covered by editScope.
+func seriesFilter(booking model.Booking, editScope string) gDto.FilterGroup {
+	filter := shared.FilterByID(*booking.SeriesID, model.FieldSeriesID, model.TableName)
+	filter.Operator = gDto.FilterGroupOperatorAnd
+
+	if editScope == model.ScopeFollowing {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			Field:    model.FieldBookingDate,
+			Operator: gDto.FilterOperatorGreaterEq,
+			Value:    booking.BookingDate.Format(model.DateFormat),
+			Table:    model.TableName,
+		})
+	}
+
+	return filter
+}
+
+func isOverlapViolation(err error) bool {
+	return shared.IsPqError(err, constant.PqErrorCodeExclusionViolation)
+}
@@ -0,0 +1,1403 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	postgresMocks "oil/infras/postgres/mocks"
+	bookingMocks "oil/internal/domains/booking/mocks"
+	"oil/internal/domains/booking/model"
+	"oil/internal/domains/booking/model/dto"
+	"oil/internal/domains/booking/service"
+	outboxMocks "oil/internal/domains/outbox/mocks"
+	outboxModel "oil/internal/domains/outbox/model"
+	roomMocks "oil/internal/domains/room/mocks"
+	roomModel "oil/internal/domains/room/model"
+	userMocks "oil/internal/domains/user/mocks"
+	userModel "oil/internal/domains/user/model"
+	"oil/shared"
+	cacheMocks "oil/shared/cache/mocks"
+	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	"oil/shared/event"
+	"oil/shared/failure"
+	gModel "oil/shared/model"
+)
+
+func TestBookingService_Create(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	validReq := dto.CreateBookingRequest{
+		RoomID:      "room-id",
+		GuestName:   "Guest",
+		BookingDate: "2025-01-06",
+		StartTime:   "10:00",
+		EndTime:     "11:00",
+	}
+
+	recurringReq := validReq
+	recurringReq.Recurrence = &dto.RecurrenceRequest{Frequency: model.FrequencyWeekly, Count: 3}
+
+	tests := []struct {
+		name      string
+		req       dto.CreateBookingRequest
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "successful creation",
+			req:  validReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, nil)
+				mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "overlapping booking",
+			req:  validReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(true, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "concurrent overlap rejected by exclusion constraint",
+			req:  validReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, nil)
+				mockRepo.EXPECT().
+					Insert(gomock.Any(), gomock.Any()).
+					Return(&pq.Error{Code: constant.PqErrorCodeExclusionViolation})
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "end time before start time",
+			req: dto.CreateBookingRequest{
+				RoomID:      "room-id",
+				GuestName:   "Guest",
+				BookingDate: "2025-01-06",
+				StartTime:   "11:00",
+				EndTime:     "10:00",
+			},
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "recurring booking",
+			req:  recurringReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(3)
+				mockRepo.EXPECT().InsertSeries(gomock.Any(), gomock.Any(), gomock.Len(3)).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "recurring booking with conflicting occurrences",
+			req:  recurringReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "overlap check error",
+			req:  validReq,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, errors.New("database error"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			err := svc.Create(ctx, tt.req)
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_Update(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	existing := model.Booking{
+		ID:          "booking-id",
+		RoomID:      "room-id",
+		BookingDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		StartTime:   time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		EndTime:     time.Date(0, 1, 1, 11, 0, 0, 0, time.UTC),
+		Status:      model.StatusPending,
+		Metadata:    gModel.Metadata{CreatedBy: "test-user-id"},
+	}
+
+	seriesID := "series-id"
+	occurrence := existing
+	occurrence.SeriesID = &seriesID
+
+	staleVersion := 3
+
+	tests := []struct {
+		name      string
+		req       dto.UpdateBookingRequest
+		scope     string
+		version   *int
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "reschedule without conflict",
+			req:  dto.UpdateBookingRequest{StartTime: "12:00", EndTime: "13:00"},
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), existing.ID).Return(false, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "reschedule into occupied slot",
+			req:  dto.UpdateBookingRequest{BookingDate: "2025-01-07"},
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), existing.ID).Return(true, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "non-schedule change skips overlap check",
+			req:  dto.UpdateBookingRequest{Purpose: "Standup"},
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:  "reschedule following occurrences",
+			req:   dto.UpdateBookingRequest{StartTime: "09:00", EndTime: "09:30"},
+			scope: model.ScopeFollowing,
+			setupMock: func() {
+				next := occurrence
+				next.ID = "next-id"
+				next.BookingDate = occurrence.BookingDate.AddDate(0, 0, 7)
+
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Booking{occurrence, next}, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), occurrence.ID).Return(false, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), next.ID).Return(false, nil)
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC), fields[model.FieldStartTime])
+						assert.Equal(t, time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC), fields[model.FieldEndTime])
+						assert.NotContains(t, fields, model.FieldBookingDate)
+
+						return nil
+					})
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:  "reschedule whole series",
+			req:   dto.UpdateBookingRequest{EndTime: "12:00"},
+			scope: model.ScopeAll,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Booking{occurrence}, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), occurrence.ID).Return(false, nil)
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, _ gDto.FilterGroup) error {
+						assert.Equal(t, time.Date(0, 1, 1, 12, 0, 0, 0, time.UTC), fields[model.FieldEndTime])
+						assert.NotContains(t, fields, model.FieldStartTime)
+
+						return nil
+					})
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:  "reschedule series with a conflicting occurrence",
+			req:   dto.UpdateBookingRequest{StartTime: "09:00"},
+			scope: model.ScopeAll,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Booking{occurrence}, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), occurrence.ID).Return(true, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:  "moving the date of several occurrences",
+			req:   dto.UpdateBookingRequest{BookingDate: "2025-01-07"},
+			scope: model.ScopeAll,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "booking of another user",
+			req:  dto.UpdateBookingRequest{Purpose: "Standup"},
+			setupMock: func() {
+				other := existing
+				other.CreatedBy = "other-user-id"
+
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(other, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:      "invalid scope",
+			req:       dto.UpdateBookingRequest{Purpose: "Standup"},
+			scope:     "invalid",
+			setupMock: func() {},
+			wantCode:  http.StatusBadRequest,
+		},
+		{
+			name: "booking not found",
+			req:  dto.UpdateBookingRequest{StartTime: "12:00"},
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.Booking{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "stale version",
+			req:     dto.UpdateBookingRequest{Purpose: "Standup"},
+			version: &staleVersion,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "modified concurrently",
+			req:     dto.UpdateBookingRequest{Purpose: "Standup"},
+			version: &existing.Version,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ map[string]any, filter gDto.FilterGroup) error {
+						assert.Equal(t, &existing.Version, filter.Version)
+
+						return gDto.ErrVersionMismatch
+					})
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			err := svc.Update(ctx, tt.req, existing.ID, tt.scope, tt.version)
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_ForgetsOnVersionMismatch(t *testing.T) {
+	existing := model.Booking{
+		ID:          "booking-id",
+		RoomID:      "room-id",
+		BookingDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		StartTime:   time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		EndTime:     time.Date(0, 1, 1, 11, 0, 0, 0, time.UTC),
+		Status:      model.StatusPending,
+		Metadata:    gModel.Metadata{CreatedBy: "test-user-id"},
+		Versioned:   gModel.Versioned{Version: 2},
+	}
+
+	tests := []struct {
+		name     string
+		write    func(ctx context.Context, svc service.Booking) error
+		setup    func(mockRepo *bookingMocks.MockBooking)
+		wantCode int
+	}{
+		{
+			name: "update",
+			write: func(ctx context.Context, svc service.Booking) error {
+				return svc.Update(ctx, dto.UpdateBookingRequest{Purpose: "Standup"}, existing.ID, constant.Empty, &existing.Version)
+			},
+			setup: func(mockRepo *bookingMocks.MockBooking) {
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(gDto.ErrVersionMismatch)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "delete",
+			write: func(ctx context.Context, svc service.Booking) error {
+				return svc.Delete(ctx, existing.ID, constant.Empty, &existing.Version)
+			},
+			setup: func(mockRepo *bookingMocks.MockBooking) {
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(gDto.ErrVersionMismatch)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "status change",
+			write: func(ctx context.Context, svc service.Booking) error {
+				return svc.ChangeStatus(ctx, existing.ID, model.StatusConfirmed, dto.ChangeStatusRequest{})
+			},
+			setup: func(mockRepo *bookingMocks.MockBooking) {
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(gDto.ErrVersionMismatch)
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := bookingMocks.NewMockBooking(ctrl)
+			mockCache := cacheMocks.NewMockRedisCache(ctrl)
+
+			svc := service.New(mockRepo, roomMocks.NewMockRoom(ctrl), userMocks.NewMockUser(ctrl), outboxMocks.NewMockOutbox(ctrl), postgresMocks.NewTxManager(), &config.Config{}, mockCache, mocks.NewOtel())
+
+			forgotten := make(chan struct{})
+
+			mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+			tt.setup(mockRepo)
+			mockCache.EXPECT().
+				Delete(gomock.Any(), shared.BuildCacheKey("booking:get", existing.ID)).
+				DoAndReturn(func(_ context.Context, _ string) error {
+					close(forgotten)
+
+					return nil
+				})
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			err := tt.write(ctx, svc)
+			assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+			select {
+			case <-forgotten:
+			case <-time.After(time.Second):
+				t.Fatal("cached booking was not dropped")
+			}
+		})
+	}
+}
+
+func TestBookingService_Delete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	seriesID := "series-id"
+	single := model.Booking{
+		ID:          "booking-id",
+		BookingDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		Metadata:    gModel.Metadata{CreatedBy: "test-user-id"},
+	}
+	occurrence := single
+	occurrence.SeriesID = &seriesID
+
+	staleVersion := 3
+
+	tests := []struct {
+		name      string
+		scope     string
+		version   *int
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "single booking",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(single, nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:  "series scope on a single booking deletes only the booking",
+			scope: model.ScopeAll,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(single, nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "booking of another user",
+			setupMock: func() {
+				other := single
+				other.CreatedBy = "other-user-id"
+
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(other, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:  "whole series",
+			scope: model.ScopeAll,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:  "following occurrences",
+			scope: model.ScopeFollowing,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "booking not found",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.Booking{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:    "stale version of a series occurrence",
+			scope:   model.ScopeAll,
+			version: &staleVersion,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(occurrence, nil)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "modified concurrently",
+			version: &single.Version,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(single, nil)
+				mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(gDto.ErrVersionMismatch)
+			},
+			wantCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			err := svc.Delete(ctx, "booking-id", tt.scope, tt.version)
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	deletedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	deleted := model.Booking{
+		ID:         "booking-id",
+		RoomID:     "room-id",
+		Status:     model.StatusConfirmed,
+		StartTime:  time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		EndTime:    time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		SoftDelete: gModel.SoftDelete{DeletedAt: &deletedAt},
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name: "deleted booking is restored",
+			setupMock: func() {
+				mockRepo.EXPECT().
+					Get(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, filter gDto.FilterGroup, _ ...string) (model.Booking, error) {
+						assert.True(t, filter.WithDeleted)
+
+						return deleted, nil
+					})
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), deleted, "booking-id").Return(false, nil)
+				mockRepo.EXPECT().Restore(gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "booking not found",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.Booking{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "booking is not deleted",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.Booking{ID: "booking-id"}, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "room is deleted",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(deleted, nil)
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(false, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "time slot was taken meanwhile",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(deleted, nil)
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), deleted, "booking-id").Return(true, nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name: "concurrent booking hits the overlap constraint",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(deleted, nil)
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), deleted, "booking-id").Return(false, nil)
+				mockRepo.EXPECT().
+					Restore(gomock.Any(), gomock.Any()).
+					Return(&pq.Error{Code: constant.PqErrorCodeExclusionViolation})
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "admin-id")
+			err := svc.Restore(ctx, "booking-id")
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_ChangeStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	booking := func(status string) model.Booking {
+		return model.Booking{ID: "booking-id", Status: status, Metadata: gModel.Metadata{CreatedBy: "test-user-id"}}
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name:   "approve pending booking",
+			status: model.StatusConfirmed,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking(model.StatusPending), nil)
+				mockRepo.EXPECT().
+					Update(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, fields map[string]any, filter gDto.FilterGroup) error {
+						assert.NotNil(t, filter.Version)
+						assert.Equal(t, model.StatusConfirmed, fields[model.FieldStatus])
+						assert.Equal(t, "No conflicts", fields[model.FieldStatusReason])
+						assert.Equal(t, "test-user-id", fields[model.FieldStatusChangedBy])
+						assert.NotNil(t, fields[model.FieldStatusChangedAt])
+
+						return nil
+					})
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:   "complete confirmed booking",
+			status: model.StatusCompleted,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking(model.StatusConfirmed), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:   "complete pending booking",
+			status: model.StatusCompleted,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking(model.StatusPending), nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "reopen cancelled booking",
+			status: model.StatusConfirmed,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking(model.StatusCancelled), nil)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:   "booking not found",
+			status: model.StatusCancelled,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.Booking{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			// The update matches no row because another transition won, so no
+			// event is recorded for it.
+			name:   "status changed concurrently",
+			status: model.StatusConfirmed,
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking(model.StatusPending), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(gDto.ErrVersionMismatch)
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			err := svc.ChangeStatus(ctx, "booking-id", tt.status, dto.ChangeStatusRequest{Reason: "No conflicts"})
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_GetAll(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	bookings := []model.Booking{{ID: "booking-1"}, {ID: "booking-2"}}
+	cacheMiss := errors.New("cache miss")
+
+	// keepFilter stands in for the allow-list check of accepted query filters.
+	keepFilter := func(filter gDto.FilterGroup, _ gDto.QueryParams) (gDto.FilterGroup, error) {
+		return filter, nil
+	}
+
+	tests := []struct {
+		name      string
+		req       gDto.QueryParams
+		setupMock func()
+		wantTotal *int
+		wantNext  string
+		wantCode  int
+	}{
+		{
+			name: "offset pagination counts the total",
+			req:  gDto.QueryParams{Page: 1, Limit: 10},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss).Times(2)
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).DoAndReturn(keepFilter)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return(bookings, nil)
+				mockRepo.EXPECT().Count(gomock.Any(), gomock.Any()).Return(12, nil)
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			wantTotal: func() *int { total := 12; return &total }(),
+		},
+		{
+			name: "cursor pagination skips the count",
+			req:  gDto.QueryParams{Limit: 2, Pagination: gDto.PaginationCursor},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss)
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).DoAndReturn(keepFilter)
+				mockRepo.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Return(bookings, gDto.Pagination{NextCursor: "next"}, nil)
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			wantNext: "next",
+		},
+		{
+			name: "cursor pagination with total",
+			req:  gDto.QueryParams{Limit: 2, Cursor: "cursor", IncludeTotal: true},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss).Times(2)
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).DoAndReturn(keepFilter)
+				mockRepo.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Return(bookings, gDto.Pagination{NextCursor: "next"}, nil)
+				mockRepo.EXPECT().Count(gomock.Any(), gomock.Any()).Return(12, nil)
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+			wantTotal: func() *int { total := 12; return &total }(),
+			wantNext:  "next",
+		},
+		{
+			name: "invalid cursor",
+			req:  gDto.QueryParams{Limit: 2, Cursor: "garbage"},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss)
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).DoAndReturn(keepFilter)
+				mockRepo.EXPECT().
+					GetPage(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, gDto.Pagination{}, fmt.Errorf("%w: malformed cursor", gDto.ErrInvalidQuery))
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "filter on a hidden field",
+			req:  gDto.QueryParams{Filters: []gDto.QueryFilter{{Field: "password", Operator: gDto.FilterOperatorEq}}},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss)
+				mockRepo.EXPECT().
+					WithFilters(gomock.Any(), gomock.Any()).
+					Return(gDto.FilterGroup{}, fmt.Errorf("%w: cannot filter by password", gDto.ErrInvalidQuery))
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown sort field",
+			req:  gDto.QueryParams{Page: 1, Limit: 10, Sort: []gDto.Sort{{Field: "1; DROP TABLE users", Dir: gDto.SortDirAsc}}},
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cacheMiss)
+				mockRepo.EXPECT().WithFilters(gomock.Any(), gomock.Any()).DoAndReturn(keepFilter)
+				mockRepo.EXPECT().
+					GetAll(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("%w: cannot sort by 1; DROP TABLE users", gDto.ErrInvalidQuery))
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.GetAll(context.Background(), tt.req, gDto.FilterGroup{})
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, res.Bookings, len(bookings))
+			assert.Equal(t, tt.wantTotal, res.TotalData)
+			assert.Equal(t, tt.wantNext, res.NextCursor)
+		})
+	}
+}
+
+func TestBookingService_GetAll_Owner(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	owner := gDto.Filter{
+		ArgName:  "owner",
+		Field:    model.FieldCreatedBy,
+		Operator: gDto.FilterOperatorEq,
+		Value:    "test-user-id",
+		Table:    model.TableName,
+	}
+
+	tests := []struct {
+		name      string
+		role      string
+		wantOwner bool
+	}{
+		{name: "user lists their own bookings", role: constant.RoleUser, wantOwner: true},
+		{name: "admin lists every booking", role: constant.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("cache miss")).Times(2)
+			mockRepo.EXPECT().
+				WithFilters(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(filter gDto.FilterGroup, _ gDto.QueryParams) (gDto.FilterGroup, error) {
+					assert.Equal(t, tt.wantOwner, slices.Contains(filter.Filters, any(owner)))
+
+					return filter, nil
+				})
+			mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Booking{}, nil)
+			mockRepo.EXPECT().Count(gomock.Any(), gomock.Any()).Return(0, nil)
+			mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			ctx = context.WithValue(ctx, constant.ContextKeyUserRole, tt.role)
+
+			_, err := svc.GetAll(ctx, gDto.QueryParams{Page: 1, Limit: 10}, gDto.FilterGroup{})
+			assert.NoError(t, err)
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+		})
+	}
+}
+
+func TestBookingService_Get(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	booking := model.Booking{ID: "booking-id", Metadata: gModel.Metadata{CreatedBy: "owner-id"}}
+
+	tests := []struct {
+		name      string
+		userID    string
+		role      string
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name:   "owner",
+			userID: "owner-id",
+			role:   constant.RoleUser,
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("cache miss"))
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking, nil)
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:   "admin",
+			userID: "admin-id",
+			role:   constant.RoleAdmin,
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("cache miss"))
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking, nil)
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name:   "other user",
+			userID: "other-id",
+			role:   constant.RoleUser,
+			setupMock: func() {
+				mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("cache miss"))
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking, nil)
+			},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:   "other user with cached booking",
+			userID: "other-id",
+			role:   constant.RoleUser,
+			setupMock: func() {
+				mockCache.EXPECT().
+					Get(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, res *dto.BookingResponse) error {
+						res.FromModel(booking)
+
+						return nil
+					})
+			},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, tt.userID)
+			ctx = context.WithValue(ctx, constant.ContextKeyUserRole, tt.role)
+			_, err := svc.Get(ctx, booking.ID)
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+func TestBookingService_GetUserCalendar(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.App.Name = "oil"
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	booking := model.Booking{
+		ID:          "booking-id",
+		RoomID:      "room-id",
+		GuestName:   "Guest",
+		BookingDate: time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC),
+		StartTime:   time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+		EndTime:     time.Date(0, 1, 1, 11, 0, 0, 0, time.UTC),
+		Purpose:     "Planning",
+		Status:      model.StatusConfirmed,
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		setupMock func()
+		wantCode  int
+		contains  []string
+	}{
+		{
+			name:  "valid token",
+			token: "feed-token",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{ID: "user-id", Active: true}, nil)
+				mockRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.Booking{booking}, nil)
+				mockRoomRepo.EXPECT().GetAll(gomock.Any(), gomock.Any(), gomock.Any()).Return([]roomModel.Room{{ID: "room-id", Name: "Room A"}}, nil)
+			},
+			contains: []string{"UID:booking-id@oil", "SUMMARY:Planning", "LOCATION:Room A", "STATUS:CONFIRMED"},
+		},
+		{
+			name:      "missing token",
+			setupMock: func() {},
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			name:  "unknown token",
+			token: "unknown",
+			setupMock: func() {
+				mockUserRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(userModel.User{}, nil)
+			},
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			calendar, err := svc.GetUserCalendar(context.Background(), tt.token)
+
+			if tt.wantCode == 0 {
+				assert.NoError(t, err)
+
+				for _, line := range tt.contains {
+					assert.Contains(t, string(calendar), line+"\r\n")
+				}
+			} else {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+			}
+		})
+	}
+}
+
+type calendarFile struct {
+	*strings.Reader
+}
+
+func (calendarFile) Close() error { return nil }
+
+func TestBookingService_Import(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	// Event times are floating, so they are read in the application timezone.
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT", "UID:free", "DTSTART:20250106T100000", "DTEND:20250106T110000", "SUMMARY:Free", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:duplicate", "DTSTART:20250106T103000", "DTEND:20250106T113000", "SUMMARY:Duplicate", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:taken", "DTSTART:20250107T100000", "DTEND:20250107T110000", "SUMMARY:Taken", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:all-day", "DTSTART;VALUE=DATE:20250108", "SUMMARY:Holiday", "END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		setupMock func()
+		wantCode  int
+	}{
+		{
+			name:   "dry run reports without inserting",
+			dryRun: true,
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(true, nil)
+			},
+		},
+		{
+			name: "import inserts accepted events",
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, nil)
+				mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(true, nil)
+				mockRepo.EXPECT().InsertBulk(gomock.Any(), gomock.Len(1)).Return(nil)
+				mockOutboxRepo.EXPECT().Add(gomock.Any()).Return(nil)
+				mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			},
+		},
+		{
+			name: "unknown room",
+			setupMock: func() {
+				mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(false, nil)
+			},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+			res, err := svc.Import(ctx, dto.ImportBookingsRequest{
+				RoomID:   "room-id",
+				DryRun:   tt.dryRun,
+				Calendar: calendarFile{strings.NewReader(calendar)},
+			})
+
+			// Allow time for goroutines to complete
+			time.Sleep(10 * time.Millisecond)
+
+			if tt.wantCode != 0 {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.dryRun, res.DryRun)
+			assert.Equal(t, 1, res.Created)
+			assert.Equal(t, 2, res.Conflicts)
+			assert.Equal(t, 1, res.Skipped)
+
+			statuses := map[string]string{}
+			for _, item := range res.Items {
+				statuses[item.UID] = item.Status
+			}
+
+			assert.Equal(t, map[string]string{
+				"free":      dto.ImportStatusCreated,
+				"duplicate": dto.ImportStatusConflict,
+				"taken":     dto.ImportStatusConflict,
+				"all-day":   dto.ImportStatusSkipped,
+			}, statuses)
+		})
+	}
+}
+
+func TestBookingService_Events(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOutboxRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockCache := cacheMocks.NewMockRedisCache(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Cache.TTL = 3600
+	cfg.Kafka.Topics.BookingEvents = "booking-events"
+
+	svc := service.New(mockRepo, mockRoomRepo, mockUserRepo, mockOutboxRepo, postgresMocks.NewTxManager(), cfg, mockCache, mockOtel)
+
+	ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "test-user-id")
+
+	// assertEvent decodes the single outbox message and checks its envelope.
+	assertEvent := func(events []outboxModel.Message, eventType, previousStatus, status string) {
+		assert.Len(t, events, 1)
+
+		message := events[0]
+		assert.Equal(t, "booking-events", message.Topic)
+		assert.Equal(t, eventType, message.EventType)
+
+		envelope := event.Envelope[dto.BookingEvent]{}
+		assert.NoError(t, json.Unmarshal(message.Payload, &envelope))
+		assert.NotEmpty(t, envelope.ID)
+		assert.Equal(t, eventType, envelope.Type)
+		assert.Equal(t, model.EventVersion, envelope.Version)
+		assert.Equal(t, "test-user-id", envelope.Actor)
+		assert.Equal(t, message.Key, envelope.Payload.Booking.ID)
+		assert.Equal(t, previousStatus, envelope.Payload.PreviousStatus)
+		assert.Equal(t, status, envelope.Payload.Booking.Status)
+	}
+
+	t.Run("created", func(t *testing.T) {
+		mockRoomRepo.EXPECT().Exist(gomock.Any(), gomock.Any()).Return(true, nil)
+		mockRepo.EXPECT().Overlaps(gomock.Any(), gomock.Any(), constant.Empty).Return(false, nil)
+		mockRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutboxRepo.EXPECT().
+			Add(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, events ...outboxModel.Message) error {
+				assertEvent(events, model.EventCreated, constant.Empty, model.StatusPending)
+
+				return nil
+			})
+		mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		err := svc.Create(ctx, dto.CreateBookingRequest{
+			RoomID:      "room-id",
+			GuestName:   "Guest",
+			BookingDate: "2025-01-06",
+			StartTime:   "10:00",
+			EndTime:     "11:00",
+		})
+
+		time.Sleep(10 * time.Millisecond)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("status changed", func(t *testing.T) {
+		booking := model.Booking{ID: "booking-id", Status: model.StatusPending, Metadata: gModel.Metadata{CreatedBy: "test-user-id"}}
+
+		mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking, nil)
+		mockRepo.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+		mockOutboxRepo.EXPECT().
+			Add(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, events ...outboxModel.Message) error {
+				assertEvent(events, model.EventStatusChanged, model.StatusPending, model.StatusConfirmed)
+
+				return nil
+			})
+		mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		err := svc.ChangeStatus(ctx, "booking-id", model.StatusConfirmed, dto.ChangeStatusRequest{})
+
+		time.Sleep(10 * time.Millisecond)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("deleted", func(t *testing.T) {
+		booking := model.Booking{ID: "booking-id", Status: model.StatusPending, Metadata: gModel.Metadata{CreatedBy: "test-user-id"}}
+
+		mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(booking, nil)
+		mockRepo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+		mockOutboxRepo.EXPECT().
+			Add(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, events ...outboxModel.Message) error {
+				assertEvent(events, model.EventDeleted, constant.Empty, model.StatusPending)
+
+				return nil
+			})
+		mockCache.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		mockCache.EXPECT().Clear(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		err := svc.Delete(ctx, "booking-id", constant.Empty, nil)
+
+		time.Sleep(10 * time.Millisecond)
+
+		assert.NoError(t, err)
+	})
+}
@@ -13,8 +13,8 @@ const (
 )
 
 type Gallery struct {
-	ID          string   `db:"id"`
-	Title       string   `db:"title"`
+	ID          string   `db:"id"          query:"filter"`
+	Title       string   `db:"title"       query:"sort,filter"`
 	Description string   `db:"description"`
 	Images      []string `db:"images"`
 	model.Metadata
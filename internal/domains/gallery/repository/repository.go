@@ -27,9 +27,9 @@ type repositoryImpl struct {
 	otel otel.Otel
 }
 
-func New(db *postgres.Connection, otel otel.Otel) Gallery {
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) Gallery {
 	return &repositoryImpl{
-		Repository: gRepo.NewRepository[model.Gallery](model.EntityName, model.TableName, model.FieldID, db, otel),
+		Repository: gRepo.NewRepository[model.Gallery](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
 		db:         db,
 		otel:       otel,
 	}
@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"oil/internal/domains/outbox/model"
+	"oil/shared/constant"
+	"oil/shared/timezone"
+)
+
+type LagResponse struct {
+	Pending         int    `json:"pending"`
+	Failed          int    `json:"failed"`
+	OldestCreatedAt string `json:"oldest_created_at,omitempty"`
+	LagSeconds      int64  `json:"lag_seconds"`
+}
+
+func (r *LagResponse) FromModel(lag model.Lag) {
+	r.Pending = lag.Pending
+	r.Failed = lag.Failed
+
+	if lag.OldestCreatedAt != nil {
+		r.OldestCreatedAt = timezone.Format(*lag.OldestCreatedAt, constant.DateFormat)
+		r.LagSeconds = int64(timezone.Now().Sub(*lag.OldestCreatedAt).Seconds())
+	}
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"oil/shared/timezone"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+const (
+	TableName  = "outbox"
+	EntityName = "outbox"
+
+	FieldID          = "id"
+	FieldTopic       = "topic"
+	FieldKey         = "message_key"
+	FieldAttempts    = "attempts"
+	FieldLastError   = "last_error"
+	FieldAvailableAt = "available_at"
+	FieldDeliveredAt = "delivered_at"
+)
+
+// Message is an event waiting in the outbox to be published to Kafka. It is
+// written in the same transaction as the change it describes.
+type Message struct {
+	ID          string         `db:"id"`
+	Topic       string         `db:"topic"`
+	Key         string         `db:"message_key"`
+	EventType   string         `db:"event_type"`
+	Payload     types.JSONText `db:"payload"`
+	Attempts    int            `db:"attempts"`
+	LastError   *string        `db:"last_error"`
+	AvailableAt time.Time      `db:"available_at"`
+	DeliveredAt *time.Time     `db:"delivered_at"`
+	CreatedAt   time.Time      `db:"created_at"`
+}
+
+// Unaudited keeps the outbox out of the audit log; messages describe changes
+// that are audited already.
+func (Message) Unaudited() {}
+
+// NewMessage encodes value as the JSON payload of a message for topic.
+func NewMessage(topic, key, eventType string, value any) (Message, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return Message{}, fmt.Errorf("failed to encode outbox payload: %w", err)
+	}
+
+	now := timezone.Now()
+
+	return Message{
+		ID:          uuid.NewString(),
+		Topic:       topic,
+		Key:         key,
+		EventType:   eventType,
+		Payload:     payload,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}, nil
+}
+
+// Lag summarises the messages that have not been delivered yet. Failed
+// messages exhausted their attempts and are no longer retried.
+type Lag struct {
+	Pending         int        `db:"pending"`
+	Failed          int        `db:"failed"`
+	OldestCreatedAt *time.Time `db:"oldest_created_at"`
+}
@@ -0,0 +1,190 @@
+package repository
+
+//go:generate go run go.uber.org/mock/mockgen -source=./repository.go -destination=../mocks/repository_mock.go -package=mocks
+
+import (
+	"context"
+	"fmt"
+	"oil/infras/otel"
+	"oil/infras/postgres"
+	"oil/internal/domains/outbox/model"
+	"oil/shared"
+	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	gRepo "oil/shared/repository"
+	"oil/shared/timezone"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Outbox interface {
+	Add(ctx context.Context, messages ...model.Message) error
+	Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]model.Message, error)
+	MarkDelivered(ctx context.Context, id string) error
+	MarkFailed(ctx context.Context, message model.Message, cause string, retryAt time.Time) error
+	DeleteDelivered(ctx context.Context, before time.Time) error
+	Lag(ctx context.Context, maxAttempts int) (model.Lag, error)
+}
+
+type repositoryImpl struct {
+	gRepo.Repository[model.Message]
+	db   *postgres.Connection
+	otel otel.Otel
+}
+
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) Outbox {
+	return &repositoryImpl{
+		Repository: gRepo.NewRepository[model.Message](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
+		db:         db,
+		otel:       otel,
+	}
+}
+
+// Add stores messages to be relayed. Called within a unit of work, they are
+// committed together with the change they announce.
+func (r *repositoryImpl) Add(ctx context.Context, messages ...model.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.Add")
+	defer scope.End()
+
+	if err := r.InsertBulk(ctx, messages); err != nil {
+		return fmt.Errorf("failed to insert outbox messages: %w", err)
+	}
+
+	return nil
+}
+
+// Claim locks up to limit pending messages by pushing their availability past
+// lease, so that concurrent relays skip them while they are being published.
+// A relay that dies mid-batch releases its messages when the lease expires.
+//
+// Messages of a key are published in order: a message is not claimed while an
+// earlier one of its key waits for a retry or is claimed by another relay.
+// Once that one used up maxAttempts it no longer holds the others back.
+func (r *repositoryImpl) Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]model.Message, error) {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.Claim")
+	defer scope.End()
+
+	now := timezone.Now()
+
+	query := fmt.Sprintf(`UPDATE %[1]s SET %[2]s = $1
+		WHERE %[3]s IN (
+			SELECT %[3]s FROM %[1]s pending
+			WHERE %[4]s IS NULL AND %[5]s < $2 AND %[2]s <= $3
+			AND NOT EXISTS (
+				SELECT 1 FROM %[1]s earlier
+				WHERE earlier.%[6]s = pending.%[6]s AND earlier.%[7]s = pending.%[7]s
+				AND earlier.%[4]s IS NULL AND earlier.%[5]s < $2 AND earlier.%[2]s > $3
+				AND earlier.created_at < pending.created_at
+			)
+			ORDER BY created_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING %[8]s`,
+		model.TableName, model.FieldAvailableAt, model.FieldID, model.FieldDeliveredAt, model.FieldAttempts,
+		model.FieldTopic, model.FieldKey, strings.Join(r.InsertColumns, ", "),
+	)
+
+	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	messages := []model.Message{}
+	if err := sqlx.SelectContext(ctx, r.db.Writer(ctx), &messages, query, now.Add(lease), maxAttempts, now, limit); err != nil {
+		scope.TraceError(err)
+
+		return nil, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(messages, func(a, b model.Message) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	return messages, nil
+}
+
+func (r *repositoryImpl) MarkDelivered(ctx context.Context, id string) error {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.MarkDelivered")
+	defer scope.End()
+
+	fields := map[string]any{model.FieldDeliveredAt: timezone.Now()}
+
+	if err := r.Update(ctx, fields, shared.FilterByID(id, model.FieldID, model.TableName)); err != nil {
+		return fmt.Errorf("failed to mark outbox message delivered: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed attempt and schedules the next one at retryAt.
+func (r *repositoryImpl) MarkFailed(ctx context.Context, message model.Message, cause string, retryAt time.Time) error {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.MarkFailed")
+	defer scope.End()
+
+	fields := map[string]any{
+		model.FieldAttempts:    message.Attempts + 1,
+		model.FieldLastError:   cause,
+		model.FieldAvailableAt: retryAt,
+	}
+
+	if err := r.Update(ctx, fields, shared.FilterByID(message.ID, model.FieldID, model.TableName)); err != nil {
+		return fmt.Errorf("failed to mark outbox message failed: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDelivered removes messages delivered before the given time.
+func (r *repositoryImpl) DeleteDelivered(ctx context.Context, before time.Time) error {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.DeleteDelivered")
+	defer scope.End()
+
+	filter := gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldDeliveredAt,
+				Operator: gDto.FilterOperatorLess,
+				Value:    before,
+				Table:    model.TableName,
+			},
+		},
+	}
+
+	if err := r.Delete(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete delivered outbox messages: %w", err)
+	}
+
+	return nil
+}
+
+// Lag counts undelivered messages. Messages that used up maxAttempts are
+// reported as failed instead of pending.
+func (r *repositoryImpl) Lag(ctx context.Context, maxAttempts int) (model.Lag, error) {
+	ctx, scope := r.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".outbox.Lag")
+	defer scope.End()
+
+	query := fmt.Sprintf(`SELECT
+			COUNT(*) FILTER (WHERE %[2]s < $1) AS pending,
+			COUNT(*) FILTER (WHERE %[2]s >= $1) AS failed,
+			MIN(created_at) FILTER (WHERE %[2]s < $1) AS oldest_created_at
+		FROM %[1]s WHERE %[3]s IS NULL`,
+		model.TableName, model.FieldAttempts, model.FieldDeliveredAt,
+	)
+
+	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	lag := model.Lag{}
+	if err := sqlx.GetContext(ctx, r.db.Reader(ctx), &lag, query, maxAttempts); err != nil {
+		scope.TraceError(err)
+
+		return lag, fmt.Errorf("failed to get outbox lag: %w", err)
+	}
+
+	return lag, nil
+}
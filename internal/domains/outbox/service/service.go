@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"oil/config"
+	"oil/infras/kafka"
+	"oil/infras/otel"
+	"oil/internal/domains/outbox/model/dto"
+	"oil/internal/domains/outbox/repository"
+	"oil/shared/constant"
+	"oil/shared/timezone"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 10
+	defaultRetryBackoff = 5 * time.Second
+	defaultRetention    = 7 * 24 * time.Hour
+
+	maxRetryBackoff = 15 * time.Minute
+	claimLease      = time.Minute
+	purgeInterval   = time.Hour
+)
+
+// Relay publishes the messages stored in the outbox to Kafka. Delivery is at
+// least once: consumers deduplicate by the event ID in the envelope.
+type Relay interface {
+	Run(ctx context.Context)
+	RelayBatch(ctx context.Context) (int, error)
+	Lag(ctx context.Context) (dto.LagResponse, error)
+}
+
+type relayImpl struct {
+	repo  repository.Outbox
+	kafka kafka.Client
+	cfg   *config.Config
+	otel  otel.Otel
+}
+
+func New(repo repository.Outbox, kafka kafka.Client, cfg *config.Config, otel otel.Otel) Relay {
+	return &relayImpl{
+		repo:  repo,
+		kafka: kafka,
+		cfg:   cfg,
+		otel:  otel,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled. A full batch is followed by the
+// next one immediately so that a backlog drains without waiting for the ticker.
+func (s *relayImpl) Run(ctx context.Context) {
+	if !s.cfg.Kafka.Outbox.Enable {
+		log.Info().Msg("Outbox relay disabled")
+
+		return
+	}
+
+	poll := time.NewTicker(s.pollInterval())
+	defer poll.Stop()
+
+	purge := time.NewTicker(purgeInterval)
+	defer purge.Stop()
+
+	log.Info().Dur("interval", s.pollInterval()).Msg("Outbox relay started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Outbox relay stopped")
+
+			return
+		case <-purge.C:
+			if err := s.repo.DeleteDelivered(ctx, timezone.Now().Add(-s.retention())); err != nil {
+				log.Error().Err(err).Msg("failed to purge delivered outbox messages")
+			}
+		case <-poll.C:
+			for ctx.Err() == nil {
+				delivered, err := s.RelayBatch(ctx)
+				if err != nil || delivered < s.batchSize() {
+					break
+				}
+			}
+		}
+	}
+}
+
+// RelayBatch publishes one batch of pending messages and returns how many were
+// delivered. A failed message is retried later with exponential backoff, and
+// the messages after it with the same key wait for it to keep their order.
+func (s *relayImpl) RelayBatch(ctx context.Context) (delivered int, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".RelayBatch")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	messages, err := s.repo.Claim(ctx, s.batchSize(), s.maxAttempts(), claimLease)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to claim outbox messages")
+
+		return 0, fmt.Errorf("failed to claim outbox messages: %w", err)
+	}
+
+	// The keys of failed messages. Their later messages stay claimed until the
+	// lease expires and are not claimed again before the failed one is delivered.
+	failedKeys := map[[2]string]bool{}
+
+	for _, message := range messages {
+		key := [2]string{message.Topic, message.Key}
+		if failedKeys[key] {
+			continue
+		}
+
+		sendErr := s.kafka.SendMessages(ctx, message.Topic, kafka.Message{Key: message.Key, Value: message.Payload})
+		if sendErr != nil {
+			log.Error().Err(sendErr).Str("id", message.ID).Int("attempts", message.Attempts+1).Msg("failed to publish outbox message")
+
+			failedKeys[key] = true
+
+			retryAt := timezone.Now().Add(s.backoff(message.Attempts + 1))
+			if err = s.repo.MarkFailed(ctx, message, sendErr.Error(), retryAt); err != nil {
+				log.Error().Err(err).Msg("failed to mark outbox message failed")
+
+				return delivered, fmt.Errorf("failed to mark outbox message failed: %w", err)
+			}
+
+			continue
+		}
+
+		if err = s.repo.MarkDelivered(ctx, message.ID); err != nil {
+			log.Error().Err(err).Msg("failed to mark outbox message delivered")
+
+			return delivered, fmt.Errorf("failed to mark outbox message delivered: %w", err)
+		}
+
+		delivered++
+	}
+
+	return delivered, nil
+}
+
+func (s *relayImpl) Lag(ctx context.Context) (res dto.LagResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Lag")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	lag, err := s.repo.Lag(ctx, s.maxAttempts())
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get outbox lag")
+
+		return res, fmt.Errorf("failed to get outbox lag: %w", err)
+	}
+
+	res.FromModel(lag)
+
+	return res, nil
+}
+
+// backoff doubles the configured delay for every failed attempt.
+func (s *relayImpl) backoff(attempts int) time.Duration {
+	delay := defaultRetryBackoff
+	if seconds := s.cfg.Kafka.Outbox.RetryBackoffSeconds; seconds > 0 {
+		delay = time.Duration(seconds) * time.Second
+	}
+
+	for range attempts - 1 {
+		delay *= 2
+		if delay >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+
+	return delay
+}
+
+func (s *relayImpl) pollInterval() time.Duration {
+	if ms := s.cfg.Kafka.Outbox.PollIntervalMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	return defaultPollInterval
+}
+
+func (s *relayImpl) batchSize() int {
+	if s.cfg.Kafka.Outbox.BatchSize > 0 {
+		return s.cfg.Kafka.Outbox.BatchSize
+	}
+
+	return defaultBatchSize
+}
+
+func (s *relayImpl) maxAttempts() int {
+	if s.cfg.Kafka.Outbox.MaxAttempts > 0 {
+		return s.cfg.Kafka.Outbox.MaxAttempts
+	}
+
+	return defaultMaxAttempts
+}
+
+func (s *relayImpl) retention() time.Duration {
+	if hours := s.cfg.Kafka.Outbox.RetentionHours; hours > 0 {
+		return time.Duration(hours) * time.Hour
+	}
+
+	return defaultRetention
+}
@@ -0,0 +1,154 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/kafka"
+	kafkaMocks "oil/infras/kafka/mocks"
+	"oil/infras/otel/mocks"
+	outboxMocks "oil/internal/domains/outbox/mocks"
+	"oil/internal/domains/outbox/model"
+	"oil/internal/domains/outbox/service"
+)
+
+func TestRelay_RelayBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockKafka := kafkaMocks.NewMockClient(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.Kafka.Outbox.BatchSize = 10
+	cfg.Kafka.Outbox.MaxAttempts = 3
+	cfg.Kafka.Outbox.RetryBackoffSeconds = 10
+
+	relay := service.New(mockRepo, mockKafka, cfg, mockOtel)
+
+	first := model.Message{ID: "first", Topic: "booking-events", Key: "booking-1", Payload: types.JSONText(`{"id":"event-1"}`)}
+	second := model.Message{ID: "second", Topic: "booking-events", Key: "booking-2", Payload: types.JSONText(`{"id":"event-2"}`), Attempts: 2}
+
+	tests := []struct {
+		name          string
+		setupMock     func()
+		wantDelivered int
+		wantErr       bool
+	}{
+		{
+			name: "all messages delivered",
+			setupMock: func() {
+				mockRepo.EXPECT().Claim(gomock.Any(), 10, 3, gomock.Any()).Return([]model.Message{first, second}, nil)
+				mockKafka.EXPECT().
+					SendMessages(gomock.Any(), "booking-events", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, messages ...kafka.Message) error {
+						assert.Len(t, messages, 1)
+						assert.Equal(t, "booking-1", messages[0].Key)
+						assert.Equal(t, first.Payload, messages[0].Value)
+
+						return nil
+					})
+				mockKafka.EXPECT().SendMessages(gomock.Any(), "booking-events", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().MarkDelivered(gomock.Any(), "first").Return(nil)
+				mockRepo.EXPECT().MarkDelivered(gomock.Any(), "second").Return(nil)
+			},
+			wantDelivered: 2,
+		},
+		{
+			name: "failed message is rescheduled with backoff",
+			setupMock: func() {
+				mockRepo.EXPECT().Claim(gomock.Any(), 10, 3, gomock.Any()).Return([]model.Message{first, second}, nil)
+				mockKafka.EXPECT().SendMessages(gomock.Any(), "booking-events", gomock.Any()).Return(nil)
+				mockKafka.EXPECT().SendMessages(gomock.Any(), "booking-events", gomock.Any()).Return(errors.New("broker unavailable"))
+				mockRepo.EXPECT().MarkDelivered(gomock.Any(), "first").Return(nil)
+				mockRepo.EXPECT().
+					MarkFailed(gomock.Any(), second, "broker unavailable", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ model.Message, _ string, retryAt time.Time) error {
+						// Third attempt: 10s doubled twice.
+						assert.WithinDuration(t, time.Now().Add(40*time.Second), retryAt, 5*time.Second)
+
+						return nil
+					})
+			},
+			wantDelivered: 1,
+		},
+		{
+			name: "messages after a failed one with the same key wait for it",
+			setupMock: func() {
+				next := first
+				next.ID = "next"
+
+				mockRepo.EXPECT().Claim(gomock.Any(), 10, 3, gomock.Any()).Return([]model.Message{first, second, next}, nil)
+				mockKafka.EXPECT().
+					SendMessages(gomock.Any(), "booking-events", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, messages ...kafka.Message) error {
+						assert.Equal(t, first.Payload, messages[0].Value)
+
+						return errors.New("broker unavailable")
+					})
+				mockRepo.EXPECT().MarkFailed(gomock.Any(), first, "broker unavailable", gomock.Any()).Return(nil)
+				mockKafka.EXPECT().SendMessages(gomock.Any(), "booking-events", gomock.Any()).Return(nil)
+				mockRepo.EXPECT().MarkDelivered(gomock.Any(), "second").Return(nil)
+			},
+			wantDelivered: 1,
+		},
+		{
+			name: "nothing pending",
+			setupMock: func() {
+				mockRepo.EXPECT().Claim(gomock.Any(), 10, 3, gomock.Any()).Return([]model.Message{}, nil)
+			},
+		},
+		{
+			name: "claim error",
+			setupMock: func() {
+				mockRepo.EXPECT().Claim(gomock.Any(), 10, 3, gomock.Any()).Return(nil, errors.New("database error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			delivered, err := relay.RelayBatch(context.Background())
+
+			assert.Equal(t, tt.wantDelivered, delivered)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRelay_Lag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := outboxMocks.NewMockOutbox(ctrl)
+	mockKafka := kafkaMocks.NewMockClient(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	relay := service.New(mockRepo, mockKafka, &config.Config{}, mockOtel)
+
+	oldest := time.Now().Add(-time.Minute)
+	mockRepo.EXPECT().Lag(gomock.Any(), 10).Return(model.Lag{Pending: 4, Failed: 1, OldestCreatedAt: &oldest}, nil)
+
+	lag, err := relay.Lag(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, lag.Pending)
+	assert.Equal(t, 1, lag.Failed)
+	assert.InDelta(t, 60, lag.LagSeconds, 5)
+}
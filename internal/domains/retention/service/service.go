@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oil/config"
+	"oil/infras/otel"
+	bookingRepo "oil/internal/domains/booking/repository"
+	roomRepo "oil/internal/domains/room/repository"
+	userRepo "oil/internal/domains/user/repository"
+	"oil/shared/constant"
+	"oil/shared/timezone"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultSoftDeleteRetention = 30 * 24 * time.Hour
+
+	purgeInterval = time.Hour
+)
+
+// Purger permanently removes soft-deleted rows once they are past the
+// retention period. Until then they can be restored.
+type Purger interface {
+	Run(ctx context.Context)
+	Purge(ctx context.Context) error
+}
+
+type purgerImpl struct {
+	bookingRepo bookingRepo.Booking
+	roomRepo    roomRepo.Room
+	userRepo    userRepo.User
+	cfg         *config.Config
+	otel        otel.Otel
+}
+
+func New(bookingRepo bookingRepo.Booking, roomRepo roomRepo.Room, userRepo userRepo.User, cfg *config.Config, otel otel.Otel) Purger {
+	return &purgerImpl{
+		bookingRepo: bookingRepo,
+		roomRepo:    roomRepo,
+		userRepo:    userRepo,
+		cfg:         cfg,
+		otel:        otel,
+	}
+}
+
+// Run purges once at start and then every purgeInterval until ctx is cancelled.
+func (s *purgerImpl) Run(ctx context.Context) {
+	if !s.cfg.App.Retention.Enable {
+		log.Info().Msg("Soft delete purge disabled")
+
+		return
+	}
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	log.Info().Dur("retention", s.retention()).Msg("Soft delete purge started")
+
+	for {
+		if err := s.Purge(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to purge soft-deleted data")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("Soft delete purge stopped")
+
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes the bookings, rooms and users deleted before the retention
+// period. A purged room takes its remaining bookings along through the
+// cascading foreign key.
+func (s *purgerImpl) Purge(ctx context.Context) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Purge")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	before := timezone.Now().Add(-s.retention())
+
+	purges := []struct {
+		entity string
+		purge  func(ctx context.Context, before time.Time) (int64, error)
+	}{
+		{entity: "bookings", purge: s.bookingRepo.Purge},
+		{entity: "rooms", purge: s.roomRepo.Purge},
+		{entity: "users", purge: s.userRepo.Purge},
+	}
+
+	errs := []error{}
+
+	for _, p := range purges {
+		purged, purgeErr := p.purge(ctx, before)
+		if purgeErr != nil {
+			errs = append(errs, fmt.Errorf("failed to purge %s: %w", p.entity, purgeErr))
+
+			continue
+		}
+
+		if purged > 0 {
+			log.Info().Int64("purged", purged).Str("entity", p.entity).Msg("Purged soft-deleted data")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *purgerImpl) retention() time.Duration {
+	if days := s.cfg.App.Retention.SoftDeleteDays; days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+
+	return defaultSoftDeleteRetention
+}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	bookingMocks "oil/internal/domains/booking/mocks"
+	"oil/internal/domains/retention/service"
+	roomMocks "oil/internal/domains/room/mocks"
+	userMocks "oil/internal/domains/user/mocks"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBookingRepo := bookingMocks.NewMockBooking(ctrl)
+	mockRoomRepo := roomMocks.NewMockRoom(ctrl)
+	mockUserRepo := userMocks.NewMockUser(ctrl)
+	mockOtel := mocks.NewOtel()
+
+	cfg := &config.Config{}
+	cfg.App.Retention.SoftDeleteDays = 7
+
+	purger := service.New(mockBookingRepo, mockRoomRepo, mockUserRepo, cfg, mockOtel)
+
+	beforeRetention := func(_ context.Context, before time.Time) (int64, error) {
+		assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), before, 5*time.Second)
+
+		return 1, nil
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantErr   bool
+	}{
+		{
+			name: "all entities purged",
+			setupMock: func() {
+				gomock.InOrder(
+					mockBookingRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).DoAndReturn(beforeRetention),
+					mockRoomRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).DoAndReturn(beforeRetention),
+					mockUserRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).DoAndReturn(beforeRetention),
+				)
+			},
+		},
+		{
+			name: "a failing entity does not stop the others",
+			setupMock: func() {
+				mockBookingRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("database error"))
+				mockRoomRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).Return(int64(0), nil)
+				mockUserRepo.EXPECT().Purge(gomock.Any(), gomock.Any()).Return(int64(2), nil)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := purger.Purge(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
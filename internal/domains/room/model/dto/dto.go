@@ -1,8 +1,13 @@
 package dto
 
 import (
+	"cmp"
+	"fmt"
 	"mime/multipart"
+	"slices"
+	"time"
 
+	bookingModel "oil/internal/domains/booking/model"
 	"oil/internal/domains/room/model"
 	"oil/shared"
 	gDto "oil/shared/dto"
@@ -59,6 +64,7 @@ type RoomResponse struct {
 	Capacity int    `json:"capacity"`
 	Image    string `json:"image"`
 	Active   bool   `json:"active"`
+	Version  int    `json:"version"`
 	gDto.Metadata
 }
 
@@ -69,21 +75,119 @@ func (r *RoomResponse) FromModel(model model.Room) {
 	r.Capacity = model.Capacity
 	r.Image = model.Image
 	r.Active = model.Active
+	r.Version = model.Version
 	r.Metadata.FromModel(model.Metadata)
 }
 
 type GetRoomsResponse struct {
-	Rooms     []RoomResponse `json:"rooms"`
-	TotalPage int            `json:"total_page"`
-	TotalData int            `json:"total_data"`
+	Rooms []RoomResponse `json:"rooms"`
+	gDto.Pagination
 }
 
 func (r *GetRoomsResponse) FromModels(models []model.Room, totalData, limit int) {
-	r.TotalData = totalData
-	r.TotalPage = shared.CalculateTotalPage(totalData, limit)
+	page := gDto.Pagination{}
+	page.SetTotal(totalData, shared.CalculateTotalPage(totalData, limit))
+
+	r.FromPage(models, page)
+}
+
+func (r *GetRoomsResponse) FromPage(models []model.Room, page gDto.Pagination) {
+	r.Pagination = page
 
 	r.Rooms = make([]RoomResponse, len(models))
 	for i, mod := range models {
 		r.Rooms[i].FromModel(mod)
 	}
 }
+
+type AvailabilityRequest struct {
+	StartDate   string `json:"start_date"   validate:"required,datetime=2006-01-02"`
+	EndDate     string `json:"end_date"     validate:"omitempty,datetime=2006-01-02"`
+	MinDuration int    `json:"min_duration" validate:"omitempty,min=0"`
+}
+
+type TimeSlot struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type DayAvailability struct {
+	Date  string     `json:"date"`
+	Slots []TimeSlot `json:"slots"`
+}
+
+// FromBookings fills the day with the free intervals between open and close that are
+// not covered by bookings and last at least minDuration.
+func (d *DayAvailability) FromBookings(date time.Time, open, closing time.Duration, bookings []bookingModel.Booking, minDuration time.Duration) {
+	d.Date = date.Format(bookingModel.DateFormat)
+	d.Slots = []TimeSlot{}
+
+	busy := make([][2]time.Duration, 0, len(bookings))
+	for _, booking := range bookings {
+		busy = append(busy, [2]time.Duration{clock(booking.StartTime), clock(booking.EndTime)})
+	}
+
+	slices.SortFunc(busy, func(a, b [2]time.Duration) int {
+		return cmp.Compare(a[0], b[0])
+	})
+
+	cursor := open
+
+	for _, interval := range busy {
+		if interval[0] > cursor {
+			d.addSlot(cursor, min(interval[0], closing), minDuration)
+		}
+
+		cursor = max(cursor, interval[1])
+		if cursor >= closing {
+			return
+		}
+	}
+
+	d.addSlot(cursor, closing, minDuration)
+}
+
+func (d *DayAvailability) addSlot(start, end, minDuration time.Duration) {
+	if end <= start || end-start < minDuration {
+		return
+	}
+
+	d.Slots = append(d.Slots, TimeSlot{Start: formatClock(start), End: formatClock(end)})
+}
+
+type AvailabilityResponse struct {
+	RoomID string            `json:"room_id"`
+	Open   string            `json:"open"`
+	Close  string            `json:"close"`
+	Days   []DayAvailability `json:"days"`
+}
+
+// ParseClock parses an HH:MM string into the duration since midnight.
+func ParseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+
+	parsed, err := time.Parse(bookingModel.TimeFormat, value)
+	if err != nil {
+		return 0, err
+	}
+
+	return clock(parsed), nil
+}
+
+func clock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+type AvailableRoomsRequest struct {
+	Date        string `json:"date"         validate:"required,datetime=2006-01-02"`
+	Start       string `json:"start"        validate:"required,datetime=15:04"`
+	End         string `json:"end"          validate:"required,datetime=15:04"`
+	MinCapacity int    `json:"min_capacity" validate:"omitempty,min=0"`
+	Location    string `json:"location"     validate:"omitempty,max=100"`
+}
@@ -0,0 +1,87 @@
+package dto_test
+
+import (
+	"testing"
+	"time"
+
+	bookingModel "oil/internal/domains/booking/model"
+	"oil/internal/domains/room/model/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clockTime(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestDayAvailability_FromBookings(t *testing.T) {
+	date := time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+	open := 8 * time.Hour
+	closing := 18 * time.Hour
+
+	tests := []struct {
+		name        string
+		bookings    []bookingModel.Booking
+		minDuration time.Duration
+		expected    []dto.TimeSlot
+	}{
+		{
+			name:     "no bookings leaves the whole day free",
+			bookings: nil,
+			expected: []dto.TimeSlot{{Start: "08:00", End: "18:00"}},
+		},
+		{
+			name: "unsorted and overlapping bookings are merged",
+			bookings: []bookingModel.Booking{
+				{StartTime: clockTime(13, 0), EndTime: clockTime(14, 0)},
+				{StartTime: clockTime(9, 0), EndTime: clockTime(10, 30)},
+				{StartTime: clockTime(10, 0), EndTime: clockTime(11, 0)},
+			},
+			expected: []dto.TimeSlot{
+				{Start: "08:00", End: "09:00"},
+				{Start: "11:00", End: "13:00"},
+				{Start: "14:00", End: "18:00"},
+			},
+		},
+		{
+			name: "bookings outside opening hours are clipped",
+			bookings: []bookingModel.Booking{
+				{StartTime: clockTime(7, 0), EndTime: clockTime(9, 0)},
+				{StartTime: clockTime(17, 0), EndTime: clockTime(19, 0)},
+			},
+			expected: []dto.TimeSlot{{Start: "09:00", End: "17:00"}},
+		},
+		{
+			name: "slots shorter than the minimum duration are dropped",
+			bookings: []bookingModel.Booking{
+				{StartTime: clockTime(8, 30), EndTime: clockTime(12, 0)},
+				{StartTime: clockTime(12, 45), EndTime: clockTime(18, 0)},
+			},
+			minDuration: time.Hour,
+			expected:    []dto.TimeSlot{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var day dto.DayAvailability
+			day.FromBookings(date, open, closing, tt.bookings, tt.minDuration)
+
+			assert.Equal(t, "2025-01-06", day.Date)
+			assert.Equal(t, tt.expected, day.Slots)
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	value, err := dto.ParseClock("09:30")
+	assert.NoError(t, err)
+	assert.Equal(t, 9*time.Hour+30*time.Minute, value)
+
+	value, err = dto.ParseClock("24:00")
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, value)
+
+	_, err = dto.ParseClock("invalid")
+	assert.Error(t, err)
+}
@@ -15,11 +15,13 @@ const (
 )
 
 type Room struct {
-	ID       string `db:"id"`
-	Name     string `db:"name"`
-	Location string `db:"location"`
-	Capacity int    `db:"capacity"`
+	ID       string `db:"id"       query:"filter"`
+	Name     string `db:"name"     query:"sort,filter"`
+	Location string `db:"location" query:"sort,filter"`
+	Capacity int    `db:"capacity" query:"sort,filter"`
 	Image    string `db:"image"`
-	Active   bool   `db:"active"`
+	Active   bool   `db:"active"   query:"filter"`
 	model.Metadata
+	model.SoftDelete
+	model.Versioned
 }
@@ -9,16 +9,21 @@ import (
 	"oil/internal/domains/room/model"
 	gDto "oil/shared/dto"
 	gRepo "oil/shared/repository"
+	"time"
 )
 
 type Room interface {
 	Insert(ctx context.Context, model model.Room) error
 	Get(ctx context.Context, filter gDto.FilterGroup, columns ...string) (model.Room, error)
 	GetAll(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.Room, error)
+	GetPage(ctx context.Context, params gDto.QueryParams, filter gDto.FilterGroup, columns ...string) ([]model.Room, gDto.Pagination, error)
+	WithFilters(filter gDto.FilterGroup, params gDto.QueryParams) (gDto.FilterGroup, error)
 	Exist(ctx context.Context, filter gDto.FilterGroup) (bool, error)
 	Count(ctx context.Context, filter gDto.FilterGroup) (int, error)
 	Update(ctx context.Context, req map[string]any, filter gDto.FilterGroup) error
 	Delete(ctx context.Context, filter gDto.FilterGroup) error
+	Restore(ctx context.Context, filter gDto.FilterGroup) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
 type repositoryImpl struct {
@@ -27,9 +32,9 @@ type repositoryImpl struct {
 	otel otel.Otel
 }
 
-func New(db *postgres.Connection, otel otel.Otel) Room {
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) Room {
 	return &repositoryImpl{
-		Repository: gRepo.NewRepository[model.Room](model.EntityName, model.TableName, model.FieldID, db, otel),
+		Repository: gRepo.NewRepository[model.Room](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
 		db:         db,
 		otel:       otel,
 	}
@@ -2,12 +2,19 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"oil/config"
 	"oil/infras/otel"
+	"oil/infras/postgres"
 	"oil/infras/s3"
+	bookingModel "oil/internal/domains/booking/model"
+	bookingDto "oil/internal/domains/booking/model/dto"
+	bookingRepo "oil/internal/domains/booking/repository"
+	outboxRepo "oil/internal/domains/outbox/repository"
 	"oil/internal/domains/room/model"
 	"oil/internal/domains/room/model/dto"
 	"oil/internal/domains/room/repository"
@@ -16,6 +23,7 @@ import (
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	"oil/shared/failure"
+	"oil/shared/timezone"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
@@ -25,32 +33,60 @@ const (
 	cacheGetRoom    = "room:get"
 	cacheGetAllRoom = "room:gets"
 	cacheCountRoom  = "room:count"
+
+	defaultOpeningTime  = "08:00"
+	defaultClosingTime  = "18:00"
+	maxAvailabilityDays = 31
+
+	errRoomModified = "room has been modified"
+	errRoomOverlap  = "restored bookings of the room overlap other bookings"
 )
 
+var errInvalidOpeningHours = errors.New("closing time must be after opening time")
+
 type Room interface {
 	Create(ctx context.Context, req dto.CreateRoomRequest) error
 	GetAll(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (dto.GetRoomsResponse, error)
 	Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (int, error)
 	Get(ctx context.Context, id string) (dto.RoomResponse, error)
-	Update(ctx context.Context, req dto.UpdateRoomRequest, id string) error
-	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, req dto.UpdateRoomRequest, id string, version *int) error
+	Delete(ctx context.Context, id string, version *int) error
+	Restore(ctx context.Context, id string) error
+	GetAvailability(ctx context.Context, id string, req dto.AvailabilityRequest) (dto.AvailabilityResponse, error)
+	GetAvailableRooms(ctx context.Context, params gDto.QueryParams, req dto.AvailableRoomsRequest) (dto.GetRoomsResponse, error)
+	GetCalendar(ctx context.Context, id string) ([]byte, error)
 }
 
 type serviceImpl struct {
-	repo  repository.Room
-	cfg   *config.Config
-	cache cache.RedisCache
-	otel  otel.Otel
-	s3    s3.S3
+	repo        repository.Room
+	bookingRepo bookingRepo.Booking
+	outboxRepo  outboxRepo.Outbox
+	tx          postgres.TxManager
+	cfg         *config.Config
+	cache       cache.RedisCache
+	otel        otel.Otel
+	s3          s3.S3
 }
 
-func New(repo repository.Room, cfg *config.Config, cache cache.RedisCache, otel otel.Otel, s3 s3.S3) Room {
+func New(
+	repo repository.Room,
+	bookingRepo bookingRepo.Booking,
+	outboxRepo outboxRepo.Outbox,
+	tx postgres.TxManager,
+	cfg *config.Config,
+	cache cache.RedisCache,
+	otel otel.Otel,
+	s3 s3.S3,
+) Room {
 	return &serviceImpl{
-		repo:  repo,
-		cfg:   cfg,
-		cache: cache,
-		otel:  otel,
-		s3:    s3,
+		repo:        repo,
+		bookingRepo: bookingRepo,
+		outboxRepo:  outboxRepo,
+		tx:          tx,
+		cfg:         cfg,
+		cache:       cache,
+		otel:        otel,
+		s3:          s3,
 	}
 }
 
@@ -119,21 +155,12 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 		return res, nil
 	}
 
-	total, err := s.Count(ctx, req, filter)
+	models, page, err := s.list(ctx, req, filter)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to count rooms")
-
-		return res, fmt.Errorf("failed to count rooms: %w", err)
+		return res, err
 	}
 
-	models, err := s.repo.GetAll(ctx, req, filter)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to get rooms")
-
-		return res, fmt.Errorf("failed to get rooms: %w", err)
-	}
-
-	res.FromModels(models, total, req.Limit)
+	res.FromPage(models, page)
 
 	go func() {
 		c := context.WithoutCancel(ctx)
@@ -146,6 +173,50 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 	return res, nil
 }
 
+// list loads rooms by cursor or by page number, narrowed by the filters of
+// the query string. Totals are counted in page mode and, when asked for, in
+// cursor mode.
+func (s *serviceImpl) list(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) ([]model.Room, gDto.Pagination, error) {
+	var (
+		models []model.Room
+		page   gDto.Pagination
+	)
+
+	filter, err := s.repo.WithFilters(filter, req)
+	if err != nil {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if req.UseCursor() {
+		models, page, err = s.repo.GetPage(ctx, req, filter)
+	} else {
+		models, err = s.repo.GetAll(ctx, req, filter)
+	}
+
+	if errors.Is(err, gDto.ErrInvalidQuery) {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get rooms")
+
+		return nil, page, fmt.Errorf("failed to get rooms: %w", err)
+	}
+
+	if !req.UseCursor() || req.IncludeTotal {
+		total, err := s.Count(ctx, req, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to count rooms")
+
+			return nil, page, fmt.Errorf("failed to count rooms: %w", err)
+		}
+
+		page.SetTotal(total, shared.CalculateTotalPage(total, req.Limit))
+	}
+
+	return models, page, nil
+}
+
 func (s *serviceImpl) Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (res int, err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Count")
 	defer scope.End()
@@ -216,7 +287,9 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (res dto.RoomResponse,
 	return res, nil
 }
 
-func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateRoomRequest, id string) (err error) {
+// Update applies the request to the room, provided it is still at version
+// when one is given.
+func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateRoomRequest, id string, version *int) (err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Update")
 	defer scope.End()
 	defer scope.TraceIfError(err)
@@ -237,6 +310,8 @@ func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateRoomRequest, id
 		return failure.NotFound("room not found")
 	}
 
+	filter.Version = version
+
 	return s.updateInternal(ctx, req, currentRoom, user, filter)
 }
 
@@ -278,6 +353,12 @@ func (s *serviceImpl) updateInternal(ctx context.Context, req dto.UpdateRoomRequ
 			_ = s.s3.DeleteFile(ctx, bucketName, model.EntityName, uploadedObjectName)
 		}
 
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, currentRoom.ID)
+
+			return failure.PreconditionFailed(errRoomModified) // nolint:wrapcheck
+		}
+
 		return fmt.Errorf("failed to update room: %w", err)
 	}
 
@@ -303,7 +384,8 @@ func (s *serviceImpl) updateInternal(ctx context.Context, req dto.UpdateRoomRequ
 	return nil
 }
 
-func (s *serviceImpl) Delete(ctx context.Context, id string) error {
+// Delete deletes the room, provided it is still at version when one is given.
+func (s *serviceImpl) Delete(ctx context.Context, id string, version *int) error {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Delete")
 	defer scope.End()
 	defer scope.TraceIfError(nil)
@@ -321,7 +403,15 @@ func (s *serviceImpl) Delete(ctx context.Context, id string) error {
 		return failure.NotFound("room not found") // nolint:wrapcheck
 	}
 
-	if err := s.repo.Delete(ctx, shared.FilterByID(id, model.FieldID, model.TableName)); err != nil {
+	if err := s.tx.Do(ctx, func(ctx context.Context) error {
+		return s.deleteInternal(ctx, id, version)
+	}); err != nil {
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.PreconditionFailed(errRoomModified) // nolint:wrapcheck
+		}
+
 		log.Error().Err(err).Msg("failed to delete room")
 
 		return fmt.Errorf("failed to delete room: %w", err)
@@ -340,3 +430,437 @@ func (s *serviceImpl) Delete(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// deleteInternal deletes the room together with its bookings and announces the
+// deleted bookings. It is meant to run in a unit of work: the bookings and the
+// room then share their deletion time, and nothing is deleted when the room is
+// no longer at version.
+func (s *serviceImpl) deleteInternal(ctx context.Context, id string, version *int) error {
+	topic := s.cfg.Kafka.Topics.BookingEvents
+	bookingFilter := roomBookings(id)
+
+	bookings := []bookingModel.Booking{}
+
+	if topic != constant.Empty {
+		var err error
+
+		bookings, err = s.bookingRepo.GetAll(ctx, gDto.QueryParams{}, bookingFilter)
+		if err != nil {
+			return fmt.Errorf("failed to get room bookings: %w", err)
+		}
+	}
+
+	if err := s.bookingRepo.Delete(ctx, bookingFilter); err != nil {
+		return fmt.Errorf("failed to delete room bookings: %w", err)
+	}
+
+	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.Version = version
+
+	if err := s.repo.Delete(ctx, filter); err != nil {
+		return fmt.Errorf("failed to delete room: %w", err)
+	}
+
+	return s.addBookingEvents(ctx, bookingModel.EventDeleted, bookings)
+}
+
+// Restore brings back a deleted room with the bookings deleted along with it.
+// Bookings deleted on their own before the room stay deleted.
+func (s *serviceImpl) Restore(ctx context.Context, id string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Restore")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.WithDeleted = true
+
+	room, err := s.repo.Get(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get room")
+
+		return fmt.Errorf("failed to get room: %w", err)
+	}
+
+	if room.ID == constant.Empty {
+		return failure.NotFound("room not found") // nolint:wrapcheck
+	}
+
+	if room.DeletedAt == nil {
+		return failure.Conflict("room is not deleted") // nolint:wrapcheck
+	}
+
+	if err := s.tx.Do(ctx, func(ctx context.Context) error {
+		return s.restoreInternal(ctx, room, filter)
+	}); err != nil {
+		if shared.IsPqError(err, constant.PqErrorCodeExclusionViolation) {
+			return failure.Conflict(errRoomOverlap) // nolint:wrapcheck
+		}
+
+		log.Error().Err(err).Msg("failed to restore room")
+
+		return fmt.Errorf("failed to restore room: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllRoom)
+		shared.InvalidateCaches(c, s.cache, cacheCountRoom)
+	}()
+
+	return nil
+}
+
+func (s *serviceImpl) restoreInternal(ctx context.Context, room model.Room, filter gDto.FilterGroup) error {
+	bookingFilter := gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			roomBookings(room.ID),
+			gDto.Filter{
+				ArgName:  "room_deleted_at",
+				Field:    constant.FieldDeletedAt,
+				Operator: gDto.FilterOperatorEq,
+				Value:    *room.DeletedAt,
+				Table:    bookingModel.TableName,
+			},
+		},
+		WithDeleted: true,
+	}
+
+	bookings := []bookingModel.Booking{}
+
+	if s.cfg.Kafka.Topics.BookingEvents != constant.Empty {
+		var err error
+
+		bookings, err = s.bookingRepo.GetAll(ctx, gDto.QueryParams{}, bookingFilter)
+		if err != nil {
+			return fmt.Errorf("failed to get room bookings: %w", err)
+		}
+	}
+
+	if err := s.repo.Restore(ctx, filter); err != nil {
+		return fmt.Errorf("failed to restore room: %w", err)
+	}
+
+	if err := s.bookingRepo.Restore(ctx, bookingFilter); err != nil {
+		return fmt.Errorf("failed to restore room bookings: %w", err)
+	}
+
+	return s.addBookingEvents(ctx, bookingModel.EventRestored, bookings)
+}
+
+// forget drops the cached copy of a room whose write lost a race, so that
+// clients read the version that won instead of failing to match it again.
+func (s *serviceImpl) forget(ctx context.Context, id string) {
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		if err := s.cache.Delete(c, shared.BuildCacheKey(cacheGetRoom, id)); err != nil {
+			log.Error().Err(err).Msg("failed to delete room from cache")
+		}
+	}()
+}
+
+// addBookingEvents records an event for each booking in the outbox.
+func (s *serviceImpl) addBookingEvents(ctx context.Context, eventType string, bookings []bookingModel.Booking) error {
+	actor, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	events, err := bookingDto.ToEventMessages(s.cfg.Kafka.Topics.BookingEvents, eventType, actor, constant.Empty, bookings...)
+	if err != nil {
+		return fmt.Errorf("failed to build booking events: %w", err)
+	}
+
+	if err := s.outboxRepo.Add(ctx, events...); err != nil {
+		return fmt.Errorf("failed to add booking events to outbox: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceImpl) GetAvailability(ctx context.Context, id string, req dto.AvailabilityRequest) (res dto.AvailabilityResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GetAvailability")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	startDate, err := time.Parse(bookingModel.DateFormat, req.StartDate)
+	if err != nil {
+		return res, failure.BadRequestFromString("invalid start_date format, expected YYYY-MM-DD")
+	}
+
+	endDate := startDate
+	if req.EndDate != constant.Empty {
+		endDate, err = time.Parse(bookingModel.DateFormat, req.EndDate)
+		if err != nil {
+			return res, failure.BadRequestFromString("invalid end_date format, expected YYYY-MM-DD")
+		}
+	}
+
+	if endDate.Before(startDate) {
+		return res, failure.BadRequestFromString("end_date must not be before start_date")
+	}
+
+	if endDate.Sub(startDate) > maxAvailabilityDays*24*time.Hour {
+		return res, failure.BadRequestFromString(fmt.Sprintf("date range must not exceed %d days", maxAvailabilityDays))
+	}
+
+	openTime, closeTime := s.openingHours()
+
+	open, err := dto.ParseClock(openTime)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse opening time: %w", err)
+	}
+
+	closing, err := dto.ParseClock(closeTime)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse closing time: %w", err)
+	}
+
+	if closing <= open {
+		return res, errInvalidOpeningHours
+	}
+
+	exist, err := s.repo.Exist(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to check if room exists")
+
+		return res, fmt.Errorf("failed to check if room exists: %w", err)
+	}
+
+	if !exist {
+		return res, failure.NotFound("room not found")
+	}
+
+	bookings, err := s.bookingRepo.GetAll(ctx, gDto.QueryParams{}, bookingsInRange(id, req.StartDate, endDate.Format(bookingModel.DateFormat)))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get room bookings")
+
+		return res, fmt.Errorf("failed to get room bookings: %w", err)
+	}
+
+	byDate := map[string][]bookingModel.Booking{}
+	for _, booking := range bookings {
+		key := booking.BookingDate.Format(bookingModel.DateFormat)
+		byDate[key] = append(byDate[key], booking)
+	}
+
+	minDuration := time.Duration(req.MinDuration) * time.Minute
+
+	res.RoomID = id
+	res.Open = openTime
+	res.Close = closeTime
+
+	for date := startDate; !date.After(endDate); date = date.AddDate(0, 0, 1) {
+		day := dto.DayAvailability{}
+		day.FromBookings(date, open, closing, byDate[date.Format(bookingModel.DateFormat)], minDuration)
+
+		res.Days = append(res.Days, day)
+	}
+
+	return res, nil
+}
+
+// GetCalendar renders the room's recent and upcoming bookings as an iCalendar
+// feed. The feed is public, so guest details and purposes are left out.
+func (s *serviceImpl) GetCalendar(ctx context.Context, id string) (res []byte, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GetCalendar")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	room, err := s.repo.Get(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get room")
+
+		return res, fmt.Errorf("failed to get room: %w", err)
+	}
+
+	if room.ID == constant.Empty {
+		return res, failure.NotFound("room not found")
+	}
+
+	from := timezone.Now().AddDate(0, 0, -bookingModel.CalendarPastDays).Format(bookingModel.DateFormat)
+
+	filter := bookingRepo.CalendarFilter(from)
+	filter.Filters = append(filter.Filters, gDto.Filter{
+		Field:    bookingModel.FieldRoomID,
+		Operator: gDto.FilterOperatorEq,
+		Value:    id,
+		Table:    bookingModel.TableName,
+	})
+
+	params := gDto.QueryParams{SortBy: bookingModel.FieldBookingDate, SortDir: gDto.SortDirAsc}
+
+	bookings, err := s.bookingRepo.GetAll(ctx, params, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get room bookings")
+
+		return res, fmt.Errorf("failed to get room bookings: %w", err)
+	}
+
+	calendar := bookingDto.ToCalendar(bookings, bookingDto.CalendarOptions{
+		AppName: s.cfg.App.Name,
+		Name:    room.Name,
+		Rooms:   map[string]string{room.ID: room.Name},
+	})
+
+	return calendar.Marshal(), nil
+}
+
+// GetAvailableRooms lists active rooms without an active booking overlapping the requested window.
+// Results are not cached because they depend on bookings as well as rooms.
+func (s *serviceImpl) GetAvailableRooms(ctx context.Context, params gDto.QueryParams, req dto.AvailableRoomsRequest) (res dto.GetRoomsResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".GetAvailableRooms")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	start, err := dto.ParseClock(req.Start)
+	if err != nil {
+		return res, failure.BadRequestFromString("invalid start format, expected HH:MM")
+	}
+
+	end, err := dto.ParseClock(req.End)
+	if err != nil {
+		return res, failure.BadRequestFromString("invalid end format, expected HH:MM")
+	}
+
+	if end <= start {
+		return res, failure.BadRequestFromString("end must be after start")
+	}
+
+	filter, err := s.repo.WithFilters(availableRoomsFilter(req), params)
+	if err != nil {
+		return res, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to count available rooms")
+
+		return res, fmt.Errorf("failed to count available rooms: %w", err)
+	}
+
+	models, err := s.repo.GetAll(ctx, params, filter)
+	if errors.Is(err, gDto.ErrInvalidQuery) {
+		return res, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get available rooms")
+
+		return res, fmt.Errorf("failed to get available rooms: %w", err)
+	}
+
+	res.FromModels(models, total, params.Limit)
+
+	return res, nil
+}
+
+// openingHours returns the configured opening and closing times, falling back to the defaults.
+func (s *serviceImpl) openingHours() (string, string) {
+	open := s.cfg.App.OpeningHours.Open
+	if open == constant.Empty {
+		open = defaultOpeningTime
+	}
+
+	closing := s.cfg.App.OpeningHours.Close
+	if closing == constant.Empty {
+		closing = defaultClosingTime
+	}
+
+	return open, closing
+}
+
+// roomBookings matches the bookings of a room.
+func roomBookings(roomID string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Filters: []any{
+			gDto.Filter{
+				Field:    bookingModel.FieldRoomID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    roomID,
+				Table:    bookingModel.TableName,
+			},
+		},
+	}
+}
+
+func bookingsInRange(roomID, startDate, endDate string) gDto.FilterGroup {
+	return gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    bookingModel.FieldRoomID,
+				Operator: gDto.FilterOperatorEq,
+				Value:    roomID,
+				Table:    bookingModel.TableName,
+			},
+			gDto.Filter{
+				ArgName:  "start_date",
+				Field:    bookingModel.FieldBookingDate,
+				Operator: gDto.FilterOperatorGreaterEq,
+				Value:    startDate,
+				Table:    bookingModel.TableName,
+			},
+			gDto.Filter{
+				ArgName:  "end_date",
+				Field:    bookingModel.FieldBookingDate,
+				Operator: gDto.FilterOperatorLessEq,
+				Value:    endDate,
+				Table:    bookingModel.TableName,
+			},
+			gDto.Filter{
+				Field:    bookingModel.FieldStatus,
+				Operator: gDto.FilterOperatorIn,
+				Value:    bookingModel.ActiveStatuses,
+				Table:    bookingModel.TableName,
+			},
+		},
+	}
+}
+
+func availableRoomsFilter(req dto.AvailableRoomsRequest) gDto.FilterGroup {
+	filter := gDto.FilterGroup{
+		Operator: gDto.FilterGroupOperatorAnd,
+		Filters: []any{
+			gDto.Filter{
+				Field:    model.FieldActive,
+				Operator: gDto.FilterOperatorEq,
+				Value:    true,
+				Table:    model.TableName,
+			},
+			gDto.Filter{
+				Operator: gDto.FilterNotExists,
+				Table:    bookingModel.TableName,
+				Value: gDto.FilterGroup{
+					Operator: gDto.FilterGroupOperatorAnd,
+					Filters: []any{
+						gDto.Filter{
+							Operator: gDto.FilterPlainQuery,
+							Value:    fmt.Sprintf("%s.%s = %s.%s", bookingModel.TableName, bookingModel.FieldRoomID, model.TableName, model.FieldID),
+						},
+						bookingRepo.WindowFilter(req.Date, req.Start, req.End),
+					},
+				},
+			},
+		},
+	}
+
+	if req.MinCapacity > 0 {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			Field:    model.FieldCapacity,
+			Operator: gDto.FilterOperatorGreaterEq,
+			Value:    req.MinCapacity,
+			Table:    model.TableName,
+		})
+	}
+
+	if req.Location != constant.Empty {
+		filter.Filters = append(filter.Filters, gDto.Filter{
+			Field:    model.FieldLocation,
+			Operator: gDto.FilterOperatorLike,
+			Value:    req.Location,
+			Table:    model.TableName,
+		})
+	}
+
+	return filter
+}
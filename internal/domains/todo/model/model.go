@@ -13,9 +13,9 @@ const (
 )
 
 type Todo struct {
-	ID          string `db:"id"`
-	Title       string `db:"title"`
+	ID          string `db:"id"          query:"filter"`
+	Title       string `db:"title"       query:"sort,filter"`
 	Description string `db:"description"`
-	Completed   bool   `db:"completed"`
+	Completed   bool   `db:"completed"   query:"filter"`
 	model.Metadata
 }
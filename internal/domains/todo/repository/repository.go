@@ -27,9 +27,9 @@ type repositoryImpl struct {
 	otel otel.Otel
 }
 
-func New(db *postgres.Connection, otel otel.Otel) Todo {
+func New(db *postgres.Connection, tx postgres.TxManager, otel otel.Otel) Todo {
 	return &repositoryImpl{
-		Repository: gRepo.NewRepository[model.Todo](model.EntityName, model.TableName, model.FieldID, db, otel),
+		Repository: gRepo.NewRepository[model.Todo](model.EntityName, model.TableName, model.FieldID, db, tx, otel),
 		db:         db,
 		otel:       otel,
 	}
@@ -5,8 +5,10 @@ import (
 	"oil/shared"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
+	"oil/shared/lockout"
 	gModel "oil/shared/model"
 	"oil/shared/timezone"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -34,7 +36,7 @@ func (r *CreateUserRequest) ToModel(username string, hashedPassword string) mode
 	return model.User{
 		ID:           uuid.NewString(),
 		Email:        r.Email,
-		Password:     hashedPassword,
+		Password:     &hashedPassword,
 		Level:        level,
 		FullName:     r.FullName,
 		ProfileImage: r.ProfileImage,
@@ -58,6 +60,7 @@ type UserResponse struct {
 	IsVerified   bool    `json:"is_verified"`
 	LastLogin    *string `json:"last_login,omitempty"`
 	Active       bool    `json:"active"`
+	Version      int     `json:"version"`
 	gDto.Metadata
 }
 
@@ -70,6 +73,7 @@ func (r *UserResponse) FromModel(model model.User) {
 	r.IsVerified = model.IsVerified
 	r.LastLogin = model.LastLogin
 	r.Active = model.Active
+	r.Version = model.Version
 	r.Metadata.FromModel(model.Metadata)
 }
 
@@ -87,17 +91,36 @@ type UpdateProfileRequest struct {
 }
 
 type GetUsersResponse struct {
-	Users     []UserResponse `json:"users"`
-	TotalPage int            `json:"total_page"`
-	TotalData int            `json:"total_data"`
+	Users []UserResponse `json:"users"`
+	gDto.Pagination
 }
 
 func (r *GetUsersResponse) FromModels(models []model.User, totalData, limit int) {
-	r.TotalData = totalData
-	r.TotalPage = shared.CalculateTotalPage(totalData, limit)
+	page := gDto.Pagination{}
+	page.SetTotal(totalData, shared.CalculateTotalPage(totalData, limit))
+
+	r.FromPage(models, page)
+}
+
+func (r *GetUsersResponse) FromPage(models []model.User, page gDto.Pagination) {
+	r.Pagination = page
 
 	r.Users = make([]UserResponse, len(models))
 	for i, mod := range models {
 		r.Users[i].FromModel(mod)
 	}
 }
+
+// LockoutResponse is the failed logins of a user and until when they are
+// locked out because of them.
+type LockoutResponse struct {
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+	Locked         bool       `json:"locked"`
+}
+
+func (r *LockoutResponse) FromStatus(status lockout.Status) {
+	r.FailedAttempts = status.FailedAttempts
+	r.LockedUntil = status.LockedUntil
+	r.Locked = status.Locked(timezone.Now())
+}
@@ -16,18 +16,34 @@ const (
 	FieldIsVerified   = "is_verified"
 	FieldLastLogin    = "last_login"
 	FieldActive       = "active"
+
+	FieldCalendarToken = "calendar_token"
 )
 
 type User struct {
-	ID           string  `db:"id"`
-	Email        string  `db:"email"`
-	Password     string  `db:"password"`
-	Level        string  `db:"level"`
-	GoogleID     *string `db:"google_id"`
-	FullName     *string `db:"full_name"`
+	ID           string  `db:"id"            query:"filter"`
+	Email        string  `db:"email"         query:"sort,filter"`
+	Password     *string `db:"password"      audit:"redact"`
+	Level        string  `db:"level"         query:"sort,filter"`
+	GoogleID     *string `db:"google_id"     audit:"redact"`
+	FullName     *string `db:"full_name"     query:"sort,filter"`
 	ProfileImage *string `db:"profile_image"`
-	IsVerified   bool    `db:"is_verified"`
-	LastLogin    *string `db:"last_login"`
-	Active       bool    `db:"active"`
+	IsVerified   bool    `db:"is_verified"   query:"filter"`
+	LastLogin    *string `db:"last_login"    query:"sort" version:"ignore"`
+	Active       bool    `db:"active"        query:"filter"`
+
+	CalendarToken *string `db:"calendar_token" audit:"redact"`
 	model.Metadata
+	model.SoftDelete
+	model.Versioned
+}
+
+// PasswordHash returns the password hash of the user, empty when they have no
+// password, like users signed up with Google.
+func (u User) PasswordHash() string {
+	if u.Password == nil {
+		return ""
+	}
+
+	return *u.Password
 }
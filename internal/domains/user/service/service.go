@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"oil/config"
 	"oil/infras/otel"
@@ -13,6 +14,7 @@ import (
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
 	"oil/shared/failure"
+	"oil/shared/lockout"
 	"oil/shared/password"
 
 	"github.com/rs/zerolog/log"
@@ -22,6 +24,8 @@ const (
 	cacheGetUser    = "user:get"
 	cacheGetAllUser = "user:gets"
 	cacheCountUser  = "user:count"
+
+	errUserModified = "user has been modified"
 )
 
 type User interface {
@@ -29,23 +33,28 @@ type User interface {
 	GetAll(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (dto.GetUsersResponse, error)
 	Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (int, error)
 	Get(ctx context.Context, id string) (dto.UserResponse, error)
-	Update(ctx context.Context, req dto.UpdateUserRequest, id string) error
-	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, req dto.UpdateUserRequest, id string, version *int) error
+	Delete(ctx context.Context, id string, version *int) error
+	Restore(ctx context.Context, id string) error
+	Lockout(ctx context.Context, id string) (dto.LockoutResponse, error)
+	Unlock(ctx context.Context, id string) error
 }
 
 type serviceImpl struct {
-	repo  repository.User
-	cfg   *config.Config
-	cache cache.RedisCache
-	otel  otel.Otel
+	repo    repository.User
+	cfg     *config.Config
+	cache   cache.RedisCache
+	lockout lockout.Lockout
+	otel    otel.Otel
 }
 
-func New(repo repository.User, cfg *config.Config, cache cache.RedisCache, otel otel.Otel) User {
+func New(repo repository.User, cfg *config.Config, cache cache.RedisCache, guard lockout.Lockout, otel otel.Otel) User {
 	return &serviceImpl{
-		repo:  repo,
-		cfg:   cfg,
-		cache: cache,
-		otel:  otel,
+		repo:    repo,
+		cfg:     cfg,
+		cache:   cache,
+		lockout: guard,
+		otel:    otel,
 	}
 }
 
@@ -67,6 +76,9 @@ func (s *serviceImpl) Create(ctx context.Context, req dto.CreateUserRequest) (er
 		},
 	}
 
+	// Deleted users keep their email until they are purged.
+	emailFilter.WithDeleted = true
+
 	exists, err := s.repo.Exist(ctx, emailFilter)
 	if err != nil {
 		log.Error().Err(err).Msg("failed to check if user exists")
@@ -115,21 +127,12 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 		return res, nil
 	}
 
-	total, err := s.Count(ctx, req, filter)
+	models, page, err := s.list(ctx, req, filter)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to count users")
-
-		return res, fmt.Errorf("failed to count users: %w", err)
+		return res, err
 	}
 
-	models, err := s.repo.GetAll(ctx, req, filter)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to get users")
-
-		return res, fmt.Errorf("failed to get users: %w", err)
-	}
-
-	res.FromModels(models, total, req.Limit)
+	res.FromPage(models, page)
 
 	go func() {
 		c := context.WithoutCancel(ctx)
@@ -142,6 +145,50 @@ func (s *serviceImpl) GetAll(ctx context.Context, req gDto.QueryParams, filter g
 	return res, nil
 }
 
+// list loads users by cursor or by page number, narrowed by the filters of
+// the query string. Totals are counted in page mode and, when asked for, in
+// cursor mode.
+func (s *serviceImpl) list(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) ([]model.User, gDto.Pagination, error) {
+	var (
+		models []model.User
+		page   gDto.Pagination
+	)
+
+	filter, err := s.repo.WithFilters(filter, req)
+	if err != nil {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if req.UseCursor() {
+		models, page, err = s.repo.GetPage(ctx, req, filter)
+	} else {
+		models, err = s.repo.GetAll(ctx, req, filter)
+	}
+
+	if errors.Is(err, gDto.ErrInvalidQuery) {
+		return nil, page, failure.BadRequest(err) // nolint:wrapcheck
+	}
+
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get users")
+
+		return nil, page, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	if !req.UseCursor() || req.IncludeTotal {
+		total, err := s.Count(ctx, req, filter)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to count users")
+
+			return nil, page, fmt.Errorf("failed to count users: %w", err)
+		}
+
+		page.SetTotal(total, shared.CalculateTotalPage(total, req.Limit))
+	}
+
+	return models, page, nil
+}
+
 func (s *serviceImpl) Count(ctx context.Context, req gDto.QueryParams, filter gDto.FilterGroup) (res int, err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Count")
 	defer scope.End()
@@ -212,7 +259,9 @@ func (s *serviceImpl) Get(ctx context.Context, id string) (res dto.UserResponse,
 	return res, nil
 }
 
-func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateUserRequest, id string) (err error) {
+// Update applies the request to the user, provided it is still at version
+// when one is given.
+func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateUserRequest, id string, version *int) (err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Update")
 	defer scope.End()
 	defer scope.TraceIfError(nil)
@@ -237,8 +286,16 @@ func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateUserRequest, id
 		return failure.NotFound("user not found")
 	}
 
+	filter.Version = version
+
 	updatedFields := shared.TransformFields(req, user)
 	if err := s.repo.Update(ctx, updatedFields, filter); err != nil {
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.PreconditionFailed(errUserModified) // nolint:wrapcheck
+		}
+
 		log.Error().Err(err).Msg("failed to update user")
 
 		return fmt.Errorf("failed to update user: %w", err)
@@ -258,7 +315,8 @@ func (s *serviceImpl) Update(ctx context.Context, req dto.UpdateUserRequest, id
 	return nil
 }
 
-func (s *serviceImpl) Delete(ctx context.Context, id string) (err error) {
+// Delete deletes the user, provided it is still at version when one is given.
+func (s *serviceImpl) Delete(ctx context.Context, id string, version *int) (err error) {
 	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Delete")
 	defer scope.End()
 	defer scope.TraceIfError(nil)
@@ -276,7 +334,16 @@ func (s *serviceImpl) Delete(ctx context.Context, id string) (err error) {
 		return failure.NotFound("user not found")
 	}
 
-	if err := s.repo.Delete(ctx, shared.FilterByID(id, model.FieldID, model.TableName)); err != nil {
+	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.Version = version
+
+	if err := s.repo.Delete(ctx, filter); err != nil {
+		if errors.Is(err, gDto.ErrVersionMismatch) {
+			s.forget(ctx, id)
+
+			return failure.PreconditionFailed(errUserModified) // nolint:wrapcheck
+		}
+
 		log.Error().Err(err).Msg("failed to delete user")
 
 		return fmt.Errorf("failed to delete user: %w", err)
@@ -295,3 +362,112 @@ func (s *serviceImpl) Delete(ctx context.Context, id string) (err error) {
 
 	return nil
 }
+
+func (s *serviceImpl) Restore(ctx context.Context, id string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Restore")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	filter := shared.FilterByID(id, model.FieldID, model.TableName)
+	filter.WithDeleted = true
+
+	current, err := s.repo.Get(ctx, filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if current.ID == constant.Empty {
+		return failure.NotFound("user not found")
+	}
+
+	if current.DeletedAt == nil {
+		return failure.Conflict("user is not deleted")
+	}
+
+	if err := s.repo.Restore(ctx, filter); err != nil {
+		log.Error().Err(err).Msg("failed to restore user")
+
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		shared.InvalidateCaches(c, s.cache, cacheGetAllUser)
+		shared.InvalidateCaches(c, s.cache, cacheCountUser)
+	}()
+
+	return nil
+}
+
+// Lockout returns the failed logins of a user and until when they are locked
+// out. It is read from the cache on every call, unlike the user.
+func (s *serviceImpl) Lockout(ctx context.Context, id string) (res dto.LockoutResponse, err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Lockout")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, err := s.repo.Get(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return res, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty {
+		return res, failure.NotFound("user not found")
+	}
+
+	status, err := s.lockout.Status(ctx, lockout.AccountKey(user.Email))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get lockout")
+
+		return res, fmt.Errorf("failed to get lockout: %w", err)
+	}
+
+	res.FromStatus(status)
+
+	return res, nil
+}
+
+// Unlock forgets the failed logins of a user, who can log in again at once.
+// Lockouts of the IP addresses they were made from are kept.
+func (s *serviceImpl) Unlock(ctx context.Context, id string) (err error) {
+	ctx, scope := s.otel.NewScope(ctx, constant.OtelServiceScopeName, constant.OtelServiceScopeName+".Unlock")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	user, err := s.repo.Get(ctx, shared.FilterByID(id, model.FieldID, model.TableName))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get user")
+
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if user.ID == constant.Empty {
+		return failure.NotFound("user not found")
+	}
+
+	if err := s.lockout.Reset(ctx, lockout.AccountKey(user.Email)); err != nil {
+		log.Error().Err(err).Msg("failed to unlock user")
+
+		return fmt.Errorf("failed to unlock user: %w", err)
+	}
+
+	return nil
+}
+
+// forget drops the cached copy of a user. Users are also written outside this
+// service, on login for instance, so the cached copy may carry an outdated
+// version that clients would keep failing to match.
+func (s *serviceImpl) forget(ctx context.Context, id string) {
+	go func() {
+		c := context.WithoutCancel(ctx)
+
+		if err := s.cache.Delete(c, shared.BuildCacheKey(cacheGetUser, id)); err != nil {
+			log.Error().Err(err).Msg("failed to delete user from cache")
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	userMocks "oil/internal/domains/user/mocks"
+	"oil/internal/domains/user/model"
+	"oil/internal/domains/user/service"
+	cacheMocks "oil/shared/cache/mocks"
+	"oil/shared/failure"
+	"oil/shared/lockout"
+	lockoutMocks "oil/shared/lockout/mocks"
+	"oil/shared/timezone"
+)
+
+func TestUserService_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := userMocks.NewMockUser(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+
+	svc := service.New(mockRepo, &config.Config{}, cacheMocks.NewMockRedisCache(ctrl), mockLockout, mocks.NewOtel())
+
+	user := model.User{ID: "user-1", Email: "Test@Example.com"}
+	lockedUntil := timezone.Now().Add(10 * time.Minute)
+	lockedUntilPast := timezone.Now().Add(-10 * time.Minute)
+
+	tests := []struct {
+		name       string
+		setupMock  func()
+		wantErr    bool
+		wantCode   int
+		wantLocked bool
+		wantFailed int
+	}{
+		{
+			name: "locked out",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().
+					Status(gomock.Any(), lockout.AccountKey(user.Email)).
+					Return(lockout.Status{FailedAttempts: 5, LockedUntil: &lockedUntil}, nil)
+			},
+			wantLocked: true,
+			wantFailed: 5,
+		},
+		{
+			name: "lockout over",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().
+					Status(gomock.Any(), lockout.AccountKey(user.Email)).
+					Return(lockout.Status{FailedAttempts: 5, LockedUntil: &lockedUntilPast}, nil)
+			},
+			wantFailed: 5,
+		},
+		{
+			name: "no failed logins",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Status(gomock.Any(), lockout.AccountKey(user.Email)).Return(lockout.Status{}, nil)
+			},
+		},
+		{
+			name: "user not found",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "repository error",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, errors.New("connection refused"))
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "cache error",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Status(gomock.Any(), gomock.Any()).Return(lockout.Status{}, errors.New("connection refused"))
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			res, err := svc.Lockout(context.Background(), user.ID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantFailed, res.FailedAttempts)
+			assert.Equal(t, tt.wantLocked, res.Locked)
+		})
+	}
+}
+
+func TestUserService_Unlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := userMocks.NewMockUser(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+
+	svc := service.New(mockRepo, &config.Config{}, cacheMocks.NewMockRedisCache(ctrl), mockLockout, mocks.NewOtel())
+
+	user := model.User{ID: "user-1", Email: "Test@Example.com"}
+
+	tests := []struct {
+		name      string
+		setupMock func()
+		wantErr   bool
+		wantCode  int
+	}{
+		{
+			name: "successful unlock",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Reset(gomock.Any(), lockout.AccountKey(user.Email)).Return(nil)
+			},
+		},
+		{
+			name: "user not found",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name: "repository error",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, errors.New("connection refused"))
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+		{
+			name: "cache error",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(user, nil)
+				mockLockout.EXPECT().Reset(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+			},
+			wantErr:  true,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			err := svc.Unlock(context.Background(), user.ID)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"net/http"
+	"oil/infras/otel"
+	"oil/internal/domains/audit/model/dto"
+	"oil/internal/domains/audit/service"
+	"oil/shared/constant"
+	gDto "oil/shared/dto"
+	"oil/shared/validator"
+	"oil/transport/http/response"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+type Handler struct {
+	service service.AuditLog
+	otel    otel.Otel
+}
+
+func New(service service.AuditLog, otel otel.Otel) Handler {
+	return Handler{
+		service: service,
+		otel:    otel,
+	}
+}
+
+func (handler *Handler) Router(router chi.Router) {
+	router.Route("/audit-logs", func(routerGroup chi.Router) {
+		routerGroup.Get("/", handler.GetAuditLogs)
+	})
+}
+
+// GetAuditLogs retrieves the audit log of repository writes.
+// @Summary Get audit logs
+// @Description Retrieve the recorded inserts, updates, deletes, restores and purges with the columns they changed, newest first.
+// @Tags Audit
+// @Accept json
+// @Produce json
+// @Param pagination query gDto.QueryParams false "Pagination parameters"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param entity query string false "Filter by entity, e.g. user"
+// @Param entity_id query string false "Filter by entity ID"
+// @Param actor query string false "Filter by the ID of the user who made the change"
+// @Param from query string false "Changes at or after this RFC 3339 time"
+// @Param to query string false "Changes before this RFC 3339 time"
+// @Success 200 {object} response.Data[dto.GetAuditLogsResponse] "List of audit logs"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/audit-logs [get]
+// @Security BearerAuth
+func (handler *Handler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetAuditLogs")
+	defer scope.End()
+
+	queryParams := gDto.QueryParams{}
+	queryParams.FromRequest(r, true)
+
+	query := r.URL.Query()
+	req := dto.GetAuditLogsRequest{
+		Entity:   query.Get("entity"),
+		EntityID: query.Get("entity_id"),
+		Actor:    query.Get("actor"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate query parameters")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	logs, err := handler.service.GetAll(ctx, queryParams, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get audit logs")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Audit logs retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, logs)
+}
@@ -27,20 +27,240 @@ func New(service service.Auth, otel otel.Otel) Handler {
 
 func (handler *Handler) Router(r chi.Router) {
 	r.Route("/auth", func(r chi.Router) {
+		r.Post("/register", handler.Register)
+		r.Post("/verify-email", handler.VerifyEmail)
+		r.Post("/resend-verification", handler.ResendVerification)
+		r.Post("/forgot-password", handler.ForgotPassword)
+		r.Post("/reset-password", handler.ResetPassword)
 		r.Post("/login", handler.Login)
+		r.Post("/google", handler.GoogleLogin)
+		r.Post("/mfa/setup", handler.SetupMFA)
+		r.Post("/mfa/verify", handler.VerifyMFA)
+		r.Post("/mfa/challenge", handler.ChallengeMFA)
 		r.Post("/refresh-token", handler.RefreshToken)
+		r.Post("/logout", handler.Logout)
+		r.Post("/logout-all", handler.LogoutAll)
+		r.Get("/sessions", handler.GetSessions)
+		r.Delete("/sessions/{id}", handler.DeleteSession)
 	})
 }
 
+// WellKnownRouter registers the routes served at the root, outside of the API
+// version, where clients expect to discover them.
+func (handler *Handler) WellKnownRouter(r chi.Router) {
+	r.Get("/.well-known/jwks.json", handler.GetJWKS)
+}
+
+// Register handles user self-registration
+// @Summary Register a user
+// @Description Register a new user and send them an email to verify their address. Registered emails get the same answer and an email telling their owner instead.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.RegisterRequest true "Register Request"
+// @Success 201 {object} response.Message "User registered successfully"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/register [post]
+func (handler *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".Register")
+	defer scope.End()
+
+	req := dto.RegisterRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.Register(ctx, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to register user")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User registered successfully")
+
+	response.WithMessage(w, http.StatusCreated, "Check your email to finish registering")
+}
+
+// VerifyEmail handles email verification
+// @Summary Verify an email address
+// @Description Verify the email address of a user with the token sent to them.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.VerifyEmailRequest true "Verify Email Request"
+// @Success 200 {object} response.Message "Email verified successfully"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/verify-email [post]
+func (handler *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".VerifyEmail")
+	defer scope.End()
+
+	req := dto.VerifyEmailRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.VerifyEmail(ctx, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to verify email")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Email verified successfully")
+
+	response.WithMessage(w, http.StatusOK, "Email verified successfully")
+}
+
+// ResendVerification handles resending the verification email
+// @Summary Resend the verification email
+// @Description Send a new verification email to a registered user whose address is not verified yet.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResendVerificationRequest true "Resend Verification Request"
+// @Success 200 {object} response.Message "Verification email sent"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/resend-verification [post]
+func (handler *Handler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ResendVerification")
+	defer scope.End()
+
+	req := dto.ResendVerificationRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.ResendVerification(ctx, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to resend verification email")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Verification email resent")
+
+	response.WithMessage(w, http.StatusOK, "If the email is registered and not verified yet, a verification email has been sent")
+}
+
+// ForgotPassword handles password reset requests
+// @Summary Request a password reset
+// @Description Email a single-use password reset link to a registered user.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Forgot Password Request"
+// @Success 200 {object} response.Message "Password reset email sent"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/forgot-password [post]
+func (handler *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ForgotPassword")
+	defer scope.End()
+
+	req := dto.ForgotPasswordRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.ForgotPassword(ctx, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to request password reset")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Password reset requested")
+
+	response.WithMessage(w, http.StatusOK, "If the email is registered, a password reset email has been sent")
+}
+
+// ResetPassword handles resetting a password with a reset token
+// @Summary Reset a password
+// @Description Set a new password with a password reset token and sign the user out everywhere.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} response.Message "Password reset successfully"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/reset-password [post]
+func (handler *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ResetPassword")
+	defer scope.End()
+
+	req := dto.ResetPasswordRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.ResetPassword(ctx, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to reset password")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Password reset successfully")
+
+	response.WithMessage(w, http.StatusOK, "Password reset successfully")
+}
+
 // Login handles user login
 // @Summary Login a user
-// @Description Login a user with the provided credentials.
+// @Description Login a user with the provided credentials. Users with multi-factor authentication enabled get an MFA token to complete the login at /v1/auth/mfa/challenge instead of their tokens. Accounts and IP addresses that fail too often are locked out for a time that doubles with each further failure.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginRequest true "Login Request"
 // @Success 200 {object} dto.LoginResponse "User logged in successfully"
 // @Failure 400 {object} response.Error
+// @Failure 429 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/auth/login [post]
 func (handler *Handler) Login(w http.ResponseWriter, r *http.Request) {
@@ -73,15 +293,181 @@ func (handler *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusOK, res)
 }
 
+// GoogleLogin handles login with a Google account
+// @Summary Login with Google
+// @Description Login with a Google ID token, linking the Google account to the user with its verified email or signing a new user up.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.GoogleLoginRequest true "Google Login Request"
+// @Success 200 {object} dto.LoginResponse "User logged in successfully"
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/google [post]
+func (handler *Handler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GoogleLogin")
+	defer scope.End()
+
+	req := dto.GoogleLoginRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	res, err := handler.service.GoogleLogin(ctx, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to login user with google")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User logged in with google successfully")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// SetupMFA handles starting the enrolment in multi-factor authentication
+// @Summary Set up multi-factor authentication
+// @Description Generate an authenticator secret for the authenticated user, to be confirmed with a code at /v1/auth/mfa/verify.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.MFASetupResponse "MFA secret generated"
+// @Failure 401 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/mfa/setup [post]
+func (handler *Handler) SetupMFA(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".SetupMFA")
+	defer scope.End()
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	res, err := handler.service.SetupMFA(ctx, user)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to set up mfa")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("MFA secret generated")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// VerifyMFA handles confirming the enrolment in multi-factor authentication
+// @Summary Verify multi-factor authentication
+// @Description Enable multi-factor authentication for the authenticated user with a code of their authenticator and return their recovery codes, which are only shown once.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.MFAVerifyRequest true "MFA Verify Request"
+// @Success 200 {object} dto.MFAVerifyResponse "MFA enabled successfully"
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/mfa/verify [post]
+func (handler *Handler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".VerifyMFA")
+	defer scope.End()
+
+	req := dto.MFAVerifyRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	res, err := handler.service.VerifyMFA(ctx, req, user)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to verify mfa")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("MFA enabled successfully")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// ChallengeMFA handles the second login step of users with multi-factor authentication
+// @Summary Complete a login with multi-factor authentication
+// @Description Exchange the MFA token of the first login step and a code of the authenticator, or a recovery code, for the user's tokens. Wrong codes count as failed logins of the account, and the MFA token is revoked after 5 of them.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body dto.MFAChallengeRequest true "MFA Challenge Request"
+// @Success 200 {object} dto.LoginResponse "User logged in successfully"
+// @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
+// @Failure 429 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/mfa/challenge [post]
+func (handler *Handler) ChallengeMFA(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ChallengeMFA")
+	defer scope.End()
+
+	req := dto.MFAChallengeRequest{}
+
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	res, err := handler.service.ChallengeMFA(ctx, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to complete mfa challenge")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User logged in with mfa successfully")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
 // RefreshToken handles token refresh
 // @Summary Refresh user token
-// @Description Refresh user token using the provided refresh token.
+// @Description Exchange a refresh token for a new token pair. Refresh tokens are single-use: using one again revokes its session.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body dto.RefreshTokenRequest true "Refresh Token Request"
 // @Success 200 {object} dto.RefreshTokenResponse "Token refreshed successfully"
 // @Failure 400 {object} response.Error
+// @Failure 401 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/auth/refresh-token [post]
 func (handler *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -113,3 +499,156 @@ func (handler *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	response.WithJSON(w, http.StatusOK, res)
 }
+
+// Logout handles signing out of the current session
+// @Summary Log out
+// @Description End the session of the access token, which revokes it and its refresh token.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Message "Logged out successfully"
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/logout [post]
+func (handler *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".Logout")
+	defer scope.End()
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	session, _ := ctx.Value(constant.ContextKeySessionID).(string)
+
+	if err := handler.service.Logout(ctx, user, session); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to log out")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Logged out successfully")
+
+	response.WithMessage(w, http.StatusOK, "Logged out successfully")
+}
+
+// LogoutAll handles signing out of every session
+// @Summary Log out everywhere
+// @Description End all sessions of the authenticated user, on every device, including the current one.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Message "Logged out of all sessions successfully"
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/logout-all [post]
+func (handler *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".LogoutAll")
+	defer scope.End()
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	if err := handler.service.LogoutAll(ctx, user); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to log out of all sessions")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Logged out of all sessions successfully")
+
+	response.WithMessage(w, http.StatusOK, "Logged out of all sessions successfully")
+}
+
+// GetSessions handles listing the sessions of the user
+// @Summary List sessions
+// @Description List the sessions of the authenticated user with the device, IP address and time they signed in, the most recently used first. Sessions ended because a refresh token was used twice are listed with reused_at until they would have expired.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} dto.SessionResponse "Sessions retrieved successfully"
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/sessions [get]
+func (handler *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetSessions")
+	defer scope.End()
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	session, _ := ctx.Value(constant.ContextKeySessionID).(string)
+
+	res, err := handler.service.Sessions(ctx, user, session)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get sessions")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Sessions retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// DeleteSession handles signing out of one session
+// @Summary Revoke a session
+// @Description End a session of the authenticated user, such as that of a lost device, which revokes its tokens.
+// @Tags Auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} response.Message "Session revoked successfully"
+// @Failure 401 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/auth/sessions/{id} [delete]
+func (handler *Handler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".DeleteSession")
+	defer scope.End()
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	if err := handler.service.RevokeSession(ctx, user, id); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to revoke session")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Session revoked successfully")
+
+	response.WithMessage(w, http.StatusOK, "Session revoked successfully")
+}
+
+// GetJWKS handles publishing the keys that verify access tokens
+// @Summary Get JSON Web Key Set
+// @Description Get the public keys that verify access tokens, identified by the kid header of a token. Other services verify tokens with them without a shared secret, and fetch them again when they meet an unknown kid. The keys also sign refresh and MFA tokens, so verifiers must require the typ header at+jwt, which only access tokens carry. A new key is published before it signs tokens and stays published until the tokens it signed have expired. The set is empty when tokens are signed with shared secrets.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} jwt.JWKS "JSON Web Key Set"
+// @Failure 500 {object} response.Error
+// @Router /.well-known/jwks.json [get]
+func (handler *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetJWKS")
+	defer scope.End()
+
+	jwks, err := handler.service.JWKS(ctx)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get JWKS")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("JWKS retrieved successfully")
+
+	response.WithContent(w, http.StatusOK, constant.ContentTypeJSON, jwks)
+}
@@ -6,8 +6,11 @@ import (
 	"oil/internal/domains/booking/model"
 	"oil/internal/domains/booking/model/dto"
 	"oil/internal/domains/booking/service"
+	"oil/shared"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
+	"oil/shared/etag"
+	"oil/shared/failure"
 	"oil/shared/validator"
 	"oil/transport/http/response"
 
@@ -31,11 +34,20 @@ func New(service service.Booking, otel otel.Otel) Handler {
 func (handler *Handler) Router(router chi.Router) {
 	router.Route("/bookings", func(routerGroup chi.Router) {
 		routerGroup.Post("/", handler.CreateBooking)
+		routerGroup.Post("/import", handler.ImportBookings)
 		routerGroup.Get("/", handler.GetBookings)
 		routerGroup.Get("/mybookings", handler.GetMyBookings)
+		routerGroup.Get("/mybookings/calendar.ics", handler.GetMyBookingsCalendar)
+		routerGroup.Post("/mybookings/calendar-token", handler.IssueCalendarToken)
 		routerGroup.Get("/{id}", handler.GetBookingByID)
 		routerGroup.Patch("/{id}", handler.UpdateBooking)
 		routerGroup.Delete("/{id}", handler.DeleteBooking)
+		routerGroup.Post("/{id}/restore", handler.RestoreBooking)
+		routerGroup.Post("/{id}/approve", handler.ApproveBooking)
+		routerGroup.Post("/{id}/reject", handler.RejectBooking)
+		routerGroup.Post("/{id}/cancel", handler.CancelBooking)
+		routerGroup.Post("/{id}/complete", handler.CompleteBooking)
+		routerGroup.Post("/{id}/no-show", handler.MarkNoShowBooking)
 	})
 }
 
@@ -48,6 +60,7 @@ func (handler *Handler) Router(router chi.Router) {
 // @Param request body dto.CreateBookingRequest true "Create Booking Request"
 // @Success 201 {object} response.Message "Booking created successfully"
 // @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/bookings [post]
 // @Security BearerAuth
@@ -81,20 +94,91 @@ func (handler *Handler) CreateBooking(writer http.ResponseWriter, request *http.
 	response.WithMessage(writer, http.StatusCreated, "Booking created successfully")
 }
 
+// ImportBookings imports bookings from an iCalendar file.
+// @Summary Import bookings from an iCalendar file
+// @Description Create bookings in a room from the events of an .ics file. Every event is checked for conflicts and reported as created, skipped or conflict. With dry_run nothing is stored.
+// @Tags Booking
+// @Accept multipart/form-data
+// @Produce json
+// @Param room_id formData string true "Room ID"
+// @Param dry_run formData boolean false "Only report what would be imported"
+// @Param file formData file true "iCalendar file (text/calendar)"
+// @Success 200 {object} response.Data[dto.ImportBookingsResponse] "Import report"
+// @Failure 400 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/import [post]
+// @Security BearerAuth
+func (handler *Handler) ImportBookings(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ImportBookings")
+	defer scope.End()
+
+	if err := r.ParseMultipartForm(constant.RequestMaxMemory); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to parse multipart form")
+		response.WithError(w, failure.BadRequest(err))
+
+		return
+	}
+
+	req := dto.ImportBookingsRequest{
+		RoomID: r.FormValue("room_id"),
+	}
+
+	if dryRun := shared.ConvertStringToBool(r.FormValue("dry_run")); dryRun != nil {
+		req.DryRun = *dryRun
+	}
+
+	file, fileHeader, err := r.FormFile(constant.FormFile)
+	if err == nil {
+		req.File = fileHeader
+		req.Calendar = file
+
+		defer file.Close()
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	res, err := handler.service.Import(ctx, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to import bookings")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	scope.AddEvent("Bookings imported by user " + user)
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
 // GetBookings retrieves all bookings based on query parameters.
 // @Summary Get all bookings
-// @Description Retrieve all bookings with optional filtering and pagination.
+// @Description Retrieve bookings with optional filtering and pagination. Admins see every booking, other users only their own.
 // @Tags Booking
 // @Accept json
 // @Produce json
 // @Param pagination query gDto.QueryParams false "Pagination parameters"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param filter[field][op] query string false "Filter on a field with eq, not_eq, like, in, less, less_eq, greater, greater_eq, is_null or is_not_null"
 // @Param room_id query string false "Filter by room ID"
-// @Param status query string false "Filter by status (pending, confirmed, cancelled)"
+// @Param status query string false "Filter by status (pending, confirmed, rejected, cancelled, completed, no_show)"
 // @Param booking_date query string false "Filter by booking date (YYYY-MM-DD)"
 // @Success 200 {object} response.Data[dto.BookingResponse] "List of bookings"
 // @Failure 400 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/bookings [get]
+// @Security BearerAuth
 func (handler *Handler) GetBookings(w http.ResponseWriter, r *http.Request) {
 	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetBookings")
 	defer scope.End()
@@ -161,7 +245,9 @@ func (handler *Handler) GetBookings(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param pagination query gDto.QueryParams false "Pagination parameters"
-// @Param status query string false "Filter by status (pending, confirmed, cancelled)"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param filter[field][op] query string false "Filter on a field with eq, not_eq, like, in, less, less_eq, greater, greater_eq, is_null or is_not_null"
+// @Param status query string false "Filter by status (pending, confirmed, rejected, cancelled, completed, no_show)"
 // @Param booking_date query string false "Filter by booking date (YYYY-MM-DD)"
 // @Success 200 {object} response.Data[dto.BookingResponse] "List of user's bookings"
 // @Failure 400 {object} response.Error
@@ -226,6 +312,65 @@ func (handler *Handler) GetMyBookings(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusOK, bookings)
 }
 
+// GetMyBookingsCalendar exports the bookings of a user as an iCalendar feed.
+// @Summary Get my bookings calendar feed
+// @Description Export the recent and upcoming bookings of the user owning the feed token as an iCalendar (RFC 5545) feed. Calendar clients cannot send bearer tokens, so the secret feed token authenticates the request.
+// @Tags Booking
+// @Produce text/calendar
+// @Param token query string true "Personal calendar feed token"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/mybookings/calendar.ics [get]
+func (handler *Handler) GetMyBookingsCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetMyBookingsCalendar")
+	defer scope.End()
+
+	calendar, err := handler.service.GetUserCalendar(ctx, r.URL.Query().Get(constant.RequestParamToken))
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get user calendar")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User calendar exported successfully")
+
+	response.WithContent(w, http.StatusOK, constant.ContentTypeCalendar, calendar)
+}
+
+// IssueCalendarToken issues a new personal calendar feed token.
+// @Summary Issue a calendar feed token
+// @Description Issue a new secret token for the personal calendar feed. The token is only returned once and replaces any previously issued token.
+// @Tags Booking
+// @Produce json
+// @Success 200 {object} response.Data[dto.CalendarTokenResponse] "Calendar feed token"
+// @Failure 401 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/mybookings/calendar-token [post]
+// @Security BearerAuth
+func (handler *Handler) IssueCalendarToken(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".IssueCalendarToken")
+	defer scope.End()
+
+	res, err := handler.service.IssueCalendarToken(ctx)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to issue calendar token")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	scope.AddEvent("Calendar token issued for user " + user)
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
 // GetBookingByID retrieves a booking by its ID.
 // @Summary Get a booking by ID
 // @Description Retrieve a booking by its unique identifier.
@@ -233,11 +378,16 @@ func (handler *Handler) GetMyBookings(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Booking ID"
+// @Param If-None-Match header string false "ETag of a cached copy of the booking"
 // @Success 200 {object} response.Data[dto.BookingResponse] "Booking details"
+// @Header 200 {string} ETag "Version of the booking"
+// @Success 304 "Cached copy is current"
 // @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
 // @Failure 404 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/bookings/{id} [get]
+// @Security BearerAuth
 func (handler *Handler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
 	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetBookingByID")
 	defer scope.End()
@@ -254,6 +404,14 @@ func (handler *Handler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag.Set(w, booking.Version)
+
+	if etag.NotModified(r, booking.Version) {
+		response.WithNotModified(w)
+
+		return
+	}
+
 	scope.AddEvent("Booking retrieved successfully")
 
 	response.WithJSON(w, http.StatusOK, booking)
@@ -267,9 +425,15 @@ func (handler *Handler) GetBookingByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "Booking ID"
 // @Param request body dto.UpdateBookingRequest true "Update Booking Request"
+// @Param scope query string false "Occurrences of a recurring booking to update (this, following, all)"
+// @Param If-Match header string true "ETag of the version of the booking being changed, or *"
 // @Success 200 {object} response.Message "Booking updated successfully"
 // @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/bookings/{id} [patch]
 // @Security BearerAuth
@@ -279,6 +443,16 @@ func (handler *Handler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
 	req := dto.UpdateBookingRequest{}
 	if err := validator.Validate(r.Body, &req); err != nil {
 		scope.TraceError(err)
@@ -289,7 +463,7 @@ func (handler *Handler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := handler.service.Update(ctx, req, id); err != nil {
+	if err := handler.service.Update(ctx, req, id, r.URL.Query().Get(constant.RequestParamScope), version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to update booking")
 
@@ -311,9 +485,14 @@ func (handler *Handler) UpdateBooking(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Booking ID"
+// @Param scope query string false "Occurrences of a recurring booking to delete (this, following, all)"
+// @Param If-Match header string true "ETag of the version of the booking being changed, or *"
 // @Success 200 {object} response.Message "Booking deleted successfully"
 // @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/bookings/{id} [delete]
 // @Security BearerAuth
@@ -323,7 +502,17 @@ func (handler *Handler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
-	if err := handler.service.Delete(ctx, id); err != nil {
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.Delete(ctx, id, r.URL.Query().Get(constant.RequestParamScope), version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to delete booking")
 
@@ -337,3 +526,168 @@ func (handler *Handler) DeleteBooking(w http.ResponseWriter, r *http.Request) {
 
 	response.WithMessage(w, http.StatusOK, "Booking deleted successfully")
 }
+
+// RestoreBooking restores a deleted booking by its ID.
+// @Summary Restore a deleted booking
+// @Description Restore a booking that was deleted and not purged yet.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Success 200 {object} response.Message "Booking restored successfully"
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/restore [post]
+// @Security BearerAuth
+func (handler *Handler) RestoreBooking(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".RestoreBooking")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	if err := handler.service.Restore(ctx, id); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to restore booking")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	scope.AddEvent("Booking restored successfully by user " + user)
+
+	response.WithMessage(w, http.StatusOK, "Booking restored successfully")
+}
+
+// ApproveBooking moves a booking to the confirmed status.
+// @Summary Approve a pending booking
+// @Description Confirm a pending booking.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param request body dto.ChangeStatusRequest true "Change Status Request"
+// @Success 200 {object} response.Message "Booking approved successfully"
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/approve [post]
+// @Security BearerAuth
+func (handler *Handler) ApproveBooking(w http.ResponseWriter, r *http.Request) {
+	handler.changeStatus(w, r, model.StatusConfirmed, "Booking approved successfully")
+}
+
+// RejectBooking moves a booking to the rejected status.
+// @Summary Reject a pending booking
+// @Description Reject a pending booking, optionally with a reason.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param request body dto.ChangeStatusRequest true "Change Status Request"
+// @Success 200 {object} response.Message "Booking rejected successfully"
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/reject [post]
+// @Security BearerAuth
+func (handler *Handler) RejectBooking(w http.ResponseWriter, r *http.Request) {
+	handler.changeStatus(w, r, model.StatusRejected, "Booking rejected successfully")
+}
+
+// CancelBooking moves a booking to the cancelled status.
+// @Summary Cancel a booking
+// @Description Cancel a pending or confirmed booking, optionally with a reason.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param request body dto.ChangeStatusRequest true "Change Status Request"
+// @Success 200 {object} response.Message "Booking cancelled successfully"
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/cancel [post]
+// @Security BearerAuth
+func (handler *Handler) CancelBooking(w http.ResponseWriter, r *http.Request) {
+	handler.changeStatus(w, r, model.StatusCancelled, "Booking cancelled successfully")
+}
+
+// CompleteBooking moves a booking to the completed status.
+// @Summary Mark a booking as completed
+// @Description Mark a confirmed booking as completed.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param request body dto.ChangeStatusRequest true "Change Status Request"
+// @Success 200 {object} response.Message "Booking completed successfully"
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/complete [post]
+// @Security BearerAuth
+func (handler *Handler) CompleteBooking(w http.ResponseWriter, r *http.Request) {
+	handler.changeStatus(w, r, model.StatusCompleted, "Booking completed successfully")
+}
+
+// MarkNoShowBooking moves a booking to the no_show status.
+// @Summary Mark a booking as no-show
+// @Description Mark a confirmed booking whose guest did not show up.
+// @Tags Booking
+// @Accept json
+// @Produce json
+// @Param id path string true "Booking ID"
+// @Param request body dto.ChangeStatusRequest true "Change Status Request"
+// @Success 200 {object} response.Message "Booking marked as no-show successfully"
+// @Failure 400 {object} response.Error
+// @Failure 403 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/bookings/{id}/no-show [post]
+// @Security BearerAuth
+func (handler *Handler) MarkNoShowBooking(w http.ResponseWriter, r *http.Request) {
+	handler.changeStatus(w, r, model.StatusNoShow, "Booking marked as no-show successfully")
+}
+
+func (handler *Handler) changeStatus(w http.ResponseWriter, r *http.Request, status, message string) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".ChangeStatus")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	req := dto.ChangeStatusRequest{}
+	if err := validator.Validate(r.Body, &req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request body")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.ChangeStatus(ctx, id, status, req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to change booking status")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	scope.AddEvent("Booking status changed to " + status + " by user " + user)
+
+	response.WithMessage(w, http.StatusOK, message)
+}
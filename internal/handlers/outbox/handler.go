@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"net/http"
+	"oil/infras/otel"
+	"oil/internal/domains/outbox/service"
+	"oil/shared/constant"
+	"oil/transport/http/response"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/rs/zerolog/log"
+)
+
+type Handler struct {
+	service service.Relay
+	otel    otel.Otel
+}
+
+func New(service service.Relay, otel otel.Otel) Handler {
+	return Handler{
+		service: service,
+		otel:    otel,
+	}
+}
+
+func (handler *Handler) Router(router chi.Router) {
+	router.Route("/outbox", func(routerGroup chi.Router) {
+		routerGroup.Get("/lag", handler.GetLag)
+	})
+}
+
+// GetLag reports how far the outbox relay is behind.
+// @Summary Get outbox lag
+// @Description Count the events not yet published to Kafka and the age of the oldest one.
+// @Tags Outbox
+// @Accept json
+// @Produce json
+// @Success 200 {object} response.Data[dto.LagResponse] "Outbox lag"
+// @Failure 500 {object} response.Error
+// @Router /v1/outbox/lag [get]
+// @Security BearerAuth
+func (handler *Handler) GetLag(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetLag")
+	defer scope.End()
+
+	lag, err := handler.service.Lag(ctx)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get outbox lag")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Outbox lag retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, lag)
+}
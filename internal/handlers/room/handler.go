@@ -9,6 +9,8 @@ import (
 	"oil/shared"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
+	"oil/shared/etag"
+	"oil/shared/failure"
 	"oil/shared/validator"
 	"oil/transport/http/response"
 
@@ -33,9 +35,13 @@ func (handler *Handler) Router(router chi.Router) {
 	router.Route("/rooms", func(routerGroup chi.Router) {
 		routerGroup.Post("/", handler.CreateRoom)
 		routerGroup.Get("/", handler.GetRooms)
+		routerGroup.Get("/available", handler.GetAvailableRooms)
 		routerGroup.Get("/{id}", handler.GetRoomByID)
+		routerGroup.Get("/{id}/availability", handler.GetRoomAvailability)
+		routerGroup.Get("/{id}/calendar.ics", handler.GetRoomCalendar)
 		routerGroup.Patch("/{id}", handler.UpdateRoom)
 		routerGroup.Delete("/{id}", handler.DeleteRoom)
+		routerGroup.Post("/{id}/restore", handler.RestoreRoom)
 	})
 }
 
@@ -121,6 +127,8 @@ func (handler *Handler) CreateRoom(writer http.ResponseWriter, request *http.Req
 // @Accept json
 // @Produce json
 // @Param pagination query gDto.QueryParams false "Pagination parameters"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param filter[field][op] query string false "Filter on a field with eq, not_eq, like, in, less, less_eq, greater, greater_eq, is_null or is_not_null"
 // @Param name query string false "Filter by name"
 // @Param location query string false "Filter by location"
 // @Param active query boolean false "Filter by active status"
@@ -180,6 +188,75 @@ func (handler *Handler) GetRooms(w http.ResponseWriter, r *http.Request) {
 	response.WithJSON(w, http.StatusOK, rooms)
 }
 
+// GetAvailableRooms retrieves active rooms that are free within a time window.
+// @Summary Search available rooms
+// @Description Retrieve active rooms without an overlapping booking in the requested time window.
+// @Tags Room
+// @Accept json
+// @Produce json
+// @Param pagination query gDto.QueryParams false "Pagination parameters"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param filter[field][op] query string false "Filter on a field with eq, not_eq, like, in, less, less_eq, greater, greater_eq, is_null or is_not_null"
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param start query string true "Window start (HH:MM)"
+// @Param end query string true "Window end (HH:MM)"
+// @Param min_capacity query integer false "Minimum room capacity"
+// @Param location query string false "Filter by location"
+// @Success 200 {object} response.Data[dto.GetRoomsResponse] "List of available rooms"
+// @Failure 400 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/rooms/available [get]
+func (handler *Handler) GetAvailableRooms(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetAvailableRooms")
+	defer scope.End()
+
+	queryParams := gDto.QueryParams{}
+	queryParams.FromRequest(r, true)
+
+	query := r.URL.Query()
+
+	req := dto.AvailableRoomsRequest{
+		Date:     query.Get("date"),
+		Start:    query.Get("start"),
+		End:      query.Get("end"),
+		Location: query.Get(model.FieldLocation),
+	}
+
+	if minCapacity := query.Get("min_capacity"); minCapacity != "" {
+		capacity, err := shared.ConvertStringToInt(minCapacity)
+		if err != nil {
+			response.WithError(w, failure.BadRequestFromString("min_capacity must be a number"))
+
+			return
+		}
+
+		req.MinCapacity = capacity
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	rooms, err := handler.service.GetAvailableRooms(ctx, queryParams, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get available rooms")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Available rooms retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, rooms)
+}
+
 // GetRoomByID retrieves a room by its ID.
 // @Summary Get a room by ID
 // @Description Retrieve a room by its unique identifier.
@@ -187,7 +264,10 @@ func (handler *Handler) GetRooms(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Room ID"
+// @Param If-None-Match header string false "ETag of a cached copy of the room"
 // @Success 200 {object} response.Data[dto.RoomResponse] "Room details"
+// @Header 200 {string} ETag "Version of the room"
+// @Success 304 "Cached copy is current"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
 // @Failure 500 {object} response.Error
@@ -208,11 +288,117 @@ func (handler *Handler) GetRoomByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag.Set(w, room.Version)
+
+	if etag.NotModified(r, room.Version) {
+		response.WithNotModified(w)
+
+		return
+	}
+
 	scope.AddEvent("Room retrieved successfully")
 
 	response.WithJSON(w, http.StatusOK, room)
 }
 
+// GetRoomAvailability retrieves the free time slots of a room.
+// @Summary Get room availability
+// @Description Retrieve the free time slots of a room within opening hours for a date or date range.
+// @Tags Room
+// @Accept json
+// @Produce json
+// @Param id path string true "Room ID"
+// @Param date query string false "Single date (YYYY-MM-DD), alias of start_date"
+// @Param start_date query string false "Range start date (YYYY-MM-DD)"
+// @Param end_date query string false "Range end date (YYYY-MM-DD), defaults to start_date"
+// @Param min_duration query integer false "Minimum slot duration in minutes"
+// @Success 200 {object} response.Data[dto.AvailabilityResponse] "Room availability"
+// @Failure 400 {object} response.Error
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/rooms/{id}/availability [get]
+func (handler *Handler) GetRoomAvailability(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetRoomAvailability")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+	query := r.URL.Query()
+
+	req := dto.AvailabilityRequest{
+		StartDate: query.Get("start_date"),
+		EndDate:   query.Get("end_date"),
+	}
+
+	if date := query.Get("date"); date != "" && req.StartDate == "" {
+		req.StartDate = date
+	}
+
+	if minDuration := query.Get("min_duration"); minDuration != "" {
+		duration, err := shared.ConvertStringToInt(minDuration)
+		if err != nil {
+			response.WithError(w, failure.BadRequestFromString("min_duration must be a number of minutes"))
+
+			return
+		}
+
+		req.MinDuration = duration
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to validate request")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	availability, err := handler.service.GetAvailability(ctx, id, req)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get room availability")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Room availability retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, availability)
+}
+
+// GetRoomCalendar exports the bookings of a room as an iCalendar feed.
+// @Summary Get room calendar feed
+// @Description Export recent and upcoming bookings of a room as an iCalendar (RFC 5545) feed for calendar subscriptions.
+// @Tags Room
+// @Produce text/calendar
+// @Param id path string true "Room ID"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/rooms/{id}/calendar.ics [get]
+func (handler *Handler) GetRoomCalendar(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetRoomCalendar")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	calendar, err := handler.service.GetCalendar(ctx, id)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get room calendar")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("Room calendar exported successfully")
+
+	response.WithContent(w, http.StatusOK, constant.ContentTypeCalendar, calendar)
+}
+
 // UpdateRoom updates an existing room by its ID.
 // @Summary Update a room by ID
 // @Description Update the details of an existing room.
@@ -225,9 +411,12 @@ func (handler *Handler) GetRoomByID(w http.ResponseWriter, r *http.Request) {
 // @Param capacity formData integer false "Room capacity"
 // @Param active formData boolean false "Room active status"
 // @Param image formData file false "Room image"
+// @Param If-Match header string true "ETag of the version of the room being changed, or *"
 // @Success 200 {object} response.Message "Room updated successfully"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/rooms/{id} [patch]
 // @Security BearerAuth
@@ -237,6 +426,16 @@ func (handler *Handler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
 	if err := r.ParseMultipartForm(constant.RequestMaxMemory); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to parse multipart form")
@@ -277,7 +476,7 @@ func (handler *Handler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := handler.service.Update(ctx, req, id); err != nil {
+	if err := handler.service.Update(ctx, req, id, version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to update room")
 
@@ -299,9 +498,12 @@ func (handler *Handler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Room ID"
+// @Param If-Match header string true "ETag of the version of the room being changed, or *"
 // @Success 200 {object} response.Message "Room deleted successfully"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/rooms/{id} [delete]
 // @Security BearerAuth
@@ -311,7 +513,17 @@ func (handler *Handler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
-	if err := handler.service.Delete(ctx, id); err != nil {
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.Delete(ctx, id, version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to delete room")
 
@@ -325,3 +537,37 @@ func (handler *Handler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
 
 	response.WithMessage(w, http.StatusOK, "Room deleted successfully")
 }
+
+// RestoreRoom restores a deleted room by its ID.
+// @Summary Restore a deleted room
+// @Description Restore a room that was deleted and not purged yet.
+// @Tags Room
+// @Accept json
+// @Produce json
+// @Param id path string true "Room ID"
+// @Success 200 {object} response.Message "Room restored successfully"
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/rooms/{id}/restore [post]
+// @Security BearerAuth
+func (handler *Handler) RestoreRoom(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".RestoreRoom")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	if err := handler.service.Restore(ctx, id); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to restore room")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+	scope.AddEvent("Room restored successfully by user " + user)
+
+	response.WithMessage(w, http.StatusOK, "Room restored successfully")
+}
@@ -8,6 +8,7 @@ import (
 	"oil/internal/domains/user/service"
 	"oil/shared/constant"
 	gDto "oil/shared/dto"
+	"oil/shared/etag"
 	"oil/shared/validator"
 	"oil/transport/http/response"
 
@@ -34,6 +35,9 @@ func (handler *Handler) Router(router chi.Router) {
 		routerGroup.Get("/{id}", handler.GetUserByID)
 		routerGroup.Patch("/{id}", handler.UpdateUser)
 		routerGroup.Delete("/{id}", handler.DeleteUser)
+		routerGroup.Post("/{id}/restore", handler.RestoreUser)
+		routerGroup.Get("/{id}/lockout", handler.GetUserLockout)
+		routerGroup.Post("/{id}/unlock", handler.UnlockUser)
 	})
 }
 
@@ -85,6 +89,8 @@ func (handler *Handler) CreateUser(writer http.ResponseWriter, request *http.Req
 // @Accept json
 // @Produce json
 // @Param pagination query gDto.QueryParams false "Pagination parameters"
+// @Param sort query string false "Comma-separated sort fields, descending when prefixed with -, e.g. -created_at,id"
+// @Param filter[field][op] query string false "Filter on a field with eq, not_eq, like, in, less, less_eq, greater, greater_eq, is_null or is_not_null"
 // @Param email query string false "Filter by email"
 // @Param level query string false "Filter by level"
 // @Success 200 {object} response.Data[dto.UserResponse] "List of users"
@@ -153,7 +159,10 @@ func (handler *Handler) GetUsers(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
+// @Param If-None-Match header string false "ETag of a cached copy of the user"
 // @Success 200 {object} response.Data[dto.UserResponse] "User details"
+// @Header 200 {string} ETag "Version of the user"
+// @Success 304 "Cached copy is current"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
 // @Failure 500 {object} response.Error
@@ -175,6 +184,14 @@ func (handler *Handler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag.Set(w, user.Version)
+
+	if etag.NotModified(r, user.Version) {
+		response.WithNotModified(w)
+
+		return
+	}
+
 	scope.AddEvent("User retrieved successfully")
 
 	response.WithJSON(w, http.StatusOK, user)
@@ -188,9 +205,12 @@ func (handler *Handler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "User ID"
 // @Param request body dto.UpdateUserRequest true "Update User Request"
+// @Param If-Match header string true "ETag of the version of the user being changed, or *"
 // @Success 200 {object} response.Message "User updated successfully"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/users/{id} [patch]
 // @Security BearerAuth
@@ -200,6 +220,16 @@ func (handler *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
 	req := dto.UpdateUserRequest{}
 	if err := validator.Validate(r.Body, &req); err != nil {
 		scope.TraceError(err)
@@ -210,7 +240,7 @@ func (handler *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := handler.service.Update(ctx, req, id); err != nil {
+	if err := handler.service.Update(ctx, req, id, version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to update user")
 
@@ -231,9 +261,12 @@ func (handler *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param id path string true "User ID"
+// @Param If-Match header string true "ETag of the version of the user being changed, or *"
 // @Success 200 {object} response.Message "User deleted successfully"
 // @Failure 400 {object} response.Error
 // @Failure 404 {object} response.Error
+// @Failure 412 {object} response.Error
+// @Failure 428 {object} response.Error
 // @Failure 500 {object} response.Error
 // @Router /v1/users/{id} [delete]
 // @Security BearerAuth
@@ -243,7 +276,17 @@ func (handler *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	id := chi.URLParam(r, constant.RequestParamID)
 
-	if err := handler.service.Delete(ctx, id); err != nil {
+	version, err := etag.IfMatch(r)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to check If-Match header")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	if err := handler.service.Delete(ctx, id, version); err != nil {
 		scope.TraceError(err)
 		log.Error().Err(err).Msg("failed to delete user")
 
@@ -256,3 +299,99 @@ func (handler *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 
 	response.WithMessage(w, http.StatusOK, "User deleted successfully")
 }
+
+// RestoreUser restores a deleted user by their ID.
+// @Summary Restore a deleted user
+// @Description Restore a user that was deleted and not purged yet.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Message "User restored successfully"
+// @Failure 404 {object} response.Error
+// @Failure 409 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/users/{id}/restore [post]
+// @Security BearerAuth
+func (handler *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".RestoreUser")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	if err := handler.service.Restore(ctx, id); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to restore user")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User restored successfully")
+
+	response.WithMessage(w, http.StatusOK, "User restored successfully")
+}
+
+// GetUserLockout retrieves the failed logins of a user.
+// @Summary Get the login lockout of a user
+// @Description Retrieve the failed login attempts of a user and until when they are locked out because of them.
+// @Tags User
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.LockoutResponse "User lockout retrieved successfully"
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/users/{id}/lockout [get]
+// @Security BearerAuth
+func (handler *Handler) GetUserLockout(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".GetUserLockout")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	res, err := handler.service.Lockout(ctx, id)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to get user lockout")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User lockout retrieved successfully")
+
+	response.WithJSON(w, http.StatusOK, res)
+}
+
+// UnlockUser lifts the login lockout of a user.
+// @Summary Unlock a user
+// @Description Forget the failed login attempts of a user, who can log in again at once.
+// @Tags User
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Message "User unlocked successfully"
+// @Failure 404 {object} response.Error
+// @Failure 500 {object} response.Error
+// @Router /v1/users/{id}/unlock [post]
+// @Security BearerAuth
+func (handler *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	ctx, scope := handler.otel.NewScope(r.Context(), constant.OtelHandlerScopeName, constant.OtelHandlerScopeName+".UnlockUser")
+	defer scope.End()
+
+	id := chi.URLParam(r, constant.RequestParamID)
+
+	if err := handler.service.Unlock(ctx, id); err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Msg("failed to unlock user")
+
+		response.WithError(w, err)
+
+		return
+	}
+
+	scope.AddEvent("User unlocked successfully")
+
+	response.WithMessage(w, http.StatusOK, "User unlocked successfully")
+}
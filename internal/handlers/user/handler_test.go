@@ -0,0 +1,120 @@
+package user_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	userMocks "oil/internal/domains/user/mocks"
+	"oil/internal/domains/user/model"
+	"oil/internal/domains/user/service"
+	"oil/internal/handlers/user"
+	cacheMocks "oil/shared/cache/mocks"
+	"oil/shared/lockout"
+	lockoutMocks "oil/shared/lockout/mocks"
+	"oil/shared/timezone"
+)
+
+func TestHandler_Lockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := userMocks.NewMockUser(ctrl)
+	mockLockout := lockoutMocks.NewMockLockout(ctrl)
+
+	svc := service.New(mockRepo, &config.Config{}, cacheMocks.NewMockRedisCache(ctrl), mockLockout, mocks.NewOtel())
+	handler := user.New(svc, mocks.NewOtel())
+
+	router := chi.NewRouter()
+	handler.Router(router)
+
+	existing := model.User{ID: "user-1", Email: "test@example.com"}
+	lockedUntil := timezone.Now().Add(10 * time.Minute).Truncate(time.Second)
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		setupMock func()
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:   "get lockout",
+			method: http.MethodGet,
+			path:   "/users/user-1/lockout",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockLockout.EXPECT().
+					Status(gomock.Any(), lockout.AccountKey(existing.Email)).
+					Return(lockout.Status{FailedAttempts: 5, LockedUntil: &lockedUntil}, nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"data":{"failed_attempts":5,"locked_until":"` + lockedUntil.Format(time.RFC3339) + `","locked":true}}`,
+		},
+		{
+			name:   "get lockout of a missing user",
+			method: http.MethodGet,
+			path:   "/users/user-2/lockout",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"user not found"}`,
+		},
+		{
+			name:   "unlock",
+			method: http.MethodPost,
+			path:   "/users/user-1/unlock",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockLockout.EXPECT().Reset(gomock.Any(), lockout.AccountKey(existing.Email)).Return(nil)
+			},
+			wantCode: http.StatusOK,
+			wantBody: `{"message":"User unlocked successfully"}`,
+		},
+		{
+			name:   "unlock a missing user",
+			method: http.MethodPost,
+			path:   "/users/user-2/unlock",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(model.User{}, nil)
+			},
+			wantCode: http.StatusNotFound,
+			wantBody: `{"error":"user not found"}`,
+		},
+		{
+			name:   "unlock failing",
+			method: http.MethodPost,
+			path:   "/users/user-1/unlock",
+			setupMock: func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(existing, nil)
+				mockLockout.EXPECT().Reset(gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
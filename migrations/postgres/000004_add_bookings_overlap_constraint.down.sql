@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS excl_room_bookings_overlap;
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS chk_room_bookings_time_range;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE room_bookings
+  ADD CONSTRAINT chk_room_bookings_time_range
+    CHECK (end_time > start_time);
+
+ALTER TABLE room_bookings
+  ADD CONSTRAINT excl_room_bookings_overlap
+    EXCLUDE USING gist (
+      room_id WITH =,
+      tsrange(booking_date + start_time, booking_date + end_time) WITH &&
+    )
+    WHERE (status IN ('pending', 'confirmed'));
+
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_room_bookings_series_id;
+
+ALTER TABLE room_bookings
+  DROP CONSTRAINT IF EXISTS fk_room_bookings_series,
+  DROP COLUMN IF EXISTS series_id;
+
+DROP TABLE IF EXISTS booking_series;
+
+COMMIT;
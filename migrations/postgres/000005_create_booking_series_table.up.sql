@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS booking_series (
+  id VARCHAR(36) PRIMARY KEY,
+
+  room_id VARCHAR(36) NOT NULL,
+
+  frequency VARCHAR(10) NOT NULL,
+  repeat_interval INT NOT NULL DEFAULT 1,
+  weekdays VARCHAR(20),
+  until_date DATE,
+  occurrence_count INT,
+
+  start_date DATE NOT NULL,
+  start_time TIME NOT NULL,
+  end_time TIME NOT NULL,
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  modified_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  created_by VARCHAR(36) NOT NULL,
+  modified_by VARCHAR(36) NOT NULL,
+
+  CONSTRAINT fk_booking_series_room
+    FOREIGN KEY (room_id)
+    REFERENCES rooms(id)
+    ON DELETE CASCADE
+);
+
+ALTER TABLE room_bookings
+  ADD COLUMN series_id VARCHAR(36) DEFAULT NULL,
+  ADD CONSTRAINT fk_room_bookings_series
+    FOREIGN KEY (series_id)
+    REFERENCES booking_series(id)
+    ON DELETE CASCADE;
+
+CREATE INDEX idx_room_bookings_series_id ON room_bookings(series_id);
+
+COMMIT;
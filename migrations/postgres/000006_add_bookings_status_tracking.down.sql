@@ -0,0 +1,11 @@
+BEGIN;
+
+ALTER TABLE room_bookings
+  DROP CONSTRAINT IF EXISTS chk_room_bookings_status;
+
+ALTER TABLE room_bookings
+  DROP COLUMN IF EXISTS status_changed_at,
+  DROP COLUMN IF EXISTS status_changed_by,
+  DROP COLUMN IF EXISTS status_reason;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE room_bookings
+  ADD COLUMN status_reason TEXT,
+  ADD COLUMN status_changed_by VARCHAR(36),
+  ADD COLUMN status_changed_at TIMESTAMPTZ;
+
+ALTER TABLE room_bookings
+  ADD CONSTRAINT chk_room_bookings_status
+    CHECK (status IN ('pending', 'confirmed', 'rejected', 'cancelled', 'completed', 'no_show'));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE users
+  ADD COLUMN calendar_token VARCHAR(64) UNIQUE;
+
+COMMIT;
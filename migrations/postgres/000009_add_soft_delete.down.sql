@@ -0,0 +1,42 @@
+BEGIN;
+
+-- Without deleted_at, soft-deleted rows would come back as live rows and
+-- deleted bookings could break the overlap constraint. Rather than destroying
+-- or resurrecting them, refuse to roll back until they are restored or purged.
+DO $$
+BEGIN
+  IF EXISTS (SELECT 1 FROM room_bookings WHERE deleted_at IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM rooms WHERE deleted_at IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM users WHERE deleted_at IS NOT NULL) THEN
+    RAISE EXCEPTION 'cannot remove soft delete while soft-deleted rows exist: restore or purge them first';
+  END IF;
+END
+$$;
+
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS excl_room_bookings_overlap;
+
+ALTER TABLE room_bookings
+  ADD CONSTRAINT excl_room_bookings_overlap
+    EXCLUDE USING gist (
+      room_id WITH =,
+      tsrange(booking_date + start_time, booking_date + end_time) WITH &&
+    )
+    WHERE (status IN ('pending', 'confirmed'));
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_room_bookings_deleted_at;
+DROP INDEX IF EXISTS idx_rooms_deleted_at;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS deleted_by,
+  DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE room_bookings
+  DROP COLUMN IF EXISTS deleted_by,
+  DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE rooms
+  DROP COLUMN IF EXISTS deleted_by,
+  DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,30 @@
+BEGIN;
+
+ALTER TABLE rooms
+  ADD COLUMN deleted_at TIMESTAMPTZ,
+  ADD COLUMN deleted_by VARCHAR(36);
+
+ALTER TABLE room_bookings
+  ADD COLUMN deleted_at TIMESTAMPTZ,
+  ADD COLUMN deleted_by VARCHAR(36);
+
+ALTER TABLE users
+  ADD COLUMN deleted_at TIMESTAMP,
+  ADD COLUMN deleted_by VARCHAR(36);
+
+CREATE INDEX idx_rooms_deleted_at ON rooms(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_room_bookings_deleted_at ON room_bookings(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_users_deleted_at ON users(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Deleted bookings no longer hold their time slot.
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS excl_room_bookings_overlap;
+
+ALTER TABLE room_bookings
+  ADD CONSTRAINT excl_room_bookings_overlap
+    EXCLUDE USING gist (
+      room_id WITH =,
+      tsrange(booking_date + start_time, booking_date + end_time) WITH &&
+    )
+    WHERE (status IN ('pending', 'confirmed') AND deleted_at IS NULL);
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id VARCHAR(36) PRIMARY KEY,
+
+  entity VARCHAR(100) NOT NULL,
+  entity_id VARCHAR(255) NOT NULL,
+  action VARCHAR(20) NOT NULL,
+  actor VARCHAR(36),
+  request_id VARCHAR(255),
+  changes JSONB NOT NULL DEFAULT '{}',
+
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_logs_entity ON audit_logs(entity, entity_id, created_at);
+CREATE INDEX idx_audit_logs_actor ON audit_logs(actor, created_at);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE rooms
+  DROP COLUMN IF EXISTS version;
+
+ALTER TABLE room_bookings
+  DROP COLUMN IF EXISTS version;
+
+ALTER TABLE users
+  DROP COLUMN IF EXISTS version;
+
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+
+ALTER TABLE rooms
+  ADD COLUMN version INT NOT NULL DEFAULT 0;
+
+ALTER TABLE room_bookings
+  ADD COLUMN version INT NOT NULL DEFAULT 0;
+
+ALTER TABLE users
+  ADD COLUMN version INT NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -16,6 +16,9 @@ type Permission struct {
 	Path        string   `json:"path"`
 	Method      string   `json:"method"`
 	Skip        bool     `json:"skip"`
+	// MFAExempt lets users whose role requires multi-factor authentication
+	// call the endpoint without it, so that they can enrol.
+	MFAExempt bool `json:"mfa_exempt,omitempty"`
 }
 
 type PermissionData struct {
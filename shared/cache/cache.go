@@ -21,11 +21,25 @@ const (
 
 type RedisCache interface {
 	Save(ctx context.Context, key string, value any, duration int) (err error)
+	SaveIfAbsent(ctx context.Context, key string, value any, duration int) (saved bool, err error)
+	CompareAndSave(ctx context.Context, key, old string, value any, duration int) (saved bool, err error)
 	Get(ctx context.Context, key string, value any) (err error)
 	Delete(ctx context.Context, key string) error
 	Clear(ctx context.Context, prefix string) error
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Incr(ctx context.Context, key string, duration int) (int64, error)
 }
 
+// compareAndSave sets KEYS[1] to ARGV[2] for ARGV[3] seconds if it still holds
+// ARGV[1]. Redis runs a script without interleaving other commands.
+var compareAndSave = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "EX", ARGV[3])
+	return 1
+end
+return 0
+`)
+
 type redisCache struct {
 	client *redis.Client
 	otel   otel.Otel
@@ -63,6 +77,51 @@ func (cache *redisCache) Clear(ctx context.Context, prefix string) (err error) {
 	return nil
 }
 
+// Keys implements RedisCache.
+func (cache *redisCache) Keys(ctx context.Context, pattern string) (keys []string, err error) {
+	ctx, scope := cache.otel.NewScope(ctx, otelScopeName, otelScopeName+".Keys")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	scope.SetAttribute(otelCacheKeyAttribute, pattern)
+
+	iter := cache.client.Scan(ctx, 0, pattern, 0).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+
+	if err = iter.Err(); err != nil {
+		log.Error().Err(err).Str("pattern", pattern).Str("RedisCache", "Keys").Msg("failed to scan cache")
+
+		return nil, fmt.Errorf("failed to scan cache keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Incr implements RedisCache. It increments the counter at key and has it
+// expire duration seconds later in one transaction, so that concurrent callers
+// each get their own value.
+func (cache *redisCache) Incr(ctx context.Context, key string, duration int) (value int64, err error) {
+	ctx, scope := cache.otel.NewScope(ctx, otelScopeName, otelScopeName+".Incr")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	scope.SetAttribute(otelCacheKeyAttribute, key)
+
+	pipe := cache.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, time.Second*time.Duration(duration))
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "Incr").Msg("failed to incr cache")
+
+		return 0, fmt.Errorf("failed to increment cache value: %w", err)
+	}
+
+	return incr.Val(), nil
+}
+
 // Delete implements RedisCache.
 func (cache *redisCache) Delete(ctx context.Context, key string) (err error) {
 	ctx, scope := cache.otel.NewScope(ctx, otelScopeName, otelScopeName+".Delete")
@@ -89,25 +148,23 @@ func (cache *redisCache) Get(ctx context.Context, key string, value any) (err er
 	scope.SetAttribute(otelCacheKeyAttribute, key)
 
 	cacheValue, err := cache.client.Get(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to get cache value: %w", err)
+	}
 
-	if err == nil {
-		switch v := value.(type) {
-		case *string:
-			*v = cacheValue
-		default:
-			err = json.Unmarshal([]byte(cacheValue), value)
+	if v, ok := value.(*string); ok {
+		*v = cacheValue
 
-			if err != nil {
-				log.Error().Err(err).Str("RedisCache", "Get").Msg("failed to unmarshal cache")
+		return nil
+	}
 
-				return fmt.Errorf("failed to unmarshal cache value: %w", err)
-			}
+	if err = json.Unmarshal([]byte(cacheValue), value); err != nil {
+		log.Error().Err(err).Str("RedisCache", "Get").Msg("failed to unmarshal cache")
 
-			return nil
-		}
+		return fmt.Errorf("failed to unmarshal cache value: %w", err)
 	}
 
-	return fmt.Errorf("failed to get cache value: %w", err)
+	return nil
 }
 
 // Save implements RedisCache.
@@ -118,19 +175,12 @@ func (cache *redisCache) Save(ctx context.Context, key string, value any, durati
 
 	scope.SetAttribute(otelCacheKeyAttribute, key)
 
-	var strValue []byte
-	switch v := value.(type) {
-	case string:
-		strValue = []byte(v)
-	default:
-		strValue, err = json.Marshal(v)
-
-		if err != nil {
-			scope.TraceError(err)
-			log.Error().Err(err).Str("key", key).Str("RedisCache", "Save").Msg("failed to marshal cache")
+	strValue, err := encode(value)
+	if err != nil {
+		scope.TraceError(err)
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "Save").Msg("failed to marshal cache")
 
-			return fmt.Errorf("failed to marshal cache value: %w", err)
-		}
+		return err
 	}
 
 	err = cache.client.Set(ctx, key, strValue, time.Second*time.Duration(duration)).Err()
@@ -147,3 +197,71 @@ func (cache *redisCache) Save(ctx context.Context, key string, value any, durati
 
 	return nil
 }
+
+// SaveIfAbsent implements RedisCache. It saves value only when key is not set,
+// reporting whether it did, so that of concurrent callers only one saves.
+func (cache *redisCache) SaveIfAbsent(ctx context.Context, key string, value any, duration int) (saved bool, err error) {
+	ctx, scope := cache.otel.NewScope(ctx, otelScopeName, otelScopeName+".SaveIfAbsent")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	scope.SetAttribute(otelCacheKeyAttribute, key)
+
+	strValue, err := encode(value)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "SaveIfAbsent").Msg("failed to marshal cache")
+
+		return false, err
+	}
+
+	saved, err = cache.client.SetNX(ctx, key, strValue, time.Second*time.Duration(duration)).Result()
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "SaveIfAbsent").Msg("failed to set cache")
+
+		return false, fmt.Errorf("failed to set cache value: %w", err)
+	}
+
+	return saved, nil
+}
+
+// CompareAndSave implements RedisCache. It saves value only when key still
+// holds old in its stored form, reporting whether it did, so that of concurrent
+// callers replacing the same value only one saves.
+func (cache *redisCache) CompareAndSave(ctx context.Context, key, old string, value any, duration int) (saved bool, err error) {
+	ctx, scope := cache.otel.NewScope(ctx, otelScopeName, otelScopeName+".CompareAndSave")
+	defer scope.End()
+	defer scope.TraceIfError(err)
+
+	scope.SetAttribute(otelCacheKeyAttribute, key)
+
+	strValue, err := encode(value)
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "CompareAndSave").Msg("failed to marshal cache")
+
+		return false, err
+	}
+
+	swapped, err := compareAndSave.Run(ctx, cache.client, []string{key}, old, strValue, duration).Int()
+	if err != nil {
+		log.Error().Err(err).Str("key", key).Str("RedisCache", "CompareAndSave").Msg("failed to set cache")
+
+		return false, fmt.Errorf("failed to set cache value: %w", err)
+	}
+
+	return swapped == 1, nil
+}
+
+// encode returns the stored form of a cache value: strings as they are, other
+// values as JSON.
+func encode(value any) ([]byte, error) {
+	if v, ok := value.(string); ok {
+		return []byte(v), nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cache value: %w", err)
+	}
+
+	return data, nil
+}
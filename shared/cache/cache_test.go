@@ -0,0 +1,159 @@
+package cache_test
+
+import (
+	"context"
+	"oil/infras/otel/mocks"
+	"oil/shared/cache"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCache(t *testing.T) cache.RedisCache {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	t.Cleanup(func() { client.Close() })
+
+	return cache.NewRedisCache(client, mocks.NewOtel())
+}
+
+func TestRedisCache_Keys(t *testing.T) {
+	ctx := context.Background()
+	redisCache := newCache(t)
+
+	for _, key := range []string{"oil:cache:jwt:user:user-1:a", "oil:cache:jwt:user:user-1:b", "oil:cache:jwt:user:user-2:a"} {
+		assert.NoError(t, redisCache.Save(ctx, key, "session", 60))
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "keys of a user", pattern: "oil:cache:jwt:user:user-1:*", want: []string{"oil:cache:jwt:user:user-1:a", "oil:cache:jwt:user:user-1:b"}},
+		{name: "keys of every user", pattern: "oil:cache:jwt:user:*:a", want: []string{"oil:cache:jwt:user:user-1:a", "oil:cache:jwt:user:user-2:a"}},
+		{name: "no match", pattern: "oil:cache:jwt:user:user-3:*", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := redisCache.Keys(ctx, tt.pattern)
+
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, keys)
+		})
+	}
+}
+
+func TestRedisCache_SaveGet(t *testing.T) {
+	ctx := context.Background()
+	redisCache := newCache(t)
+
+	type session struct {
+		ID string `json:"id"`
+	}
+
+	assert.NoError(t, redisCache.Save(ctx, "session:1", session{ID: "session-1"}, 60))
+	assert.NoError(t, redisCache.Save(ctx, "session:2", session{ID: "session-2"}, 60))
+
+	var value session
+	assert.NoError(t, redisCache.Get(ctx, "session:1", &value))
+	assert.Equal(t, "session-1", value.ID)
+
+	assert.NoError(t, redisCache.Delete(ctx, "session:1"))
+	assert.ErrorIs(t, redisCache.Get(ctx, "session:1", &value), cache.Nil)
+
+	assert.NoError(t, redisCache.Clear(ctx, "session:*"))
+	assert.ErrorIs(t, redisCache.Get(ctx, "session:2", &value), cache.Nil)
+}
+
+func TestRedisCache_SaveIfAbsent(t *testing.T) {
+	ctx := context.Background()
+	redisCache := newCache(t)
+
+	saved, err := redisCache.SaveIfAbsent(ctx, "token:1", map[string]string{"by": "first"}, 60)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	saved, err = redisCache.SaveIfAbsent(ctx, "token:1", map[string]string{"by": "second"}, 60)
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	var value map[string]string
+	assert.NoError(t, redisCache.Get(ctx, "token:1", &value))
+	assert.Equal(t, "first", value["by"])
+}
+
+func TestRedisCache_Incr(t *testing.T) {
+	ctx := context.Background()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	t.Cleanup(func() { client.Close() })
+
+	redisCache := cache.NewRedisCache(client, mocks.NewOtel())
+
+	for want := int64(1); want <= 3; want++ {
+		value, err := redisCache.Incr(ctx, "lockout:1", 60)
+		assert.NoError(t, err)
+		assert.Equal(t, want, value)
+	}
+
+	var value int
+	assert.NoError(t, redisCache.Get(ctx, "lockout:1", &value))
+	assert.Equal(t, 3, value)
+
+	// Every increment starts the window again.
+	server.FastForward(30 * time.Second)
+
+	_, err := redisCache.Incr(ctx, "lockout:1", 60)
+	assert.NoError(t, err)
+	assert.Equal(t, 60*time.Second, server.TTL("lockout:1"))
+
+	server.FastForward(61 * time.Second)
+
+	assert.ErrorIs(t, redisCache.Get(ctx, "lockout:1", &value), cache.Nil)
+}
+
+func TestRedisCache_CompareAndSave(t *testing.T) {
+	ctx := context.Background()
+	redisCache := newCache(t)
+
+	saved, err := redisCache.CompareAndSave(ctx, "session:1", `{"by":"first"}`, map[string]string{"by": "second"}, 60)
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	assert.NoError(t, redisCache.Save(ctx, "session:1", map[string]string{"by": "first"}, 60))
+
+	saved, err = redisCache.CompareAndSave(ctx, "session:1", `{"by":"first"}`, map[string]string{"by": "second"}, 60)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+
+	saved, err = redisCache.CompareAndSave(ctx, "session:1", `{"by":"first"}`, map[string]string{"by": "third"}, 60)
+	assert.NoError(t, err)
+	assert.False(t, saved)
+
+	var value map[string]string
+	assert.NoError(t, redisCache.Get(ctx, "session:1", &value))
+	assert.Equal(t, "second", value["by"])
+}
+
+func TestRedisCache_GetString(t *testing.T) {
+	ctx := context.Background()
+	redisCache := newCache(t)
+
+	assert.NoError(t, redisCache.Save(ctx, "token:1", "revoked", 60))
+
+	var value string
+	assert.NoError(t, redisCache.Get(ctx, "token:1", &value))
+	assert.Equal(t, "revoked", value)
+
+	assert.ErrorIs(t, redisCache.Get(ctx, "token:2", &value), cache.Nil)
+}
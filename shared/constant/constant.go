@@ -16,6 +16,9 @@ const (
 	ContextKeyUserEmail contextKey = "user_email"
 	ContextKeyUserRole  contextKey = "user_role"
 	ContextKeyTokenID   contextKey = "token_id"
+	ContextKeySessionID contextKey = "session_id"
+	ContextKeyUserAgent contextKey = "user_agent"
+	ContextKeyClientIP  contextKey = "client_ip"
 )
 
 const (
@@ -25,15 +28,21 @@ const (
 )
 
 const (
-	RequestParamPage    = "page"
-	RequestParamLimit   = "limit"
-	RequestParamSortBy  = "sort_by"
-	RequestParamSortDir = "sort_dir"
+	RequestParamPage         = "page"
+	RequestParamLimit        = "limit"
+	RequestParamSortBy       = "sort_by"
+	RequestParamSortDir      = "sort_dir"
+	RequestParamSort         = "sort"
+	RequestParamPagination   = "pagination"
+	RequestParamCursor       = "cursor"
+	RequestParamIncludeTotal = "include_total"
 )
 
 const (
-	RequestParamID   = "id"
-	RequestMaxMemory = 10 << 20 // 10 MB
+	RequestParamID    = "id"
+	RequestParamScope = "scope"
+	RequestParamToken = "token"
+	RequestMaxMemory  = 10 << 20 // 10 MB
 )
 
 const (
@@ -48,11 +57,16 @@ const (
 	FieldCreatedBy  = "created_by"
 	FieldModifiedAt = "modified_at"
 	FieldModifiedBy = "modified_by"
+	FieldDeletedAt  = "deleted_at"
+	FieldDeletedBy  = "deleted_by"
+	FieldVersion    = "version"
 )
 
 const (
-	PqErrorCodeUniqueViolation = "23505"
-	PqErrorCodeFkViolation     = "23503"
+	PqErrorCodeUniqueViolation    = "23505"
+	PqErrorCodeFkViolation        = "23503"
+	PqErrorCodeCheckViolation     = "23514"
+	PqErrorCodeExclusionViolation = "23P01"
 )
 
 const (
@@ -85,12 +99,16 @@ const (
 	RequestHeaderForwardedFor       = "X-Forwarded-For"
 	RequestHeaderRealIP             = "X-Real-IP"
 	RequestHeaderAPIKey             = "X-API-Key"
+	RequestHeaderIfMatch            = "If-Match"
+	RequestHeaderIfNoneMatch        = "If-None-Match"
+	ResponseHeaderETag              = "ETag"
 )
 
 const (
 	ContentTypeJSON              = "application/json"
 	ContentTypeFormURLEncoded    = "application/x-www-form-urlencoded"
 	ContentTypeMultipartFormData = "multipart/form-data"
+	ContentTypeCalendar          = "text/calendar; charset=utf-8"
 	FormFile                     = "file"
 )
 
@@ -1,11 +1,14 @@
 package dto_test
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
 	"oil/shared/constant"
 	"oil/shared/dto"
 	"oil/shared/model"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -222,3 +225,141 @@ func TestSortDirectionConstants(t *testing.T) {
 		t.Errorf("expected SortDirDesc to be 'DESC', got %s", dto.SortDirDesc)
 	}
 }
+
+func TestFilter_GetWhereClause_Subquery(t *testing.T) {
+	filter := dto.Filter{
+		Operator: dto.FilterNotExists,
+		Table:    "room_bookings",
+		Value: dto.FilterGroup{
+			Operator: dto.FilterGroupOperatorAnd,
+			Filters: []any{
+				dto.Filter{Operator: dto.FilterPlainQuery, Value: "room_bookings.room_id = rooms.id"},
+				dto.Filter{Field: "start_time", Operator: dto.FilterOperatorLess, Value: "11:00", Table: "room_bookings"},
+				dto.Filter{Field: "end_time", Operator: dto.FilterOperatorGreater, Value: "10:00", Table: "room_bookings"},
+			},
+		},
+	}
+
+	where, args := filter.GetWhereClause()
+
+	expected := "NOT EXISTS (SELECT 1 FROM room_bookings WHERE ((room_bookings.room_id = rooms.id) AND " +
+		"room_bookings.start_time < :start_time AND room_bookings.end_time > :end_time))"
+	if where != expected {
+		t.Errorf("expected where clause %q, got %q", expected, where)
+	}
+
+	if args["start_time"] != "11:00" || args["end_time"] != "10:00" {
+		t.Errorf("expected subquery args to be propagated, got %v", args)
+	}
+}
+
+func TestQueryParams_FromRequest_Cursor(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/test?pagination=CURSOR&cursor=abc&include_total=true", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	queryParams := &dto.QueryParams{}
+	queryParams.FromRequest(req, true)
+
+	if queryParams.Pagination != dto.PaginationCursor {
+		t.Errorf("expected Pagination to be %s, got %s", dto.PaginationCursor, queryParams.Pagination)
+	}
+	if queryParams.Cursor != "abc" {
+		t.Errorf("expected Cursor to be 'abc', got %s", queryParams.Cursor)
+	}
+	if !queryParams.IncludeTotal {
+		t.Error("expected IncludeTotal to be true")
+	}
+	if !queryParams.UseCursor() {
+		t.Error("expected cursor pagination")
+	}
+
+	offset := &dto.QueryParams{Page: 2, Limit: 10}
+	if offset.UseCursor() {
+		t.Error("expected offset pagination without cursor parameters")
+	}
+}
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	cursor := dto.Cursor{
+		SortBy:  "created_at",
+		SortDir: dto.SortDirDesc,
+		Value:   "2025-01-06T10:00:00Z",
+		ID:      "booking-id",
+		Before:  true,
+	}
+
+	encoded, err := cursor.Encode()
+	if err != nil {
+		t.Fatalf("failed to encode cursor: %v", err)
+	}
+
+	decoded, err := dto.DecodeCursor(encoded)
+	if err != nil {
+		t.Fatalf("failed to decode cursor: %v", err)
+	}
+
+	if decoded != cursor {
+		t.Errorf("expected cursor %+v, got %+v", cursor, decoded)
+	}
+
+	// Numbers keep their exact representation.
+	cursor.Value = 9007199254740993
+
+	encoded, _ = cursor.Encode()
+	decoded, _ = dto.DecodeCursor(encoded)
+
+	if decoded.Value != json.Number("9007199254740993") {
+		t.Errorf("expected numeric value to be preserved, got %v", decoded.Value)
+	}
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, raw := range []string{"not base64!", "bm90IGpzb24", "eyJzIjoiIiwiZCI6IkFTQyIsImlkIjoiMSJ9", "eyJzIjoibmFtZSIsImQiOiJVUCIsImlkIjoiMSJ9"} {
+		if _, err := dto.DecodeCursor(raw); !errors.Is(err, dto.ErrInvalidQuery) {
+			t.Errorf("expected invalid pagination error for %q, got %v", raw, err)
+		}
+	}
+}
+
+func TestQueryParams_FromRequest_SortAndFilters(t *testing.T) {
+	req, err := http.NewRequest("GET", "http://example.com/test?sort=-booking_date,%2Bstart_time,,&"+
+		"filter[status][in]=pending,confirmed&filter[room_id]=room-1&filter[guest_name][like]=ann&filters=ignored", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+
+	queryParams := &dto.QueryParams{SortBy: "name", SortDir: dto.SortDirDesc}
+	queryParams.FromRequest(req, false)
+
+	expectedSorts := []dto.Sort{
+		{Field: "booking_date", Dir: dto.SortDirDesc},
+		{Field: "start_time", Dir: dto.SortDirAsc},
+	}
+	if !reflect.DeepEqual(queryParams.Sorts(), expectedSorts) {
+		t.Errorf("expected sorts %+v, got %+v", expectedSorts, queryParams.Sorts())
+	}
+
+	expectedFilters := []dto.QueryFilter{
+		{Field: "guest_name", Operator: dto.FilterOperatorLike, Value: "ann"},
+		{Field: "room_id", Operator: dto.FilterOperatorEq, Value: "room-1"},
+		{Field: "status", Operator: dto.FilterOperatorIn, Value: "pending,confirmed"},
+	}
+	if !reflect.DeepEqual(queryParams.Filters, expectedFilters) {
+		t.Errorf("expected filters %+v, got %+v", expectedFilters, queryParams.Filters)
+	}
+}
+
+func TestQueryParams_Sorts_Legacy(t *testing.T) {
+	queryParams := &dto.QueryParams{SortBy: "name"}
+
+	expected := []dto.Sort{{Field: "name", Dir: dto.SortDirAsc}}
+	if !reflect.DeepEqual(queryParams.Sorts(), expected) {
+		t.Errorf("expected sorts %+v, got %+v", expected, queryParams.Sorts())
+	}
+
+	if sorts := (&dto.QueryParams{}).Sorts(); sorts != nil {
+		t.Errorf("expected no sorts, got %+v", sorts)
+	}
+}
@@ -14,9 +14,13 @@ const (
 	FilterOperatorNotEq     = "not_eq"
 	FilterOperatorLessEq    = "less_eq"
 	FilterOperatorGreaterEq = "greater_eq"
+	FilterOperatorLess      = "less"
+	FilterOperatorGreater   = "greater"
 	FilterPlainQuery        = "plan"
 	FilterIsNotNull         = "is_not_null"
 	FilterIsNull            = "is_null"
+	FilterExists            = "exists"
+	FilterNotExists         = "not_exists"
 )
 
 const (
@@ -28,7 +32,7 @@ type Filter struct {
 	ArgName  string
 	Field    string
 	Value    any
-	Operator string `validate:"required,oneof=eq like in not_eq less_eq greater_eq"`
+	Operator string `validate:"required,oneof=eq like in not_eq less_eq greater_eq less greater"`
 	Table    string
 }
 
@@ -84,6 +88,14 @@ func (f *Filter) GetWhereClause() (string, map[string]any) {
 		args[argName] = f.Value
 
 		return fmt.Sprintf("%s >= :%s", column, argName), args
+	case FilterOperatorLess:
+		args[argName] = f.Value
+
+		return fmt.Sprintf("%s < :%s", column, argName), args
+	case FilterOperatorGreater:
+		args[argName] = f.Value
+
+		return fmt.Sprintf("%s > :%s", column, argName), args
 	case FilterPlainQuery:
 		query, _ := f.Value.(string)
 
@@ -92,14 +104,38 @@ func (f *Filter) GetWhereClause() (string, map[string]any) {
 		return column + " IS NOT NULL", args
 	case FilterIsNull:
 		return column + " IS NULL", args
+	case FilterExists, FilterNotExists:
+		// Value holds the FilterGroup applied to the subquery on Table; correlate it with the
+		// outer query using a FilterPlainQuery inside the group.
+		group, _ := f.Value.(FilterGroup)
+		where, subArgs := group.GetWhereClause()
+		maps.Copy(args, subArgs)
+
+		subquery := "SELECT 1 FROM " + f.Table
+		if where != "" {
+			subquery += " WHERE " + where
+		}
+
+		if f.Operator == FilterNotExists {
+			return fmt.Sprintf("NOT EXISTS (%s)", subquery), args
+		}
+
+		return fmt.Sprintf("EXISTS (%s)", subquery), args
 	default:
 		return "", args
 	}
 }
 
+// FilterGroup joins filters with Operator. Repositories of soft-deletable
+// models only match rows that are not deleted unless WithDeleted is set on the
+// outermost group. When Version is set on it, updates and deletes of versioned
+// models only apply to rows still at that version and fail with
+// ErrVersionMismatch when there are none.
 type FilterGroup struct {
-	Filters  []any
-	Operator string
+	Filters     []any
+	Operator    string
+	WithDeleted bool
+	Version     *int
 }
 
 func (f *FilterGroup) GetWhereClause() (string, map[string]any) {
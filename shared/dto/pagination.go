@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Cursor is the position of a row in a list ordered by SortBy and the primary
+// key. It is handed to clients as an opaque string; Before marks a cursor that
+// pages backwards from the row.
+type Cursor struct {
+	SortBy  string `json:"s"`
+	SortDir string `json:"d"`
+	Value   any    `json:"v"`
+	ID      any    `json:"id"`
+	Before  bool   `json:"b,omitempty"`
+}
+
+// Encode returns the cursor as URL-safe base64.
+func (c Cursor) Encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a cursor produced by Encode. Numbers are kept as
+// json.Number so that they reach the database without losing precision.
+func DecodeCursor(raw string) (Cursor, error) {
+	cursor := Cursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&cursor); err != nil || cursor.SortBy == "" || cursor.ID == nil {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	if cursor.SortDir != SortDirAsc && cursor.SortDir != SortDirDesc {
+		return Cursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	return cursor, nil
+}
+
+// Pagination describes the page of a list response. Totals are always set in
+// offset mode and only on request in cursor mode, where counting is the
+// expensive part of a list call; cursors are only set in cursor mode.
+type Pagination struct {
+	TotalPage  *int   `json:"total_page,omitempty"`
+	TotalData  *int   `json:"total_data,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+func (p *Pagination) SetTotal(totalData, totalPage int) {
+	p.TotalData = &totalData
+	p.TotalPage = &totalPage
+}
@@ -1,8 +1,13 @@
 package dto
 
 import (
+	"errors"
+	"maps"
 	"net/http"
+	"net/url"
 	"oil/shared/constant"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 )
@@ -10,13 +15,78 @@ import (
 const (
 	SortDirAsc  = "ASC"
 	SortDirDesc = "DESC"
+
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+
+	sortDescPrefix = "-"
+	sortAscPrefix  = "+"
 )
 
+var (
+	// ErrInvalidQuery is returned for list parameters the entity does not
+	// allow, such as an unknown sort field.
+	ErrInvalidQuery = errors.New("invalid query")
+
+	// ErrVersionMismatch is returned by writes conditioned on a version the
+	// row no longer has.
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	filterParamPattern = regexp.MustCompile(`^filter\[([^\[\]]+)\](?:\[([^\[\]]+)\])?$`)
+)
+
+// Sort orders a list by Field in Dir.
+type Sort struct {
+	Field string `json:"field"`
+	Dir   string `json:"dir"`
+}
+
+// QueryFilter is taken from a filter[field][op]=value query parameter, with
+// op defaulting to eq. It is checked against the columns of the entity by
+// Repository.WithFilters before use.
+type QueryFilter struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+// QueryParams holds list parameters. Lists are paginated by page and limit
+// unless Pagination is "cursor" or a Cursor is given; see Cursor.
 type QueryParams struct {
-	Page    int    `json:"page"     validate:"omitempty"`
-	Limit   int    `json:"limit"    validate:"omitempty"`
-	SortBy  string `json:"sort_by"  validate:"omitempty"`
-	SortDir string `json:"sort_dir" validate:"omitempty,oneof=ASC DESC"`
+	Page         int    `json:"page"          validate:"omitempty"`
+	Limit        int    `json:"limit"         validate:"omitempty"`
+	SortBy       string `json:"sort_by"       validate:"omitempty"`
+	SortDir      string `json:"sort_dir"      validate:"omitempty,oneof=ASC DESC"`
+	Pagination   string `json:"pagination"    validate:"omitempty,oneof=offset cursor"`
+	Cursor       string `json:"cursor"        validate:"omitempty"`
+	IncludeTotal bool   `json:"include_total" validate:"omitempty"`
+
+	Sort    []Sort        `json:"sort"    swaggerignore:"true"`
+	Filters []QueryFilter `json:"filters" swaggerignore:"true"`
+}
+
+// Sorts returns the requested ordering. The sort parameter takes precedence
+// over sort_by and sort_dir, which sort ascending by default.
+func (q *QueryParams) Sorts() []Sort {
+	if len(q.Sort) > 0 {
+		return q.Sort
+	}
+
+	if q.SortBy == "" {
+		return nil
+	}
+
+	dir := q.SortDir
+	if dir == "" {
+		dir = SortDirAsc
+	}
+
+	return []Sort{{Field: q.SortBy, Dir: dir}}
+}
+
+// UseCursor reports whether the list is paginated with cursors.
+func (q *QueryParams) UseCursor() bool {
+	return q.Pagination == PaginationCursor || q.Cursor != ""
 }
 
 // FromRequest populates QueryParams from the HTTP request.
@@ -51,6 +121,24 @@ func (q *QueryParams) FromRequest(r *http.Request, defaultRequest bool) {
 		q.SortDir = strings.ToUpper(sortDir)
 	}
 
+	if sort := queryParams.Get(constant.RequestParamSort); sort != "" {
+		q.Sort = ParseSort(sort)
+	}
+
+	q.Filters = append(q.Filters, parseFilters(queryParams)...)
+
+	if pagination := strings.ToLower(queryParams.Get(constant.RequestParamPagination)); pagination == PaginationOffset || pagination == PaginationCursor {
+		q.Pagination = pagination
+	}
+
+	if cursor := queryParams.Get(constant.RequestParamCursor); cursor != "" {
+		q.Cursor = cursor
+	}
+
+	if includeTotal, err := strconv.ParseBool(queryParams.Get(constant.RequestParamIncludeTotal)); err == nil {
+		q.IncludeTotal = includeTotal
+	}
+
 	if defaultRequest {
 		if q.Page == 0 {
 			q.Page = constant.DefaultValuePage
@@ -61,3 +149,50 @@ func (q *QueryParams) FromRequest(r *http.Request, defaultRequest bool) {
 		}
 	}
 }
+
+// ParseSort reads a comma-separated list of fields, each sorted descending
+// when prefixed with "-", e.g. "-booking_date,start_time".
+func ParseSort(value string) []Sort {
+	sorts := []Sort{}
+
+	for field := range strings.SplitSeq(value, ",") {
+		field = strings.TrimSpace(field)
+
+		dir := SortDirAsc
+		if strings.HasPrefix(field, sortDescPrefix) {
+			dir = SortDirDesc
+		}
+
+		field = strings.TrimPrefix(strings.TrimPrefix(field, sortDescPrefix), sortAscPrefix)
+		if field == "" {
+			continue
+		}
+
+		sorts = append(sorts, Sort{Field: field, Dir: dir})
+	}
+
+	return sorts
+}
+
+// parseFilters collects the filter[field][op] parameters in a stable order.
+func parseFilters(values url.Values) []QueryFilter {
+	filters := []QueryFilter{}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		match := filterParamPattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+
+		operator := match[2]
+		if operator == "" {
+			operator = FilterOperatorEq
+		}
+
+		for _, value := range values[key] {
+			filters = append(filters, QueryFilter{Field: match[1], Operator: operator, Value: value})
+		}
+	}
+
+	return filters
+}
@@ -0,0 +1,79 @@
+// Package etag maps row versions to entity tags, so that clients can make
+// their reads and writes conditional on the version they have.
+package etag
+
+import (
+	"net/http"
+	"oil/shared/constant"
+	"oil/shared/failure"
+	"strconv"
+	"strings"
+)
+
+const (
+	anyTag     = "*"
+	weakPrefix = "W/"
+)
+
+// Format returns the strong entity tag of a version.
+func Format(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// Set sets the ETag header of a response to the tag of a version.
+func Set(writer http.ResponseWriter, version int) {
+	writer.Header().Set(constant.ResponseHeaderETag, Format(version))
+}
+
+// IfMatch returns the version a write requires through the If-Match header,
+// or nil when any version is accepted. The header is required; it must hold a
+// single strong tag or *.
+func IfMatch(request *http.Request) (*int, error) {
+	header := strings.TrimSpace(request.Header.Get(constant.RequestHeaderIfMatch))
+	if header == constant.Empty {
+		return nil, failure.PreconditionRequired("If-Match header is required")
+	}
+
+	if header == anyTag {
+		return nil, nil
+	}
+
+	version, ok := parse(header)
+	if !ok {
+		return nil, failure.PreconditionFailed("If-Match does not match the current version")
+	}
+
+	return &version, nil
+}
+
+// NotModified reports whether the If-None-Match header of a read matches the
+// version, in which case the client's copy is current.
+func NotModified(request *http.Request, version int) bool {
+	header := strings.TrimSpace(request.Header.Get(constant.RequestHeaderIfNoneMatch))
+	if header == constant.Empty {
+		return false
+	}
+
+	if header == anyTag {
+		return true
+	}
+
+	for tag := range strings.SplitSeq(header, ",") {
+		tagged, ok := parse(strings.TrimPrefix(strings.TrimSpace(tag), weakPrefix))
+		if ok && tagged == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parse(tag string) (int, bool) {
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+
+	return version, err == nil
+}
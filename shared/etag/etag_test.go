@@ -0,0 +1,74 @@
+package etag_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"oil/shared/etag"
+	"oil/shared/failure"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		want     *int
+		wantCode int
+	}{
+		{name: "strong tag", header: `"3"`, want: intPtr(3)},
+		{name: "any version", header: "*"},
+		{name: "missing", wantCode: http.StatusPreconditionRequired},
+		{name: "weak tag never matches", header: `W/"3"`, wantCode: http.StatusPreconditionFailed},
+		{name: "unquoted", header: "3", wantCode: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.header != "" {
+				request.Header.Set("If-Match", tt.header)
+			}
+
+			version, err := etag.IfMatch(request)
+			if tt.wantCode != 0 {
+				assert.Equal(t, tt.wantCode, failure.GetCode(err))
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "current version", header: etag.Format(2), want: true},
+		{name: "weak tag in a list", header: `"1", W/"2"`, want: true},
+		{name: "any version", header: "*", want: true},
+		{name: "stale version", header: `"1"`},
+		{name: "missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				request.Header.Set("If-None-Match", tt.header)
+			}
+
+			assert.Equal(t, tt.want, etag.NotModified(request, 2))
+		})
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
@@ -0,0 +1,33 @@
+// Package event defines the envelope shared by all domain events published to
+// the message broker.
+package event
+
+import (
+	"oil/shared/timezone"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Envelope wraps an event payload with the metadata consumers need to route,
+// deduplicate and order events. Version is bumped for breaking payload changes.
+type Envelope[T any] struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Version    int       `json:"version"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Actor      string    `json:"actor"`
+	Payload    T         `json:"payload"`
+}
+
+// New creates an envelope with a fresh event ID, occurring now.
+func New[T any](eventType string, version int, actor string, payload T) Envelope[T] {
+	return Envelope[T]{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		Version:    version,
+		OccurredAt: timezone.Now(),
+		Actor:      actor,
+		Payload:    payload,
+	}
+}
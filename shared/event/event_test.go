@@ -0,0 +1,36 @@
+package event_test
+
+import (
+	"encoding/json"
+	"oil/shared/event"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	first := event.New("booking.created", 1, "user-id", map[string]string{"id": "booking-id"})
+	second := event.New("booking.created", 1, "user-id", map[string]string{})
+
+	if first.ID == "" || first.ID == second.ID {
+		t.Error("expected unique event IDs")
+	}
+
+	if first.OccurredAt.IsZero() {
+		t.Error("expected occurred_at to be set")
+	}
+
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	decoded := map[string]any{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, key := range []string{"id", "type", "version", "occurred_at", "actor", "payload"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("expected key %q in envelope", key)
+		}
+	}
+}
@@ -92,6 +92,30 @@ func Forbidden(msg string) error {
 	}
 }
 
+// PreconditionFailed returns a new Failure with code for conditional requests whose condition does not hold.
+func PreconditionFailed(msg string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionFailed,
+		Message: msg,
+	}
+}
+
+// PreconditionRequired returns a new Failure with code for requests missing a required condition.
+func PreconditionRequired(msg string) error {
+	return &Failure{
+		Code:    http.StatusPreconditionRequired,
+		Message: msg,
+	}
+}
+
+// TooManyRequests returns a new Failure with code for callers that made too many attempts.
+func TooManyRequests(msg string) error {
+	return &Failure{
+		Code:    http.StatusTooManyRequests,
+		Message: msg,
+	}
+}
+
 // GetCode returns the error code of an error interface.
 func GetCode(err error) int {
 	var fail *Failure
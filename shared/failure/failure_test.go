@@ -241,6 +241,54 @@ func TestForbidden(t *testing.T) {
 	}
 }
 
+func TestPreconditionFailed(t *testing.T) {
+	result := failure.PreconditionFailed("Room has been modified")
+
+	f, ok := result.(*failure.Failure)
+	if !ok {
+		t.Errorf("expected result to be *failure.Failure, got %T", result)
+	} else {
+		if f.Code != http.StatusPreconditionFailed {
+			t.Errorf("expected code to be %d, got %d", http.StatusPreconditionFailed, f.Code)
+		}
+		if f.Message != "Room has been modified" {
+			t.Errorf("expected message to be 'Room has been modified', got %s", f.Message)
+		}
+	}
+}
+
+func TestPreconditionRequired(t *testing.T) {
+	result := failure.PreconditionRequired("If-Match header is required")
+
+	f, ok := result.(*failure.Failure)
+	if !ok {
+		t.Errorf("expected result to be *failure.Failure, got %T", result)
+	} else {
+		if f.Code != http.StatusPreconditionRequired {
+			t.Errorf("expected code to be %d, got %d", http.StatusPreconditionRequired, f.Code)
+		}
+		if f.Message != "If-Match header is required" {
+			t.Errorf("expected message to be 'If-Match header is required', got %s", f.Message)
+		}
+	}
+}
+
+func TestTooManyRequests(t *testing.T) {
+	result := failure.TooManyRequests("Too many failed attempts")
+
+	f, ok := result.(*failure.Failure)
+	if !ok {
+		t.Errorf("expected result to be *failure.Failure, got %T", result)
+	} else {
+		if f.Code != http.StatusTooManyRequests {
+			t.Errorf("expected code to be %d, got %d", http.StatusTooManyRequests, f.Code)
+		}
+		if f.Message != "Too many failed attempts" {
+			t.Errorf("expected message to be 'Too many failed attempts', got %s", f.Message)
+		}
+	}
+}
+
 func TestGetCode(t *testing.T) {
 	tests := []struct {
 		name     string
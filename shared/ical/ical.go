@@ -0,0 +1,152 @@
+// Package ical renders RFC 5545 iCalendar documents.
+//
+// Event times are written in UTC so that no VTIMEZONE component is needed;
+// the calendar advertises the application timezone through X-WR-TIMEZONE so
+// clients display events in the expected zone.
+package ical
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+
+	crlf       = "\r\n"
+	lineLength = 75
+	utcFormat  = "20060102T150405Z"
+)
+
+// Calendar is a VCALENDAR holding a list of events.
+type Calendar struct {
+	ProdID   string
+	Name     string
+	Timezone string
+	Events   []Event
+}
+
+// Event is a single VEVENT. UID must be stable across exports so that
+// subscribed clients update events instead of duplicating them.
+type Event struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+	Created      time.Time
+	LastModified time.Time
+
+	// The fields below are only filled in by Parse.
+	AllDay         bool
+	RRule          string
+	OrganizerName  string
+	OrganizerEmail string
+	duration       time.Duration
+}
+
+// Marshal renders the calendar as an iCalendar document.
+func (c *Calendar) Marshal() []byte {
+	var builder strings.Builder
+
+	write := func(name, value string) {
+		builder.WriteString(fold(name + ":" + value))
+		builder.WriteString(crlf)
+	}
+
+	write("BEGIN", "VCALENDAR")
+	write("VERSION", "2.0")
+	write("PRODID", c.ProdID)
+	write("CALSCALE", "GREGORIAN")
+	write("METHOD", "PUBLISH")
+
+	if c.Name != "" {
+		write("X-WR-CALNAME", Escape(c.Name))
+	}
+
+	if c.Timezone != "" {
+		write("X-WR-TIMEZONE", c.Timezone)
+	}
+
+	for _, event := range c.Events {
+		write("BEGIN", "VEVENT")
+		write("UID", event.UID)
+		write("DTSTAMP", formatTime(event.LastModified))
+		write("DTSTART", formatTime(event.Start))
+		write("DTEND", formatTime(event.End))
+		write("SUMMARY", Escape(event.Summary))
+
+		if event.Description != "" {
+			write("DESCRIPTION", Escape(event.Description))
+		}
+
+		if event.Location != "" {
+			write("LOCATION", Escape(event.Location))
+		}
+
+		if event.Status != "" {
+			write("STATUS", event.Status)
+		}
+
+		if !event.Created.IsZero() {
+			write("CREATED", formatTime(event.Created))
+		}
+
+		if !event.LastModified.IsZero() {
+			write("LAST-MODIFIED", formatTime(event.LastModified))
+		}
+
+		write("END", "VEVENT")
+	}
+
+	write("END", "VCALENDAR")
+
+	return []byte(builder.String())
+}
+
+// Escape escapes a TEXT property value.
+func Escape(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(utcFormat)
+}
+
+// fold splits content lines longer than 75 octets, never breaking a UTF-8 sequence.
+func fold(line string) string {
+	if len(line) <= lineLength {
+		return line
+	}
+
+	var builder strings.Builder
+
+	limit := lineLength
+	start := 0
+
+	for index, char := range line {
+		if index+utf8.RuneLen(char)-start > limit {
+			builder.WriteString(line[start:index])
+			builder.WriteString(crlf + " ")
+
+			start = index
+			// Continuation lines begin with a space, which counts towards the limit.
+			limit = lineLength - 1
+		}
+	}
+
+	builder.WriteString(line[start:])
+
+	return builder.String()
+}
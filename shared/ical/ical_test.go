@@ -0,0 +1,88 @@
+package ical_test
+
+import (
+	"oil/shared/ical"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_Marshal(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+
+	calendar := ical.Calendar{
+		ProdID:   "-//oil//bookings//EN",
+		Name:     "Room A",
+		Timezone: "Asia/Jakarta",
+		Events: []ical.Event{
+			{
+				UID:          "booking-1@oil",
+				Start:        time.Date(2025, 1, 6, 10, 0, 0, 0, jakarta),
+				End:          time.Date(2025, 1, 6, 11, 30, 0, 0, jakarta),
+				Summary:      "Planning, Q1; review",
+				Status:       ical.StatusConfirmed,
+				LastModified: time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	output := string(calendar.Marshal())
+
+	expectedLines := []string{
+		"BEGIN:VCALENDAR",
+		"PRODID:-//oil//bookings//EN",
+		"X-WR-CALNAME:Room A",
+		"X-WR-TIMEZONE:Asia/Jakarta",
+		"BEGIN:VEVENT",
+		"UID:booking-1@oil",
+		"DTSTAMP:20250101T080000Z",
+		"DTSTART:20250106T030000Z",
+		"DTEND:20250106T043000Z",
+		`SUMMARY:Planning\, Q1\; review`,
+		"STATUS:CONFIRMED",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}
+
+	for _, line := range expectedLines {
+		if !strings.Contains(output, line+"\r\n") {
+			t.Errorf("expected output to contain line %q", line)
+		}
+	}
+
+	if strings.Contains(output, "DESCRIPTION") {
+		t.Error("expected empty description to be omitted")
+	}
+}
+
+func TestCalendar_MarshalFoldsLongLines(t *testing.T) {
+	calendar := ical.Calendar{
+		Events: []ical.Event{
+			{Summary: strings.Repeat("é", 100)},
+		},
+	}
+
+	for _, line := range strings.Split(string(calendar.Marshal()), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected line of at most 75 octets, got %d", len(line))
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "plain", expected: "plain"},
+		{input: `a\b`, expected: `a\\b`},
+		{input: "a,b;c", expected: `a\,b\;c`},
+		{input: "line1\nline2", expected: `line1\nline2`},
+	}
+
+	for _, tt := range tests {
+		if result := ical.Escape(tt.input); result != tt.expected {
+			t.Errorf("Escape(%q) = %q, expected %q", tt.input, result, tt.expected)
+		}
+	}
+}
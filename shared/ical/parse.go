@@ -0,0 +1,273 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	localFormat = "20060102T150405"
+	dateFormat  = "20060102"
+)
+
+var (
+	ErrNotCalendar = errors.New("input is not an iCalendar document")
+
+	durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+// property is a single content line split into name, parameters and value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs of an iCalendar document. Floating times and times
+// in an unknown TZID are interpreted in loc.
+func Parse(reader io.Reader, loc *time.Location) (Calendar, error) {
+	lines, err := unfold(reader)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return Calendar{}, ErrNotCalendar
+	}
+
+	calendar := Calendar{}
+
+	var (
+		event   *Event
+		nesting int
+	)
+
+	for number, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("line %d: %w", number+1, err)
+		}
+
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			event = &Event{}
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT") && event != nil:
+			event.resolveEnd()
+			calendar.Events = append(calendar.Events, *event)
+			event = nil
+		case event == nil:
+			calendar.apply(prop)
+		case prop.name == "BEGIN":
+			// Skip nested components such as VALARM.
+			nesting++
+		case prop.name == "END":
+			nesting--
+		case nesting == 0:
+			if err := event.apply(prop, loc); err != nil {
+				return Calendar{}, fmt.Errorf("line %d: %w", number+1, err)
+			}
+		}
+	}
+
+	return calendar, nil
+}
+
+func (c *Calendar) apply(prop property) {
+	switch prop.name {
+	case "PRODID":
+		c.ProdID = prop.value
+	case "X-WR-CALNAME":
+		c.Name = Unescape(prop.value)
+	case "X-WR-TIMEZONE":
+		c.Timezone = prop.value
+	}
+}
+
+func (e *Event) apply(prop property, loc *time.Location) error {
+	var err error
+
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = Unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = Unescape(prop.value)
+	case "LOCATION":
+		e.Location = Unescape(prop.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(prop.value)
+	case "RRULE":
+		e.RRule = prop.value
+	case "ORGANIZER":
+		e.OrganizerName = prop.params["CN"]
+		e.OrganizerEmail = strings.TrimPrefix(strings.TrimPrefix(prop.value, "mailto:"), "MAILTO:")
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(prop, loc)
+	case "DTEND":
+		e.End, _, err = parseTime(prop, loc)
+	case "DURATION":
+		e.duration, err = ParseDuration(prop.value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", prop.name, err)
+	}
+
+	return nil
+}
+
+// resolveEnd derives a missing DTEND from DURATION or, failing that, from the
+// defaults of RFC 5545 section 3.6.1.
+func (e *Event) resolveEnd() {
+	if !e.End.IsZero() {
+		return
+	}
+
+	switch {
+	case e.duration != 0:
+		e.End = e.Start.Add(e.duration)
+	case e.AllDay:
+		e.End = e.Start.AddDate(0, 0, 1)
+	default:
+		e.End = e.Start
+	}
+}
+
+// ParseDuration parses an RFC 5545 DURATION value such as PT1H30M or P1D.
+func ParseDuration(value string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+
+	var duration time.Duration
+
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+
+		amount, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, err
+		}
+
+		duration += time.Duration(amount) * unit
+	}
+
+	if match[1] == "-" {
+		duration = -duration
+	}
+
+	return duration, nil
+}
+
+// Unescape reverses Escape for a TEXT property value.
+func Unescape(value string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(value)
+}
+
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(dateFormat) {
+		date, err := time.ParseInLocation(dateFormat, prop.value, loc)
+
+		return date, true, err
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		value, err := time.Parse(utcFormat, prop.value)
+
+		return value, false, err
+	}
+
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if location, err := time.LoadLocation(tzid); err == nil {
+			loc = location
+		}
+	}
+
+	value, err := time.ParseInLocation(localFormat, prop.value, loc)
+
+	return value, false, err
+}
+
+// unfold joins folded content lines and drops empty ones.
+func unfold(reader io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	lines := []string{}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+
+			continue
+		}
+
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+func parseLine(line string) (property, error) {
+	colon := valueSeparator(line)
+	if colon < 0 {
+		return property{}, fmt.Errorf("malformed content line %q", line)
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: map[string]string{},
+		value:  line[colon+1:],
+	}
+
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return prop, nil
+}
+
+// valueSeparator finds the colon separating name and value, ignoring colons in
+// quoted parameter values.
+func valueSeparator(line string) int {
+	quoted := false
+
+	for index, char := range line {
+		switch char {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				return index
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,151 @@
+package ical_test
+
+import (
+	"errors"
+	"oil/shared/ical"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Legacy//Rooms//EN\r\n" +
+	"X-WR-CALNAME:Room A\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:event-1\r\n" +
+	"DTSTART:20250106T030000Z\r\n" +
+	"DTEND:20250106T040000Z\r\n" +
+	"SUMMARY:Weekly sync\\, team A\r\n" +
+	"DESCRIPTION:Agenda\r\n" +
+	" \\nand notes\r\n" +
+	"ORGANIZER;CN=\"Doe, Jane\":mailto:jane@example.com\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"TRIGGER:-PT15M\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:event-2\r\n" +
+	"DTSTART;TZID=Asia/Jakarta:20250107T090000\r\n" +
+	"DURATION:PT1H30M\r\n" +
+	"STATUS:tentative\r\n" +
+	"RRULE:FREQ=WEEKLY;COUNT=3\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:event-3\r\n" +
+	"DTSTART;VALUE=DATE:20250108\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	calendar, err := ical.Parse(strings.NewReader(sample), time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if calendar.Name != "Room A" {
+		t.Errorf("expected calendar name Room A, got %q", calendar.Name)
+	}
+
+	if len(calendar.Events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(calendar.Events))
+	}
+
+	first := calendar.Events[0]
+	if first.Summary != "Weekly sync, team A" {
+		t.Errorf("unexpected summary %q", first.Summary)
+	}
+
+	if first.Description != "Agenda\nand notes" {
+		t.Errorf("expected alarm description to be ignored and folding undone, got %q", first.Description)
+	}
+
+	if first.OrganizerName != "Doe, Jane" || first.OrganizerEmail != "jane@example.com" {
+		t.Errorf("unexpected organizer %q <%s>", first.OrganizerName, first.OrganizerEmail)
+	}
+
+	if !first.Start.Equal(time.Date(2025, 1, 6, 3, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected start %v", first.Start)
+	}
+
+	second := calendar.Events[1]
+	jakarta, _ := time.LoadLocation("Asia/Jakarta")
+
+	if !second.Start.Equal(time.Date(2025, 1, 7, 9, 0, 0, 0, jakarta)) {
+		t.Errorf("unexpected start %v", second.Start)
+	}
+
+	if second.End.Sub(second.Start) != 90*time.Minute {
+		t.Errorf("expected duration to set the end, got %v", second.End.Sub(second.Start))
+	}
+
+	if second.Status != ical.StatusTentative || second.RRule == "" {
+		t.Errorf("unexpected status %q or rrule %q", second.Status, second.RRule)
+	}
+
+	third := calendar.Events[2]
+	if !third.AllDay || third.End.Sub(third.Start) != 24*time.Hour {
+		t.Errorf("expected an all-day event lasting one day, got %v to %v", third.Start, third.End)
+	}
+}
+
+func TestParse_RoundTrip(t *testing.T) {
+	original := ical.Calendar{
+		ProdID: "-//oil//bookings//EN",
+		Events: []ical.Event{
+			{
+				UID:     "booking-1@oil",
+				Start:   time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
+				End:     time.Date(2025, 1, 6, 11, 0, 0, 0, time.UTC),
+				Summary: strings.Repeat("long; summary, ", 10),
+			},
+		},
+	}
+
+	parsed, err := ical.Parse(strings.NewReader(string(original.Marshal())), time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(parsed.Events) != 1 || parsed.Events[0].Summary != original.Events[0].Summary {
+		t.Errorf("expected summary to survive a round trip, got %+v", parsed.Events)
+	}
+}
+
+func TestParse_NotCalendar(t *testing.T) {
+	_, err := ical.Parse(strings.NewReader("hello"), time.UTC)
+	if !errors.Is(err, ical.ErrNotCalendar) {
+		t.Errorf("expected ErrNotCalendar, got %v", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{input: "PT1H30M", expected: 90 * time.Minute},
+		{input: "P1D", expected: 24 * time.Hour},
+		{input: "P1W", expected: 7 * 24 * time.Hour},
+		{input: "-PT15M", expected: -15 * time.Minute},
+		{input: "P", wantErr: true},
+		{input: "1H", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		duration, err := ical.ParseDuration(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) expected error", tt.input)
+			}
+
+			continue
+		}
+
+		if err != nil || duration != tt.expected {
+			t.Errorf("ParseDuration(%q) = %v, %v; expected %v", tt.input, duration, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package lockout counts failed attempts, such as logins, per key in the cache
+// and locks a key out once it fails too often, for a time that doubles with
+// each further failure.
+package lockout
+
+//go:generate go run go.uber.org/mock/mockgen -source=./lockout.go -destination=./mocks/lockout_mock.go -package=mocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oil/shared"
+	"oil/shared/cache"
+	"oil/shared/timezone"
+	"strings"
+	"time"
+)
+
+const (
+	cacheKeyLockout     = "lockout"
+	cacheKeyFailures    = "failures"
+	cacheKeyLockedUntil = "locked_until"
+
+	keyAccount   = "account"
+	keyIP        = "ip"
+	keyChallenge = "challenge"
+)
+
+// Policy is how many failures a key is allowed and how long it is locked out
+// after them.
+type Policy struct {
+	// Attempts is the number of failures that locks the key out.
+	Attempts int
+	// Lockout is the first lockout, doubled with each failure after it.
+	Lockout time.Duration
+	// Max caps the lockout. Failures are forgotten once it passes without one.
+	Max time.Duration
+}
+
+// Status is the failed attempts of a key.
+type Status struct {
+	FailedAttempts int        `json:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty"`
+}
+
+// Locked reports whether the key is locked out at t.
+func (s Status) Locked(t time.Time) bool {
+	return s.LockedUntil != nil && t.Before(*s.LockedUntil)
+}
+
+type Lockout interface {
+	Status(ctx context.Context, key string) (Status, error)
+	Fail(ctx context.Context, key string, policy Policy) (Status, error)
+	Reset(ctx context.Context, key string) error
+}
+
+type lockout struct {
+	cache cache.RedisCache
+}
+
+func New(cache cache.RedisCache) Lockout {
+	return &lockout{cache: cache}
+}
+
+// AccountKey returns the key of the account with an email, whether it exists
+// or not.
+func AccountKey(email string) string {
+	return keyAccount + ":" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey returns the key of an IP address.
+func IPKey(ip string) string {
+	return keyIP + ":" + ip
+}
+
+// ChallengeKey returns the key of a challenge, such as a second factor, by the
+// id of its token.
+func ChallengeKey(tokenID string) string {
+	return keyChallenge + ":" + tokenID
+}
+
+// Status returns the failed attempts of a key.
+func (l *lockout) Status(ctx context.Context, key string) (Status, error) {
+	var status Status
+
+	if err := l.cache.Get(ctx, failuresKey(key), &status.FailedAttempts); err != nil {
+		if errors.Is(err, cache.Nil) {
+			return Status{}, nil
+		}
+
+		return Status{}, fmt.Errorf("failed to get lockout: %w", err)
+	}
+
+	var lockedUntil time.Time
+
+	if err := l.cache.Get(ctx, lockedUntilKey(key), &lockedUntil); err != nil {
+		if errors.Is(err, cache.Nil) {
+			return status, nil
+		}
+
+		return Status{}, fmt.Errorf("failed to get lockout: %w", err)
+	}
+
+	status.LockedUntil = &lockedUntil
+
+	return status, nil
+}
+
+// Fail records a failed attempt of a key and locks it out when the policy
+// allows no more. Failures are counted atomically, so that concurrent ones
+// each count and each lock the key out for as long as their count calls for.
+func (l *lockout) Fail(ctx context.Context, key string, policy Policy) (Status, error) {
+	ttl := int(policy.Max.Seconds())
+
+	failures, err := l.cache.Incr(ctx, failuresKey(key), ttl)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to count failure: %w", err)
+	}
+
+	status := Status{FailedAttempts: int(failures)}
+
+	if status.FailedAttempts < policy.Attempts {
+		return status, nil
+	}
+
+	lockedUntil := timezone.Now().Add(policy.duration(status.FailedAttempts - policy.Attempts))
+	status.LockedUntil = &lockedUntil
+
+	if err := l.cache.Save(ctx, lockedUntilKey(key), lockedUntil, ttl); err != nil {
+		return status, fmt.Errorf("failed to save lockout: %w", err)
+	}
+
+	return status, nil
+}
+
+// Reset forgets the failed attempts of a key, which unlocks it.
+func (l *lockout) Reset(ctx context.Context, key string) error {
+	for _, cacheKey := range []string{failuresKey(key), lockedUntilKey(key)} {
+		if err := l.cache.Delete(ctx, cacheKey); err != nil {
+			return fmt.Errorf("failed to delete lockout: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// failuresKey is the cache key counting the failures of a key.
+func failuresKey(key string) string {
+	return shared.BuildCacheKey(cacheKeyLockout, key, cacheKeyFailures)
+}
+
+// lockedUntilKey is the cache key of the time a key is locked out until.
+func lockedUntilKey(key string) string {
+	return shared.BuildCacheKey(cacheKeyLockout, key, cacheKeyLockedUntil)
+}
+
+// duration returns the lockout after a number of failures past the allowed
+// attempts.
+func (p Policy) duration(failures int) time.Duration {
+	lockout := p.Lockout
+	for i := 0; i < failures && lockout < p.Max; i++ {
+		lockout *= 2
+	}
+
+	return min(lockout, p.Max)
+}
@@ -0,0 +1,237 @@
+package lockout_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"oil/shared/cache"
+	"oil/shared/cache/mocks"
+	"oil/shared/lockout"
+	"oil/shared/timezone"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var policy = lockout.Policy{
+	Attempts: 3,
+	Lockout:  time.Minute,
+	Max:      10 * time.Minute,
+}
+
+func TestLockout_Fail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mocks.NewMockRedisCache(ctrl)
+	guard := lockout.New(mockCache)
+
+	tests := []struct {
+		name        string
+		failures    int64
+		wantLockout time.Duration
+	}{
+		{name: "first failure", failures: 1, wantLockout: 0},
+		{name: "failure below attempts", failures: 2, wantLockout: 0},
+		{name: "failure reaching attempts", failures: 3, wantLockout: time.Minute},
+		{name: "failure past attempts doubles", failures: 5, wantLockout: 4 * time.Minute},
+		{name: "lockout is capped", failures: 101, wantLockout: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockCache.EXPECT().Incr(gomock.Any(), gomock.Any(), 600).Return(tt.failures, nil)
+
+			if tt.wantLockout > 0 {
+				mockCache.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), 600).Return(nil)
+			}
+
+			now := timezone.Now()
+
+			status, err := guard.Fail(context.Background(), lockout.AccountKey("Test@Example.com"), policy)
+			assert.NoError(t, err)
+			assert.Equal(t, int(tt.failures), status.FailedAttempts)
+
+			if tt.wantLockout == 0 {
+				assert.Nil(t, status.LockedUntil)
+				assert.False(t, status.Locked(now))
+
+				return
+			}
+
+			assert.True(t, status.Locked(now))
+			assert.WithinDuration(t, now.Add(tt.wantLockout), *status.LockedUntil, time.Second)
+		})
+	}
+}
+
+func TestLockout_Fail_Concurrent(t *testing.T) {
+	guard := lockout.New(newMemoryCache())
+	key := lockout.IPKey("10.0.0.1")
+
+	const failures = 50
+
+	var wg sync.WaitGroup
+
+	for range failures {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := guard.Fail(context.Background(), key, policy)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	status, err := guard.Status(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, failures, status.FailedAttempts)
+	assert.True(t, status.Locked(timezone.Now()))
+
+	assert.NoError(t, guard.Reset(context.Background(), key))
+
+	status, err = guard.Status(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, lockout.Status{}, status)
+}
+
+func TestLockout_Status(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := mocks.NewMockRedisCache(ctrl)
+	guard := lockout.New(mockCache)
+
+	mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(cache.Nil)
+
+	status, err := guard.Status(context.Background(), lockout.IPKey("10.0.0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, lockout.Status{}, status)
+
+	mockCache.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("connection refused"))
+
+	_, err = guard.Status(context.Background(), lockout.IPKey("10.0.0.1"))
+	assert.Error(t, err)
+}
+
+func TestStatus_Locked(t *testing.T) {
+	now := timezone.Now()
+	past := now.Add(-time.Second)
+	future := now.Add(time.Second)
+
+	assert.False(t, lockout.Status{}.Locked(now))
+	assert.False(t, lockout.Status{FailedAttempts: 5, LockedUntil: &past}.Locked(now))
+	assert.True(t, lockout.Status{FailedAttempts: 5, LockedUntil: &future}.Locked(now))
+}
+
+func TestAccountKey(t *testing.T) {
+	assert.Equal(t, lockout.AccountKey("test@example.com"), lockout.AccountKey(" Test@Example.COM "))
+	assert.NotEqual(t, lockout.AccountKey("10.0.0.1"), lockout.IPKey("10.0.0.1"))
+}
+
+// memoryCache keeps the cache in a map, as Redis would without expiry.
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}}
+}
+
+func (c *memoryCache) Save(_ context.Context, key string, value any, _ int) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = data
+
+	return nil
+}
+
+func (c *memoryCache) SaveIfAbsent(_ context.Context, key string, value any, _ int) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+
+	c.values[key] = data
+
+	return true, nil
+}
+
+func (c *memoryCache) CompareAndSave(_ context.Context, key, old string, value any, _ int) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if stored, ok := c.values[key]; !ok || string(stored) != old {
+		return false, nil
+	}
+
+	c.values[key] = data
+
+	return true, nil
+}
+
+func (c *memoryCache) Get(_ context.Context, key string, value any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, ok := c.values[key]
+	if !ok {
+		return cache.Nil
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+
+	return nil
+}
+
+func (c *memoryCache) Clear(context.Context, string) error {
+	return nil
+}
+
+func (c *memoryCache) Keys(context.Context, string) ([]string, error) {
+	return nil, nil
+}
+
+func (c *memoryCache) Incr(_ context.Context, key string, _ int) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, _ := strconv.ParseInt(string(c.values[key]), 10, 64)
+	value++
+	c.values[key] = []byte(strconv.FormatInt(value, 10))
+
+	return value, nil
+}
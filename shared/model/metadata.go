@@ -3,8 +3,22 @@ package model
 import "time"
 
 type Metadata struct {
-	CreatedAt  time.Time `db:"created_at"`
-	ModifiedAt time.Time `db:"modified_at"`
-	CreatedBy  string    `db:"created_by"`
-	ModifiedBy string    `db:"modified_by"`
+	CreatedAt  time.Time `db:"created_at"  query:"sort,filter"`
+	ModifiedAt time.Time `db:"modified_at" query:"sort,filter" version:"ignore"`
+	CreatedBy  string    `db:"created_by"  query:"filter"`
+	ModifiedBy string    `db:"modified_by" query:"filter" version:"ignore"`
+}
+
+// SoftDelete marks a row as deleted without removing it. Repositories of
+// models embedding it leave deleted rows out of their queries.
+type SoftDelete struct {
+	DeletedAt *time.Time `db:"deleted_at" query:"sort,filter"`
+	DeletedBy *string    `db:"deleted_by" query:"filter"`
+}
+
+// Versioned rows have their version bumped on every update, except updates of
+// bookkeeping columns only, so that writers can make their changes conditional
+// on the version they read.
+type Versioned struct {
+	Version int `db:"version"`
 }
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"oil/infras/postgres"
+	"oil/shared/constant"
+	"oil/shared/dto"
+	"oil/shared/timezone"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx/types"
+)
+
+// Every write of the generic repository is recorded in the audit log with the
+// columns it changed, unless the model implements Unaudited. Columns tagged
+// `audit:"redact"` are reported as changed without their values.
+const (
+	AuditTableName = "audit_logs"
+
+	AuditActionInsert  = "insert"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	AuditActionPurge   = "purge"
+
+	auditTag       = "audit"
+	auditTagRedact = "redact"
+	redactedValue  = "[redacted]"
+)
+
+// Unaudited is implemented by models whose writes are not worth auditing,
+// such as outbox messages or the audit log itself.
+type Unaudited interface {
+	Unaudited()
+}
+
+// Change is the value of a column before and after a write. Old is left out
+// for inserted rows and New for removed ones.
+type Change struct {
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+type auditEntry struct {
+	ID        string         `db:"id"`
+	Entity    string         `db:"entity"`
+	EntityID  string         `db:"entity_id"`
+	Action    string         `db:"action"`
+	Actor     *string        `db:"actor"`
+	RequestID *string        `db:"request_id"`
+	Changes   types.JSONText `db:"changes"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+var auditColumns = []string{"id", "entity", "entity_id", "action", "actor", "request_id", "changes", "created_at"}
+
+// inTx runs fn in a unit of work when writes are audited, so that a write and
+// its audit entries are committed together.
+func (repo *Repository[T]) inTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !repo.audited {
+		return fn(ctx)
+	}
+
+	if _, ok := postgres.TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	return repo.tx.Do(ctx, fn) //nolint:wrapcheck
+}
+
+// snapshot reads the rows matching where as column maps, locking them when
+// they are about to be written. Only the columns of the model are read, so that
+// a column the model does not know cannot be recorded without its audit tag.
+func (repo *Repository[T]) snapshot(ctx context.Context, exec execer, where string, args map[string]any, lock bool) ([]map[string]any, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s %s", strings.Join(repo.InsertColumns, ", "), repo.table, where)
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	prepare, err := exec.PrepareNamedContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare audit snapshot (%s): %w", repo.entity, err)
+	}
+	defer prepare.Close()
+
+	rows, err := prepare.QueryxContext(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take audit snapshot (%s): %w", repo.entity, err)
+	}
+	defer rows.Close()
+
+	snapshots := []map[string]any{}
+
+	for rows.Next() {
+		row := map[string]any{}
+		if err := rows.MapScan(row); err != nil {
+			return nil, fmt.Errorf("failed to scan audit snapshot (%s): %w", repo.entity, err)
+		}
+
+		for col, value := range row {
+			if raw, ok := value.([]byte); ok {
+				row[col] = string(raw)
+			}
+		}
+
+		snapshots = append(snapshots, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit snapshot (%s): %w", repo.entity, err)
+	}
+
+	return snapshots, nil
+}
+
+// auditSnapshot locks and reads the rows a write is about to change, when
+// writes are audited.
+func (repo *Repository[T]) auditSnapshot(ctx context.Context, exec execer, where string, args map[string]any) ([]map[string]any, error) {
+	if !repo.audited {
+		return nil, nil
+	}
+
+	return repo.snapshot(ctx, exec, where, args, true)
+}
+
+// auditWrite records the changes made to the rows captured in before, by
+// reading them again after the write. Rows that are gone were removed.
+func (repo *Repository[T]) auditWrite(ctx context.Context, exec execer, action string, before []map[string]any) error {
+	if len(before) == 0 {
+		return nil
+	}
+
+	ids := make([]any, len(before))
+	for i, row := range before {
+		ids[i] = row[repo.primaryColumn]
+	}
+
+	byID := dto.Filter{ArgName: "audit_id", Field: repo.primaryColumn, Operator: dto.FilterOperatorIn, Value: ids, Table: repo.table}
+	where, args := byID.GetWhereClause()
+
+	after, err := repo.snapshot(ctx, exec, "WHERE "+where, args, false)
+	if err != nil {
+		return err
+	}
+
+	afterByID := map[string]map[string]any{}
+	for _, row := range after {
+		afterByID[fmt.Sprint(row[repo.primaryColumn])] = row
+	}
+
+	entries := []auditEntry{}
+
+	for _, row := range before {
+		id := fmt.Sprint(row[repo.primaryColumn])
+
+		entry, ok, err := repo.newAuditEntry(ctx, action, id, row, afterByID[id])
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return repo.record(ctx, exec, entries)
+}
+
+// auditInsert records the inserted models.
+func (repo *Repository[T]) auditInsert(ctx context.Context, exec execer, models ...T) error {
+	entries := []auditEntry{}
+
+	for _, model := range models {
+		values := map[string]any{}
+
+		for _, col := range repo.InsertColumns {
+			value, _ := fieldByTag(reflect.ValueOf(model), col)
+			values[col] = value
+		}
+
+		entry, ok, err := repo.newAuditEntry(ctx, AuditActionInsert, fmt.Sprint(values[repo.primaryColumn]), nil, values)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	return repo.record(ctx, exec, entries)
+}
+
+// newAuditEntry builds the entry of a row, reporting false when nothing changed.
+func (repo *Repository[T]) newAuditEntry(ctx context.Context, action, id string, before, after map[string]any) (auditEntry, bool, error) {
+	changes := repo.diff(before, after)
+	if len(changes) == 0 {
+		return auditEntry{}, false, nil
+	}
+
+	encoded, err := json.Marshal(changes)
+	if err != nil {
+		return auditEntry{}, false, fmt.Errorf("failed to encode audit changes (%s): %w", repo.entity, err)
+	}
+
+	entry := auditEntry{
+		ID:        uuid.NewString(),
+		Entity:    repo.entity,
+		EntityID:  id,
+		Action:    action,
+		Changes:   encoded,
+		CreatedAt: timezone.Now(),
+	}
+
+	if actor, _ := ctx.Value(constant.ContextKeyUserID).(string); actor != constant.Empty {
+		entry.Actor = &actor
+	}
+
+	if requestID := middleware.GetReqID(ctx); requestID != constant.Empty {
+		entry.RequestID = &requestID
+	}
+
+	return entry, true, nil
+}
+
+// diff returns the columns whose value differs between before and after.
+func (repo *Repository[T]) diff(before, after map[string]any) map[string]Change {
+	changes := map[string]Change{}
+
+	for col := range joinKeys(before, after) {
+		old, updated := before[col], after[col]
+		if equalValues(old, updated) {
+			continue
+		}
+
+		if c, ok := repo.column(col); ok && c.redact {
+			old, updated = redact(old), redact(updated)
+		}
+
+		changes[col] = Change{Old: old, New: updated}
+	}
+
+	return changes
+}
+
+func (repo *Repository[T]) record(ctx context.Context, exec execer, entries []auditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(auditColumns))
+	for i, col := range auditColumns {
+		placeholders[i] = ":" + col
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", AuditTableName, strings.Join(auditColumns, ", "), strings.Join(placeholders, ", "))
+
+	if _, err := exec.NamedExecContext(ctx, query, entries); err != nil {
+		return fmt.Errorf("failed to record audit log (%s): %w", repo.entity, err)
+	}
+
+	return nil
+}
+
+func joinKeys(maps ...map[string]any) map[string]struct{} {
+	keys := map[string]struct{}{}
+
+	for _, m := range maps {
+		for key := range m {
+			keys[key] = struct{}{}
+		}
+	}
+
+	return keys
+}
+
+// equalValues compares column values, times by instant.
+func equalValues(a, b any) bool {
+	if at, ok := a.(time.Time); ok {
+		bt, ok := b.(time.Time)
+
+		return ok && at.Equal(bt)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func redact(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	return redactedValue
+}
@@ -0,0 +1,279 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+
+	"oil/infras/otel/mocks"
+	"oil/shared/constant"
+	"oil/shared/repository"
+)
+
+func TestRepository_Diff(t *testing.T) {
+	repo := repository.NewRepository[account]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+
+	createdAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]repository.Change
+	}{
+		{
+			name:   "unchanged columns are left out",
+			before: map[string]any{"id": "1", "email": "a@example.com"},
+			after:  map[string]any{"id": "1", "email": "a@example.com"},
+			want:   map[string]repository.Change{},
+		},
+		{
+			name:   "changed column",
+			before: map[string]any{"id": "1", "email": "a@example.com"},
+			after:  map[string]any{"id": "1", "email": "b@example.com"},
+			want:   map[string]repository.Change{"email": {Old: "a@example.com", New: "b@example.com"}},
+		},
+		{
+			name:  "inserted row",
+			after: map[string]any{"id": "1"},
+			want:  map[string]repository.Change{"id": {New: "1"}},
+		},
+		{
+			name:   "removed row",
+			before: map[string]any{"id": "1"},
+			want:   map[string]repository.Change{"id": {Old: "1"}},
+		},
+		{
+			name:   "redacted column",
+			before: map[string]any{"password": "old-hash"},
+			after:  map[string]any{"password": "new-hash"},
+			want:   map[string]repository.Change{"password": {Old: "[redacted]", New: "[redacted]"}},
+		},
+		{
+			name:   "redacted column being set",
+			before: map[string]any{"password": nil},
+			after:  map[string]any{"password": "hash"},
+			want:   map[string]repository.Change{"password": {New: "[redacted]"}},
+		},
+		{
+			name:   "same instant in another time zone",
+			before: map[string]any{"created_at": createdAt},
+			after:  map[string]any{"created_at": createdAt.In(time.FixedZone("WIB", 7*60*60))},
+			want:   map[string]repository.Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repo.Diff(tt.before, tt.after))
+		})
+	}
+}
+
+func TestEqualValues(t *testing.T) {
+	instant := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		a    any
+		b    any
+		want bool
+	}{
+		{name: "equal strings", a: "a", b: "a", want: true},
+		{name: "different strings", a: "a", b: "b"},
+		{name: "both nil", want: true},
+		{name: "nil and value", b: "a"},
+		{name: "equal numbers", a: int64(1), b: int64(1), want: true},
+		{name: "numbers of different types", a: int64(1), b: 1},
+		{name: "same instant", a: instant, b: instant.In(time.FixedZone("WIB", 7*60*60)), want: true},
+		{name: "different instants", a: instant, b: instant.Add(time.Second)},
+		{name: "time and string", a: instant, b: instant.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repository.EqualValues(tt.a, tt.b))
+		})
+	}
+}
+
+func TestJoinKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		maps []map[string]any
+		want map[string]struct{}
+	}{
+		{name: "no maps", want: map[string]struct{}{}},
+		{name: "nil map", maps: []map[string]any{nil}, want: map[string]struct{}{}},
+		{
+			name: "keys of every map",
+			maps: []map[string]any{{"id": "1", "email": "a"}, {"id": "1", "age": 3}},
+			want: map[string]struct{}{"id": {}, "email": {}, "age": {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repository.JoinKeys(tt.maps...))
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "no value stays empty", value: nil, want: nil},
+		{name: "string", value: "secret", want: "[redacted]"},
+		{name: "empty string", value: "", want: "[redacted]"},
+		{name: "number", value: 42, want: "[redacted]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, repository.Redact(tt.value))
+		})
+	}
+}
+
+func TestRepository_NewAuditEntry(t *testing.T) {
+	repo := repository.NewRepository[account]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+
+	ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, "admin-id")
+	ctx = context.WithValue(ctx, middleware.RequestIDKey, "request-id")
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		before      map[string]any
+		after       map[string]any
+		wantOK      bool
+		wantChanges string
+		wantActor   *string
+		wantRequest *string
+	}{
+		{
+			name:        "change by a user in a request",
+			ctx:         ctx,
+			before:      map[string]any{"id": "1", "email": "a@example.com"},
+			after:       map[string]any{"id": "1", "email": "b@example.com"},
+			wantOK:      true,
+			wantChanges: `{"email":{"old":"a@example.com","new":"b@example.com"}}`,
+			wantActor:   stringPtr("admin-id"),
+			wantRequest: stringPtr("request-id"),
+		},
+		{
+			name:        "change without a user or request",
+			ctx:         context.Background(),
+			before:      map[string]any{"id": "1", "password": "old-hash"},
+			after:       map[string]any{"id": "1", "password": "new-hash"},
+			wantOK:      true,
+			wantChanges: `{"password":{"old":"[redacted]","new":"[redacted]"}}`,
+		},
+		{
+			name:   "nothing changed",
+			ctx:    ctx,
+			before: map[string]any{"id": "1", "email": "a@example.com"},
+			after:  map[string]any{"id": "1", "email": "a@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok, err := repo.NewAuditEntry(tt.ctx, repository.AuditActionUpdate, "1", tt.before, tt.after)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+
+			if !tt.wantOK {
+				return
+			}
+
+			assert.NotEmpty(t, entry.ID)
+			assert.Equal(t, "account", entry.Entity)
+			assert.Equal(t, "1", entry.EntityID)
+			assert.Equal(t, repository.AuditActionUpdate, entry.Action)
+			assert.JSONEq(t, tt.wantChanges, string(entry.Changes))
+			assert.Equal(t, tt.wantActor, entry.Actor)
+			assert.Equal(t, tt.wantRequest, entry.RequestID)
+		})
+	}
+}
+
+func TestRepository_Snapshot(t *testing.T) {
+	columns := []string{"id", "email", "password", "age", "nickname"}
+
+	tests := []struct {
+		name      string
+		lock      bool
+		rows      []fakeRows
+		wantQuery string
+		want      []map[string]any
+	}{
+		{
+			name: "reads the model columns only",
+			rows: []fakeRows{{
+				columns: columns,
+				values:  [][]driver.Value{{[]byte("1"), "a@example.com", []byte("hash"), int64(30), nil}},
+			}},
+			wantQuery: "SELECT id, email, password, age, nickname FROM accounts WHERE accounts.id = $1",
+			want: []map[string]any{
+				{"id": "1", "email": "a@example.com", "password": "hash", "age": int64(30), "nickname": nil},
+			},
+		},
+		{
+			name:      "locks rows about to be written",
+			lock:      true,
+			wantQuery: "SELECT id, email, password, age, nickname FROM accounts WHERE accounts.id = $1 FOR UPDATE",
+			want:      []map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{rows: tt.rows}
+			conn, tx := newFakeConnection(t, db)
+			repo := repository.NewRepository[account]("account", "accounts", "id", conn, tx, mocks.NewOtel())
+
+			rows, err := repo.Snapshot(context.Background(), "WHERE accounts.id = :id", map[string]any{"id": "1"}, tt.lock)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, rows)
+			assert.Equal(t, []string{tt.wantQuery}, db.queries)
+		})
+	}
+}
+
+func TestRepository_Purge(t *testing.T) {
+	deletedAt := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	db := &fakeDB{
+		affected: 1,
+		rows: []fakeRows{{
+			columns: []string{"id", "deleted_at", "deleted_by"},
+			values:  [][]driver.Value{{"1", deletedAt, "admin-id"}},
+		}},
+	}
+	conn, tx := newFakeConnection(t, db)
+	repo := repository.NewRepository[deletableAccount]("account", "accounts", "id", conn, tx, mocks.NewOtel())
+
+	purged, err := repo.Purge(context.Background(), deletedAt.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	if assert.Len(t, db.queries, 4) {
+		assert.Equal(t, "SELECT id, deleted_at, deleted_by FROM accounts WHERE deleted_at < $1 FOR UPDATE", db.queries[0])
+		assert.Equal(t, "DELETE FROM accounts WHERE deleted_at < $1", db.queries[1])
+		assert.True(t, strings.HasPrefix(db.queries[2], "SELECT id, deleted_at, deleted_by FROM accounts WHERE"))
+		assert.True(t, strings.HasPrefix(db.queries[3], "INSERT INTO audit_logs"))
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,23 @@
+package repository
+
+import "context"
+
+// Unexported helpers used by the tests of package repository_test.
+
+var (
+	EqualValues = equalValues
+	JoinKeys    = joinKeys
+	Redact      = redact
+)
+
+func (repo *Repository[T]) Diff(before, after map[string]any) map[string]Change {
+	return repo.diff(before, after)
+}
+
+func (repo *Repository[T]) NewAuditEntry(ctx context.Context, action, id string, before, after map[string]any) (auditEntry, bool, error) {
+	return repo.newAuditEntry(ctx, action, id, before, after)
+}
+
+func (repo *Repository[T]) Snapshot(ctx context.Context, where string, args map[string]any, lock bool) ([]map[string]any, error) {
+	return repo.snapshot(ctx, repo.writer(ctx), where, args, lock)
+}
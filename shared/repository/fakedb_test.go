@@ -0,0 +1,144 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+
+	"oil/infras/postgres"
+)
+
+// fakeDB is a database/sql connector that records the queries it runs. Each
+// query returns the next of rows, or no rows once they are used up, and every
+// statement reports affected rows.
+type fakeDB struct {
+	mu       sync.Mutex
+	queries  []string
+	rows     []fakeRows
+	affected int64
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+// newFakeConnection returns a connection backed by db and a TxManager on it.
+func newFakeConnection(t *testing.T, db *fakeDB) (*postgres.Connection, postgres.TxManager) {
+	t.Helper()
+
+	sqlDB := sqlx.NewDb(sql.OpenDB(db), "postgres")
+
+	t.Cleanup(func() {
+		assert.NoError(t, sqlDB.Close())
+	})
+
+	conn := &postgres.Connection{Read: sqlDB, Write: sqlDB}
+
+	return conn, postgres.NewTxManager(conn)
+}
+
+func (db *fakeDB) Connect(_ context.Context) (driver.Conn, error) {
+	return &fakeConn{db: db}, nil
+}
+
+func (db *fakeDB) Driver() driver.Driver {
+	return nil
+}
+
+func (db *fakeDB) run(query string) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.queries = append(db.queries, query)
+}
+
+func (db *fakeDB) query(query string) *fakeRows {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.queries = append(db.queries, query)
+
+	if len(db.rows) == 0 {
+		return &fakeRows{}
+	}
+
+	rows := db.rows[0]
+	db.rows = db.rows[1:]
+
+	return &rows
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	s.db.run(s.query)
+
+	return driver.RowsAffected(s.db.affected), nil
+}
+
+func (s *fakeStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return s.db.query(s.query), nil
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+
+	return nil
+}
@@ -14,36 +14,76 @@ import (
 	"reflect"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
 var (
 	errRequiredFilter = errors.New("required filter")
+	errNoSoftDelete   = errors.New("entity does not support soft delete")
+	errNoVersion      = errors.New("entity does not support versioning")
+)
+
+// Columns are hidden from query strings unless their field opts in with a query
+// tag: `query:"sort"` and `query:"filter"` allow sorting and filtering by the
+// column, and `query:"sort,filter"` allows both.
+const (
+	queryTag       = "query"
+	queryTagSort   = "sort"
+	queryTagFilter = "filter"
+)
+
+// Updates of versioned models bump the version unless they only write
+// bookkeeping columns tagged `version:"ignore"`, such as the last login of a
+// user, which would otherwise fail the next conditional write of a client.
+const (
+	versionTag       = "version"
+	versionTagIgnore = "ignore"
 )
 
 type column struct {
-	name  string
-	table string
-	alias string
+	name        string
+	table       string
+	alias       string
+	text        bool
+	nullable    bool
+	sortable    bool
+	filterable  bool
+	redact      bool
+	unversioned bool
+}
+
+// expr is the qualified column for use in queries.
+func (c column) expr() string {
+	return fmt.Sprintf("%s.%s", c.table, c.name)
 }
 
 type execer interface {
 	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
+	preparer
+}
+
+type preparer interface {
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
 }
 
 type Repository[T any] struct {
 	db            *postgres.Connection
+	tx            postgres.TxManager
 	otel          otel.Otel
 	table         string
 	entity        string
 	primaryColumn string
 	columns       []column
 	join          string
+	softDelete    bool
+	versioned     bool
+	audited       bool
 	InsertColumns []string
 }
 
-func NewRepository[T any](entityName, tableName, primaryColumn string, dbConnection *postgres.Connection, otl otel.Otel) Repository[T] {
+func NewRepository[T any](entityName, tableName, primaryColumn string, dbConnection *postgres.Connection, tx postgres.TxManager, otl otel.Otel) Repository[T] {
 	var zero T
 
 	reflectType := reflect.TypeOf(zero)
@@ -61,14 +101,25 @@ func NewRepository[T any](entityName, tableName, primaryColumn string, dbConnect
 		}
 	}
 
+	_, unaudited := any(zero).(Unaudited)
+
 	return Repository[T]{
 		db:            dbConnection,
+		tx:            tx,
 		otel:          otl,
 		table:         tableName,
 		entity:        entityName,
 		primaryColumn: primaryColumn,
 		columns:       columns,
 		join:          joinQueryStr,
+		// Models with a deleted_at column, usually by embedding
+		// model.SoftDelete, are soft deleted: Delete only marks their rows
+		// and reads skip marked rows.
+		softDelete: slices.Contains(insertColumns, constant.FieldDeletedAt),
+		// Models with a version column, usually by embedding model.Versioned,
+		// have it bumped on every update and can be written conditionally.
+		versioned:     slices.Contains(insertColumns, constant.FieldVersion),
+		audited:       !unaudited,
 		InsertColumns: insertColumns,
 	}
 }
@@ -94,6 +145,10 @@ func (repo *Repository[T]) insert(ctx context.Context, exec execer, model T) err
 		return fmt.Errorf("failed to insert data (%s): %w", repo.entity, err)
 	}
 
+	if repo.audited {
+		return repo.auditInsert(ctx, exec, model)
+	}
+
 	return nil
 }
 
@@ -101,7 +156,9 @@ func (repo *Repository[T]) Insert(ctx context.Context, model T) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Insert", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	return repo.insert(ctx, repo.db.Write, model) //nolint:wrapcheck
+	return repo.inTx(ctx, func(ctx context.Context) error {
+		return repo.insert(ctx, repo.writer(ctx), model)
+	})
 }
 
 func (repo *Repository[T]) InsertTx(ctx context.Context, sqltx *sqlx.Tx, model T) error {
@@ -115,17 +172,18 @@ func (repo *Repository[T]) Exist(ctx context.Context, filter dto.FilterGroup) (b
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Exist", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	where, args := repo.BuildWhereClause(ctx, filter)
-	if where == "" {
+	if !hasFilter(filter) {
 		return false, errRequiredFilter
 	}
 
+	where, args := repo.BuildWhereClause(ctx, filter)
+
 	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s %s)", repo.table, where)
 	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
 
 	exist := false
 
-	prepare, err := repo.db.Read.PrepareNamedContext(ctx, query)
+	prepare, err := repo.reader(ctx).PrepareNamedContext(ctx, query)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
@@ -158,7 +216,7 @@ func (repo *Repository[T]) Get(ctx context.Context, filter dto.FilterGroup, colu
 
 	var model T
 
-	prepare, err := repo.db.Read.PrepareNamedContext(ctx, query)
+	prepare, err := repo.reader(ctx).PrepareNamedContext(ctx, query)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
@@ -189,7 +247,7 @@ func (repo *Repository[T]) GetAll(ctx context.Context, params dto.QueryParams, f
 	where, args := repo.BuildWhereClause(ctx, filter)
 	selectQuery := repo.getSelectQuery(ctx, columns...)
 
-	var ordering, pagination string
+	var pagination string
 
 	page := params.Page
 	limit := params.Limit
@@ -205,17 +263,18 @@ func (repo *Repository[T]) GetAll(ctx context.Context, params dto.QueryParams, f
 		pagination = "LIMIT :limit"
 	}
 
-	if params.SortBy != "" && params.SortDir != "" {
-		ordering = fmt.Sprintf("ORDER BY %s %s", params.SortBy, params.SortDir)
+	var models []T
+
+	ordering, err := repo.orderBy(params)
+	if err != nil {
+		return models, err
 	}
 
 	query := fmt.Sprintf("SELECT %s FROM %s %s %s %s %s", selectQuery, repo.table, repo.join, where, ordering, pagination)
 
 	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
 
-	var models []T
-
-	prepare, err := repo.db.Read.PrepareNamedContext(ctx, query)
+	prepare, err := repo.reader(ctx).PrepareNamedContext(ctx, query)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
@@ -235,6 +294,148 @@ func (repo *Repository[T]) GetAll(ctx context.Context, params dto.QueryParams, f
 	return models, nil
 }
 
+// GetPage returns the rows after, or before, params.Cursor ordered by the sort
+// column with the primary column as tie-breaker, so that pages stay stable
+// while rows are inserted and each page costs an index range scan instead of
+// an OFFSET. The sort is taken from the cursor when there is one. Nullable
+// columns cannot be paged by: a row without a value has no place in the keyset
+// comparison, so they are only sorted by with page numbers.
+func (repo *Repository[T]) GetPage(ctx context.Context, params dto.QueryParams, filter dto.FilterGroup, columns ...string) ([]T, dto.Pagination, error) {
+	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.GetPage", constant.OtelRepositoryScopeName, repo.entity))
+	defer scope.End()
+
+	page := dto.Pagination{}
+
+	cursor := dto.Cursor{SortBy: constant.DefaultValueSortBy, SortDir: constant.DefaultValueSortDir}
+
+	switch sorts := params.Sorts(); len(sorts) {
+	case 0:
+	case 1:
+		cursor.SortBy, cursor.SortDir = sorts[0].Field, sorts[0].Dir
+	default:
+		return nil, page, fmt.Errorf("%w: cursor pagination sorts by a single field", dto.ErrInvalidQuery)
+	}
+
+	hasCursor := params.Cursor != ""
+	if hasCursor {
+		var err error
+
+		if cursor, err = dto.DecodeCursor(params.Cursor); err != nil {
+			return nil, page, err //nolint:wrapcheck
+		}
+	}
+
+	sortColumn, ok := repo.column(cursor.SortBy)
+	if !ok || !sortColumn.sortable {
+		return nil, page, fmt.Errorf("%w: cannot sort by %s", dto.ErrInvalidQuery, cursor.SortBy)
+	}
+
+	if sortColumn.nullable {
+		return nil, page, fmt.Errorf("%w: cursor pagination cannot sort by %s, which may be empty", dto.ErrInvalidQuery, cursor.SortBy)
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = constant.DefaultValueLimit
+	}
+
+	// Paging backwards walks the index in reverse and flips the rows afterwards.
+	direction := cursor.SortDir
+	if cursor.Before {
+		direction = reverseSortDir(direction)
+	}
+
+	sortExpr := sortColumn.expr()
+	primaryExpr := fmt.Sprintf("%s.%s", repo.table, repo.primaryColumn)
+
+	scoped := repo.notDeleted(filter)
+
+	where, args := scoped.GetWhereClause()
+	if where != "" {
+		where = fmt.Sprintf("(%s)", where)
+	}
+
+	if hasCursor {
+		operator := ">"
+		if direction == dto.SortDirDesc {
+			operator = "<"
+		}
+
+		keyset := fmt.Sprintf("(%s, %s) %s (:cursor_value, :cursor_id)", sortExpr, primaryExpr, operator)
+		args["cursor_value"] = cursor.Value
+		args["cursor_id"] = cursor.ID
+
+		if where == "" {
+			where = keyset
+		} else {
+			where = fmt.Sprintf("%s AND %s", where, keyset)
+		}
+	}
+
+	if where != "" {
+		where = fmt.Sprintf(" WHERE %s ", where)
+	}
+
+	// One extra row tells whether there is a page beyond this one.
+	args["limit"] = limit + 1
+
+	query := fmt.Sprintf("SELECT %s FROM %s %s %s ORDER BY %s %s, %s %s LIMIT :limit",
+		repo.getSelectQuery(ctx, columns...), repo.table, repo.join, where, sortExpr, direction, primaryExpr, direction)
+
+	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	models := []T{}
+
+	prepare, err := repo.reader(ctx).PrepareNamedContext(ctx, query)
+	if err != nil {
+		logger.ErrorWithStack(err)
+		scope.TraceError(err)
+
+		return models, page, fmt.Errorf("failed to prepare statement (%s): %w", repo.entity, err)
+	}
+	defer prepare.Close()
+
+	if err = prepare.SelectContext(ctx, &models, args); err != nil {
+		logger.ErrorWithStack(err)
+		scope.TraceError(err)
+
+		return models, page, fmt.Errorf("failed to get page (%s): %w", repo.entity, err)
+	}
+
+	hasMore := len(models) > limit
+	if hasMore {
+		models = models[:limit]
+	}
+
+	if cursor.Before {
+		slices.Reverse(models)
+	}
+
+	if len(models) == 0 {
+		return models, page, nil
+	}
+
+	// Coming from a cursor means there is a page on the side we came from.
+	hasNext, hasPrev := hasMore, hasCursor
+	if cursor.Before {
+		hasNext, hasPrev = hasCursor, hasMore
+	}
+
+	if hasNext {
+		if page.NextCursor, err = repo.encodeCursor(cursor, sortColumn, models[len(models)-1], false); err != nil {
+			return models, page, err
+		}
+	}
+
+	if hasPrev {
+		if page.PrevCursor, err = repo.encodeCursor(cursor, sortColumn, models[0], true); err != nil {
+			return models, page, err
+		}
+	}
+
+	return models, page, nil
+}
+
 func (repo *Repository[T]) Count(ctx context.Context, filter dto.FilterGroup) (int, error) {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Count", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
@@ -246,7 +447,7 @@ func (repo *Repository[T]) Count(ctx context.Context, filter dto.FilterGroup) (i
 
 	var count int
 
-	prepare, err := repo.db.Read.PrepareNamedContext(ctx, query)
+	prepare, err := repo.reader(ctx).PrepareNamedContext(ctx, query)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
@@ -266,44 +467,187 @@ func (repo *Repository[T]) Count(ctx context.Context, filter dto.FilterGroup) (i
 	return count, nil
 }
 
-func (repo *Repository[T]) delete(ctx context.Context, exec execer, filter dto.FilterGroup) error {
+func (repo *Repository[T]) delete(ctx context.Context, exec execer, filter dto.FilterGroup) (int64, error) {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.delete", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	where, args := repo.BuildWhereClause(ctx, filter)
-	if where == "" {
-		return errRequiredFilter
+	if !hasFilter(filter) {
+		return 0, errRequiredFilter
 	}
 
+	filter, err := repo.atVersion(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	where, args := repo.BuildWhereClause(ctx, filter)
+
 	query := fmt.Sprintf("DELETE FROM %s %s", repo.table, where)
+
+	// Soft-deletable rows are only marked. NOW() is the start of the
+	// transaction, so rows deleted in the same unit of work share their
+	// deleted_at and can be restored together.
+	if repo.softDelete {
+		userID, _ := ctx.Value(constant.ContextKeyUserID).(string)
+		args["soft_deleted_by"] = userID
+
+		query = fmt.Sprintf("UPDATE %s SET %s = NOW(), %s = :soft_deleted_by%s %s", repo.table, constant.FieldDeletedAt, constant.FieldDeletedBy, repo.bumpVersion(), where)
+	}
+
 	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
 
-	_, err := exec.NamedExecContext(ctx, query, args)
+	before, err := repo.auditSnapshot(ctx, exec, where, args)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := exec.NamedExecContext(ctx, query, args)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
 
-		return fmt.Errorf("failed to delete data (%s): %w", repo.entity, err)
+		return 0, fmt.Errorf("failed to delete data (%s): %w", repo.entity, err)
 	}
 
-	return nil
+	if err := repo.checkVersion(filter, result); err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted data (%s): %w", repo.entity, err)
+	}
+
+	return deleted, repo.auditWrite(ctx, exec, AuditActionDelete, before)
 }
 
 func (repo *Repository[T]) Delete(ctx context.Context, filter dto.FilterGroup) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Delete", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	return repo.delete(ctx, repo.db.Write, filter) //nolint:wrapcheck
+	return repo.inTx(ctx, func(ctx context.Context) error {
+		_, err := repo.delete(ctx, repo.writer(ctx), filter)
+
+		return err
+	})
+}
+
+// DeleteRows is Delete reporting how many rows it deleted. Of concurrent
+// deletes of a row only one counts it, so that callers can tell whether they
+// were the one to use it up.
+func (repo *Repository[T]) DeleteRows(ctx context.Context, filter dto.FilterGroup) (deleted int64, err error) {
+	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.DeleteRows", constant.OtelRepositoryScopeName, repo.entity))
+	defer scope.End()
+
+	err = repo.inTx(ctx, func(ctx context.Context) error {
+		deleted, err = repo.delete(ctx, repo.writer(ctx), filter)
+
+		return err
+	})
+
+	return deleted, err
 }
 
 func (repo *Repository[T]) DeleteTx(ctx context.Context, sqltx *sqlx.Tx, filter dto.FilterGroup) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.DeleteTx", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	return repo.delete(ctx, sqltx, filter) //nolint:wrapcheck
+	_, err := repo.delete(ctx, sqltx, filter)
+
+	return err
 }
 
-func (repo *Repository[T]) update(ctx context.Context, exec execer, mod map[string]any, filter dto.FilterGroup) error {
+// Restore clears the deletion mark of the deleted rows matching filter.
+func (repo *Repository[T]) Restore(ctx context.Context, filter dto.FilterGroup) error {
+	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Restore", constant.OtelRepositoryScopeName, repo.entity))
+	defer scope.End()
+
+	if !repo.softDelete {
+		return errNoSoftDelete
+	}
+
+	if !hasFilter(filter) {
+		return errRequiredFilter
+	}
+
+	deleted := dto.FilterGroup{
+		Operator: dto.FilterGroupOperatorAnd,
+		Filters: []any{
+			filter,
+			dto.Filter{Field: constant.FieldDeletedAt, Operator: dto.FilterIsNotNull, Table: repo.table},
+		},
+		WithDeleted: true,
+	}
+
+	where, args := repo.BuildWhereClause(ctx, deleted)
+
+	query := fmt.Sprintf("UPDATE %s SET %s = NULL, %s = NULL%s %s", repo.table, constant.FieldDeletedAt, constant.FieldDeletedBy, repo.bumpVersion(), where)
+	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	return repo.inTx(ctx, func(ctx context.Context) error {
+		exec := repo.writer(ctx)
+
+		before, err := repo.auditSnapshot(ctx, exec, where, args)
+		if err != nil {
+			return err
+		}
+
+		if _, err := exec.NamedExecContext(ctx, query, args); err != nil {
+			logger.ErrorWithStack(err)
+			scope.TraceError(err)
+
+			return fmt.Errorf("failed to restore data (%s): %w", repo.entity, err)
+		}
+
+		return repo.auditWrite(ctx, exec, AuditActionRestore, before)
+	})
+}
+
+// Purge permanently removes the rows soft deleted before the given time and
+// returns how many were removed. Purged rows are audited like deleted ones.
+func (repo *Repository[T]) Purge(ctx context.Context, before time.Time) (int64, error) {
+	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.Purge", constant.OtelRepositoryScopeName, repo.entity))
+	defer scope.End()
+
+	if !repo.softDelete {
+		return 0, errNoSoftDelete
+	}
+
+	where := fmt.Sprintf("WHERE %s < :before", constant.FieldDeletedAt)
+	args := map[string]any{"before": before}
+
+	query := fmt.Sprintf("DELETE FROM %s %s", repo.table, where)
+	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	var purged int64
+
+	err := repo.inTx(ctx, func(ctx context.Context) error {
+		exec := repo.writer(ctx)
+
+		rows, err := repo.auditSnapshot(ctx, exec, where, args)
+		if err != nil {
+			return err
+		}
+
+		result, err := exec.NamedExecContext(ctx, query, args)
+		if err != nil {
+			logger.ErrorWithStack(err)
+			scope.TraceError(err)
+
+			return fmt.Errorf("failed to purge data (%s): %w", repo.entity, err)
+		}
+
+		if purged, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to count purged data (%s): %w", repo.entity, err)
+		}
+
+		return repo.auditWrite(ctx, exec, AuditActionPurge, rows)
+	})
+
+	return purged, err
+}
+
+func (repo *Repository[T]) update(ctx context.Context, exec execer, mod map[string]any, filter dto.FilterGroup) (int64, error) {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".update")
 	defer scope.End()
 
@@ -313,43 +657,87 @@ func (repo *Repository[T]) update(ctx context.Context, exec execer, mod map[stri
 		updateField = append(updateField, fmt.Sprintf("%s = :%s", col, col))
 	}
 
+	filter, err := repo.atVersion(filter)
+	if err != nil {
+		return 0, err
+	}
+
 	where, args := repo.BuildWhereClause(ctx, filter)
-	updateQuery := strings.Join(updateField, ", ")
+	updateQuery := strings.Join(updateField, ", ") + repo.bumpVersion(slices.Collect(maps.Keys(mod))...)
 	query := fmt.Sprintf("UPDATE %s SET %s %s", repo.table, updateQuery, where)
 
 	scope.SetAttribute(constant.OtelQueryAttributeKey, query)
+
+	before, err := repo.auditSnapshot(ctx, exec, where, args)
+	if err != nil {
+		return 0, err
+	}
+
 	maps.Copy(args, mod)
 
-	_, err := exec.NamedExecContext(ctx, query, args)
+	result, err := exec.NamedExecContext(ctx, query, args)
 	if err != nil {
 		logger.ErrorWithStack(err)
 		scope.TraceError(err)
 
-		return fmt.Errorf("failed to update data (%s): %w", repo.entity, err)
+		return 0, fmt.Errorf("failed to update data (%s): %w", repo.entity, err)
 	}
 
-	return nil
+	if err := repo.checkVersion(filter, result); err != nil {
+		return 0, err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count updated data (%s): %w", repo.entity, err)
+	}
+
+	return updated, repo.auditWrite(ctx, exec, AuditActionUpdate, before)
 }
 
 func (repo *Repository[T]) Update(ctx context.Context, mod map[string]any, filter dto.FilterGroup) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".Update")
 	defer scope.End()
 
-	return repo.update(ctx, repo.db.Write, mod, filter) //nolint:wrapcheck
+	return repo.inTx(ctx, func(ctx context.Context) error {
+		_, err := repo.update(ctx, repo.writer(ctx), mod, filter)
+
+		return err
+	})
+}
+
+// UpdateRows is Update reporting how many rows it updated. Of concurrent
+// conditional updates of a row only the first one counts it, so that callers
+// can tell whether their condition still held.
+func (repo *Repository[T]) UpdateRows(ctx context.Context, mod map[string]any, filter dto.FilterGroup) (updated int64, err error) {
+	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".UpdateRows")
+	defer scope.End()
+
+	err = repo.inTx(ctx, func(ctx context.Context) error {
+		updated, err = repo.update(ctx, repo.writer(ctx), mod, filter)
+
+		return err
+	})
+
+	return updated, err
 }
 
 func (repo *Repository[T]) UpdateTx(ctx context.Context, sqltx *sqlx.Tx, mod map[string]any, filter dto.FilterGroup) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, constant.OtelRepositoryScopeName+".UpdateTx")
 	defer scope.End()
 
-	return repo.update(ctx, sqltx, mod, filter) //nolint:wrapcheck
+	_, err := repo.update(ctx, sqltx, mod, filter)
+
+	return err
 }
 
 func (repo *Repository[T]) InsertBulk(ctx context.Context, models []T) error {
 	ctx, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.InsertBulk", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	return repo.insertBulk(ctx, repo.db.Write, models)
+	return repo.inTx(ctx, func(ctx context.Context) error {
+		return repo.insertBulk(ctx, repo.writer(ctx), models)
+	})
 }
 
 func (repo *Repository[T]) InsertBulkTx(ctx context.Context, sqltx *sqlx.Tx, models []T) error {
@@ -382,9 +770,149 @@ func (repo *Repository[T]) insertBulk(ctx context.Context, exec execer, models [
 		return fmt.Errorf("failed to bulk insert order: %w", err)
 	}
 
+	if repo.audited {
+		return repo.auditInsert(ctx, exec, models...)
+	}
+
 	return nil
 }
 
+// writer returns the transaction of ctx when the call is part of a unit of work
+// started by postgres.TxManager, and the write connection otherwise.
+func (repo *Repository[T]) writer(ctx context.Context) execer {
+	if tx, ok := postgres.TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return repo.db.Write
+}
+
+// reader returns the transaction of ctx, so that a unit of work reads its own
+// writes, and the read connection otherwise.
+func (repo *Repository[T]) reader(ctx context.Context) preparer {
+	if tx, ok := postgres.TxFromContext(ctx); ok {
+		return tx
+	}
+
+	return repo.db.Read
+}
+
+// versions reports whether writing the named column bumps the version.
+func (repo *Repository[T]) versions(name string) bool {
+	col, ok := repo.column(name)
+
+	return !ok || !col.unversioned
+}
+
+// column finds a column by the name a row is scanned into.
+func (repo *Repository[T]) column(name string) (column, bool) {
+	for _, col := range repo.columns {
+		if col.alias == name || (col.alias == "" && col.name == name) {
+			return col, true
+		}
+	}
+
+	return column{}, false
+}
+
+// orderBy builds the ORDER BY clause of params from sortable columns only, so
+// that no request value reaches the query as is.
+func (repo *Repository[T]) orderBy(params dto.QueryParams) (string, error) {
+	terms := []string{}
+
+	for _, sort := range params.Sorts() {
+		col, ok := repo.column(sort.Field)
+		if !ok || !col.sortable {
+			return "", fmt.Errorf("%w: cannot sort by %s", dto.ErrInvalidQuery, sort.Field)
+		}
+
+		if sort.Dir != dto.SortDirAsc && sort.Dir != dto.SortDirDesc {
+			return "", fmt.Errorf("%w: invalid sort direction %s", dto.ErrInvalidQuery, sort.Dir)
+		}
+
+		terms = append(terms, col.expr()+" "+sort.Dir)
+	}
+
+	if len(terms) == 0 {
+		return "", nil
+	}
+
+	return "ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+// WithFilters adds the filters requested through the query string to filter.
+// Only filterable columns and plain comparison operators are accepted.
+func (repo *Repository[T]) WithFilters(filter dto.FilterGroup, params dto.QueryParams) (dto.FilterGroup, error) {
+	if len(params.Filters) == 0 {
+		return filter, nil
+	}
+
+	group := dto.FilterGroup{Operator: dto.FilterGroupOperatorAnd, Filters: []any{}, WithDeleted: filter.WithDeleted}
+	if len(filter.Filters) > 0 {
+		group.Filters = append(group.Filters, filter)
+	}
+
+	for i, queryFilter := range params.Filters {
+		col, ok := repo.column(queryFilter.Field)
+		if !ok || !col.filterable {
+			return filter, fmt.Errorf("%w: cannot filter by %s", dto.ErrInvalidQuery, queryFilter.Field)
+		}
+
+		condition := dto.Filter{
+			ArgName:  fmt.Sprintf("query_filter_%d", i),
+			Field:    col.name,
+			Operator: queryFilter.Operator,
+			Table:    col.table,
+		}
+
+		switch queryFilter.Operator {
+		case dto.FilterOperatorEq, dto.FilterOperatorNotEq,
+			dto.FilterOperatorLess, dto.FilterOperatorLessEq,
+			dto.FilterOperatorGreater, dto.FilterOperatorGreaterEq:
+			condition.Value = queryFilter.Value
+		case dto.FilterOperatorLike:
+			if !col.text {
+				return filter, fmt.Errorf("%w: %s does not support %s", dto.ErrInvalidQuery, queryFilter.Field, queryFilter.Operator)
+			}
+
+			condition.Value = queryFilter.Value
+		case dto.FilterOperatorIn:
+			condition.Value = strings.Split(queryFilter.Value, ",")
+		case dto.FilterIsNull, dto.FilterIsNotNull:
+		default:
+			return filter, fmt.Errorf("%w: unsupported filter operator %s", dto.ErrInvalidQuery, queryFilter.Operator)
+		}
+
+		group.Filters = append(group.Filters, condition)
+	}
+
+	return group, nil
+}
+
+// encodeCursor builds the cursor pointing at model.
+func (repo *Repository[T]) encodeCursor(cursor dto.Cursor, sortColumn column, model T, before bool) (string, error) {
+	dbName := sortColumn.name
+	if sortColumn.alias != "" {
+		dbName = sortColumn.alias
+	}
+
+	value, ok := fieldByTag(reflect.ValueOf(model), dbName)
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no value to page by", dto.ErrInvalidQuery, dbName)
+	}
+
+	id, ok := fieldByTag(reflect.ValueOf(model), repo.primaryColumn)
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no value to page by", dto.ErrInvalidQuery, repo.primaryColumn)
+	}
+
+	cursor.Value = value
+	cursor.ID = id
+	cursor.Before = before
+
+	return cursor.Encode() //nolint:wrapcheck
+}
+
 func (repo *Repository[T]) getSelectQuery(ctx context.Context, columnsParam ...string) string {
 	_, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.getSelectQuery", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
@@ -420,7 +948,9 @@ func (repo *Repository[T]) BuildWhereClause(ctx context.Context, filter dto.Filt
 	_, scope := repo.otel.NewScope(ctx, constant.OtelRepositoryScopeName, fmt.Sprintf("%s.%s.BuildWhereClause", constant.OtelRepositoryScopeName, repo.entity))
 	defer scope.End()
 
-	where, args := filter.GetWhereClause()
+	scoped := repo.notDeleted(filter)
+
+	where, args := scoped.GetWhereClause()
 
 	if where == "" {
 		return where, map[string]any{}
@@ -429,6 +959,84 @@ func (repo *Repository[T]) BuildWhereClause(ctx context.Context, filter dto.Filt
 	return fmt.Sprintf(" WHERE %s ", where), args
 }
 
+// notDeleted restricts filter to rows that are not soft deleted, unless the
+// model is not soft-deletable or the filter asks for deleted rows too.
+func (repo *Repository[T]) notDeleted(filter dto.FilterGroup) dto.FilterGroup {
+	if !repo.softDelete || filter.WithDeleted {
+		return filter
+	}
+
+	condition := dto.Filter{Field: constant.FieldDeletedAt, Operator: dto.FilterIsNull, Table: repo.table}
+
+	if len(filter.Filters) == 0 {
+		return dto.FilterGroup{Operator: dto.FilterGroupOperatorAnd, Filters: []any{condition}}
+	}
+
+	return dto.FilterGroup{Operator: dto.FilterGroupOperatorAnd, Filters: []any{filter, condition}}
+}
+
+// atVersion narrows filter down to the rows at the version it expects.
+func (repo *Repository[T]) atVersion(filter dto.FilterGroup) (dto.FilterGroup, error) {
+	if filter.Version == nil {
+		return filter, nil
+	}
+
+	if !repo.versioned {
+		return filter, errNoVersion
+	}
+
+	return dto.FilterGroup{
+		Operator: dto.FilterGroupOperatorAnd,
+		Filters: []any{
+			filter,
+			dto.Filter{ArgName: "expected_version", Field: constant.FieldVersion, Operator: dto.FilterOperatorEq, Value: *filter.Version, Table: repo.table},
+		},
+		WithDeleted: filter.WithDeleted,
+		Version:     filter.Version,
+	}, nil
+}
+
+// checkVersion fails a write conditioned on a version that matched no row.
+func (repo *Repository[T]) checkVersion(filter dto.FilterGroup, result sql.Result) error {
+	if filter.Version == nil {
+		return nil
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to count written data (%s): %w", repo.entity, err)
+	}
+
+	if affected == 0 {
+		return dto.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// bumpVersion is the SET clause, with its leading comma, increasing the version
+// of the written rows of versioned models. Writes of the given columns leave the
+// version as is when all of them are bookkeeping columns.
+func (repo *Repository[T]) bumpVersion(written ...string) string {
+	if !repo.versioned {
+		return ""
+	}
+
+	if len(written) > 0 && !slices.ContainsFunc(written, repo.versions) {
+		return ""
+	}
+
+	return fmt.Sprintf(", %s = %s + 1", constant.FieldVersion, constant.FieldVersion)
+}
+
+// hasFilter reports whether filter narrows a query down; writes and existence
+// checks refuse to run on a whole table.
+func hasFilter(filter dto.FilterGroup) bool {
+	where, _ := filter.GetWhereClause()
+
+	return where != ""
+}
+
 func getColumns(table string, reflectType reflect.Type) (columns []column, insertColumns []string) {
 	for i := range reflectType.NumField() {
 		field := reflectType.Field(i)
@@ -454,12 +1062,80 @@ func getColumns(table string, reflectType reflect.Type) (columns []column, inser
 			insertColumns = append(insertColumns, dbTag)
 		}
 
-		if colTag == "" {
-			columns = append(columns, column{name: dbTag, table: tableField})
-		} else {
-			columns = append(columns, column{name: colTag, table: tableField, alias: dbTag})
+		col := column{name: dbTag, table: tableField}
+		if colTag != "" {
+			col = column{name: colTag, table: tableField, alias: dbTag}
 		}
+
+		col.text = isText(field.Type)
+		col.nullable = field.Type.Kind() == reflect.Pointer
+		col.sortable, col.filterable = queryAccess(field.Tag.Get(queryTag))
+		col.redact = field.Tag.Get(auditTag) == auditTagRedact
+		col.unversioned = field.Tag.Get(versionTag) == versionTagIgnore
+
+		columns = append(columns, col)
 	}
 
 	return columns, insertColumns
 }
+
+// fieldByTag returns the value of the struct field with the given db tag,
+// looking into embedded structs. Nil pointers have no value.
+func fieldByTag(value reflect.Value, tag string) (any, bool) {
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if found, ok := fieldByTag(value.Field(i), tag); ok {
+				return found, true
+			}
+		}
+
+		if field.Tag.Get("db") != tag {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				return nil, false
+			}
+
+			fieldValue = fieldValue.Elem()
+		}
+
+		return fieldValue.Interface(), true
+	}
+
+	return nil, false
+}
+
+func reverseSortDir(direction string) string {
+	if direction == dto.SortDirAsc {
+		return dto.SortDirDesc
+	}
+
+	return dto.SortDirAsc
+}
+
+// queryAccess reads the query tag of a field.
+func queryAccess(tag string) (sortable, filterable bool) {
+	for option := range strings.SplitSeq(tag, ",") {
+		switch strings.TrimSpace(option) {
+		case queryTagSort:
+			sortable = true
+		case queryTagFilter:
+			filterable = true
+		}
+	}
+
+	return sortable, filterable
+}
+
+func isText(fieldType reflect.Type) bool {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	return fieldType.Kind() == reflect.String
+}
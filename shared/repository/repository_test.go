@@ -0,0 +1,331 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"oil/infras/otel/mocks"
+	"oil/shared/dto"
+	"oil/shared/model"
+	"oil/shared/repository"
+)
+
+type account struct {
+	ID       string  `db:"id"`
+	Email    string  `db:"email"    query:"sort,filter"`
+	Password string  `db:"password" audit:"redact"`
+	Age      int     `db:"age"      query:"filter"`
+	Nickname *string `db:"nickname" query:"sort"`
+}
+
+type deletableAccount struct {
+	ID string `db:"id"`
+	model.SoftDelete
+}
+
+func TestRepository_BuildWhereClause(t *testing.T) {
+	byID := dto.FilterGroup{Filters: []any{dto.Filter{Field: "id", Operator: dto.FilterOperatorEq, Value: "1", Table: "accounts"}}}
+	withDeleted := byID
+	withDeleted.WithDeleted = true
+
+	tests := []struct {
+		name      string
+		soft      bool
+		filter    dto.FilterGroup
+		wantWhere string
+	}{
+		{
+			name:      "model without soft delete",
+			filter:    byID,
+			wantWhere: " WHERE (accounts.id = :id) ",
+		},
+		{
+			name:      "deleted rows are excluded",
+			soft:      true,
+			filter:    byID,
+			wantWhere: " WHERE ((accounts.id = :id) AND accounts.deleted_at IS NULL) ",
+		},
+		{
+			name:      "deleted rows are excluded without a filter",
+			soft:      true,
+			wantWhere: " WHERE (accounts.deleted_at IS NULL) ",
+		},
+		{
+			name:      "deleted rows on request",
+			soft:      true,
+			filter:    withDeleted,
+			wantWhere: " WHERE (accounts.id = :id) ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var where string
+
+			if tt.soft {
+				repo := repository.NewRepository[deletableAccount]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+				where, _ = repo.BuildWhereClause(context.Background(), tt.filter)
+			} else {
+				repo := repository.NewRepository[account]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+				where, _ = repo.BuildWhereClause(context.Background(), tt.filter)
+			}
+
+			assert.Equal(t, tt.wantWhere, where)
+		})
+	}
+}
+
+func TestRepository_WithFilters(t *testing.T) {
+	repo := repository.NewRepository[account]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+
+	base := dto.FilterGroup{Filters: []any{dto.Filter{Field: "id", Operator: dto.FilterOperatorEq, Value: "1", Table: "accounts"}}}
+
+	tests := []struct {
+		name      string
+		filters   []dto.QueryFilter
+		wantWhere string
+		wantArgs  map[string]any
+		wantErr   bool
+	}{
+		{
+			name:      "no query filters",
+			wantWhere: "(accounts.id = :id)",
+			wantArgs:  map[string]any{"id": "1"},
+		},
+		{
+			name: "comparison and list operators",
+			filters: []dto.QueryFilter{
+				{Field: "age", Operator: dto.FilterOperatorGreaterEq, Value: "18"},
+				{Field: "email", Operator: dto.FilterOperatorIn, Value: "a@example.com,b@example.com"},
+			},
+			wantWhere: "((accounts.id = :id) AND accounts.age >= :query_filter_0 AND " +
+				"accounts.email IN (:query_filter_1_0, :query_filter_1_1) )",
+			wantArgs: map[string]any{
+				"id":               "1",
+				"query_filter_0":   "18",
+				"query_filter_1_0": "a@example.com",
+				"query_filter_1_1": "b@example.com",
+			},
+		},
+		{
+			name:    "field without a query tag",
+			filters: []dto.QueryFilter{{Field: "password", Operator: dto.FilterOperatorEq, Value: "secret"}},
+			wantErr: true,
+		},
+		{
+			name:    "sort-only field",
+			filters: []dto.QueryFilter{{Field: "nickname", Operator: dto.FilterOperatorEq, Value: "bob"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			filters: []dto.QueryFilter{{Field: "1=1; --", Operator: dto.FilterOperatorEq}},
+			wantErr: true,
+		},
+		{
+			name:    "like on a non-text field",
+			filters: []dto.QueryFilter{{Field: "age", Operator: dto.FilterOperatorLike, Value: "1"}},
+			wantErr: true,
+		},
+		{
+			name:    "raw query operator",
+			filters: []dto.QueryFilter{{Field: "email", Operator: dto.FilterPlainQuery, Value: "true"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := repo.WithFilters(base, dto.QueryParams{Filters: tt.filters})
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, dto.ErrInvalidQuery))
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			where, args := filter.GetWhereClause()
+			assert.Equal(t, tt.wantWhere, where)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
+
+func TestRepository_GetPage_NullableSort(t *testing.T) {
+	repo := repository.NewRepository[account]("account", "accounts", "id", nil, nil, mocks.NewOtel())
+
+	cursor, err := dto.Cursor{SortBy: "nickname", SortDir: dto.SortDirAsc, Value: "bob", ID: "1"}.Encode()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params dto.QueryParams
+	}{
+		{
+			name:   "first page",
+			params: dto.QueryParams{Limit: 10, Sort: []dto.Sort{{Field: "nickname", Dir: dto.SortDirAsc}}},
+		},
+		{
+			name:   "next page",
+			params: dto.QueryParams{Limit: 10, Cursor: cursor},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := repo.GetPage(context.Background(), tt.params, dto.FilterGroup{})
+			assert.ErrorIs(t, err, dto.ErrInvalidQuery)
+		})
+	}
+}
+
+type versionedAccount struct {
+	ID       string     `db:"id"`
+	Email    string     `db:"email"`
+	LastSeen *time.Time `db:"last_seen" version:"ignore"`
+	model.SoftDelete
+	model.Versioned
+}
+
+func (versionedAccount) Unaudited() {}
+
+func TestRepository_Version(t *testing.T) {
+	version := 3
+	byID := dto.FilterGroup{Filters: []any{dto.Filter{Field: "id", Operator: dto.FilterOperatorEq, Value: "1", Table: "accounts"}}}
+	atVersion := byID
+	atVersion.Version = &version
+
+	tests := []struct {
+		name          string
+		write         func(repo repository.Repository[versionedAccount]) error
+		affected      int64
+		wantErr       error
+		wantBump      bool
+		wantCondition bool
+	}{
+		{
+			name: "update bumps the version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Update(context.Background(), map[string]any{"email": "a@example.com"}, byID)
+			},
+			affected: 1,
+			wantBump: true,
+		},
+		{
+			name: "update of bookkeeping columns keeps the version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Update(context.Background(), map[string]any{"last_seen": time.Now()}, byID)
+			},
+			affected: 1,
+		},
+		{
+			name: "update at the current version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Update(context.Background(), map[string]any{"email": "a@example.com"}, atVersion)
+			},
+			affected:      1,
+			wantBump:      true,
+			wantCondition: true,
+		},
+		{
+			name: "update at an outdated version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Update(context.Background(), map[string]any{"email": "a@example.com"}, atVersion)
+			},
+			wantErr:       dto.ErrVersionMismatch,
+			wantBump:      true,
+			wantCondition: true,
+		},
+		{
+			name: "unconditional update of no row",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Update(context.Background(), map[string]any{"email": "a@example.com"}, byID)
+			},
+			wantBump: true,
+		},
+		{
+			name: "delete bumps the version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Delete(context.Background(), byID)
+			},
+			affected: 1,
+			wantBump: true,
+		},
+		{
+			name: "delete at an outdated version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Delete(context.Background(), atVersion)
+			},
+			wantErr:       dto.ErrVersionMismatch,
+			wantBump:      true,
+			wantCondition: true,
+		},
+		{
+			name: "restore bumps the version",
+			write: func(repo repository.Repository[versionedAccount]) error {
+				return repo.Restore(context.Background(), byID)
+			},
+			affected: 1,
+			wantBump: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{affected: tt.affected}
+			conn, tx := newFakeConnection(t, db)
+			repo := repository.NewRepository[versionedAccount]("account", "accounts", "id", conn, tx, mocks.NewOtel())
+
+			err := tt.write(repo)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			if assert.Len(t, db.queries, 1) {
+				assert.Equal(t, tt.wantBump, strings.Contains(db.queries[0], "version = version + 1"))
+				assert.Equal(t, tt.wantCondition, strings.Contains(db.queries[0], "accounts.version = $"))
+			}
+		})
+	}
+}
+
+func TestRepository_Version_Unversioned(t *testing.T) {
+	version := 3
+	filter := dto.FilterGroup{
+		Filters: []any{dto.Filter{Field: "id", Operator: dto.FilterOperatorEq, Value: "1", Table: "accounts"}},
+		Version: &version,
+	}
+
+	db := &fakeDB{affected: 1}
+	conn, tx := newFakeConnection(t, db)
+	repo := repository.NewRepository[account]("account", "accounts", "id", conn, tx, mocks.NewOtel())
+
+	assert.Error(t, repo.Update(context.Background(), map[string]any{"email": "a@example.com"}, filter))
+	assert.Error(t, repo.Delete(context.Background(), filter))
+	assert.Empty(t, db.queries)
+}
+
+func TestRepository_UpdateRows(t *testing.T) {
+	filter := dto.FilterGroup{
+		Filters: []any{
+			dto.Filter{Field: "id", Operator: dto.FilterOperatorEq, Value: "1", Table: "accounts"},
+			dto.Filter{ArgName: "min_age", Field: "age", Operator: dto.FilterOperatorLess, Value: 30, Table: "accounts"},
+		},
+		Operator: dto.FilterGroupOperatorAnd,
+	}
+
+	for _, affected := range []int64{0, 1} {
+		db := &fakeDB{affected: affected}
+		conn, tx := newFakeConnection(t, db)
+		repo := repository.NewRepository[account]("account", "accounts", "id", conn, tx, mocks.NewOtel())
+
+		updated, err := repo.UpdateRows(context.Background(), map[string]any{"age": 30}, filter)
+		assert.NoError(t, err)
+		assert.Equal(t, affected, updated)
+		assert.Contains(t, db.queries, "UPDATE accounts SET age = $1  WHERE (accounts.id = $2 AND accounts.age < $3) ")
+	}
+}
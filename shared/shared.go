@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"oil/config"
@@ -13,9 +14,11 @@ import (
 	"oil/shared/dto"
 	"oil/shared/timezone"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
@@ -86,6 +89,31 @@ func TransformFields(data interface{}, user string) map[string]any {
 	return updatedFields
 }
 
+// IsAdmin reports whether the authenticated user in ctx has an administrative role.
+func IsAdmin(ctx context.Context) bool {
+	role, _ := ctx.Value(constant.ContextKeyUserRole).(string)
+
+	return role == constant.RoleAdmin || role == constant.RoleSuperAdmin
+}
+
+// RequiresMFA reports whether users of role must pass multi-factor
+// authentication to use the API.
+func RequiresMFA(cfg *config.Config, role string) bool {
+	return slices.Contains(cfg.App.Auth.MFARequiredRoles, role)
+}
+
+// CanAccess reports whether the authenticated user in ctx may access a resource
+// created by owner. Admins may access every resource.
+func CanAccess(ctx context.Context, owner string) bool {
+	if IsAdmin(ctx) {
+		return true
+	}
+
+	user, _ := ctx.Value(constant.ContextKeyUserID).(string)
+
+	return user != "" && user == owner
+}
+
 func FilterByID(id, fieldID, table string) dto.FilterGroup {
 	return dto.FilterGroup{
 		Filters: []any{
@@ -149,6 +177,17 @@ func InvalidateCaches(ctx context.Context, cache cache.RedisCache, key string) {
 	}
 }
 
+// IsPqError reports whether err was raised by Postgres with the given error code,
+// e.g. constant.PqErrorCodeUniqueViolation.
+func IsPqError(err error, code string) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code) == code
+	}
+
+	return false
+}
+
 // GenerateUniqueFilename generates a unique filename with timestamp and original extension
 func GenerateUniqueFilename(originalFilename string) string {
 	timestamp := timezone.Now().Unix()
@@ -2,6 +2,9 @@ package shared_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"oil/config"
 	"oil/shared"
 	"oil/shared/cache/mocks"
 	"oil/shared/constant"
@@ -11,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"go.uber.org/mock/gomock"
 )
 
@@ -311,6 +315,77 @@ func TestTransformFieldsWithPointers(t *testing.T) {
 	}
 }
 
+func TestRequiresMFA(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.Auth.MFARequiredRoles = []string{constant.RoleAdmin, constant.RoleSuperAdmin}
+
+	for role, expected := range map[string]bool{
+		constant.RoleSuperAdmin: true,
+		constant.RoleAdmin:      true,
+		constant.RoleUser:       false,
+	} {
+		if result := shared.RequiresMFA(cfg, role); result != expected {
+			t.Errorf("role %s: expected %v, got %v", role, expected, result)
+		}
+	}
+
+	if shared.RequiresMFA(&config.Config{}, constant.RoleAdmin) {
+		t.Error("expected no role to require mfa without a policy")
+	}
+}
+
+func TestIsPqError(t *testing.T) {
+	unique := &pq.Error{Code: constant.PqErrorCodeUniqueViolation}
+
+	tests := []struct {
+		name     string
+		err      error
+		code     string
+		expected bool
+	}{
+		{name: "matching code", err: unique, code: constant.PqErrorCodeUniqueViolation, expected: true},
+		{name: "wrapped error", err: fmt.Errorf("failed to insert: %w", unique), code: constant.PqErrorCodeUniqueViolation, expected: true},
+		{name: "other code", err: unique, code: constant.PqErrorCodeExclusionViolation, expected: false},
+		{name: "not a postgres error", err: errors.New("failed"), code: constant.PqErrorCodeUniqueViolation, expected: false},
+		{name: "no error", code: constant.PqErrorCodeUniqueViolation, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := shared.IsPqError(tt.err, tt.code); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestCanAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   string
+		role     string
+		owner    string
+		expected bool
+	}{
+		{name: "owner", userID: "user-1", role: constant.RoleUser, owner: "user-1", expected: true},
+		{name: "other user", userID: "user-2", role: constant.RoleUser, owner: "user-1", expected: false},
+		{name: "admin", userID: "admin-1", role: constant.RoleAdmin, owner: "user-1", expected: true},
+		{name: "superadmin", userID: "admin-1", role: constant.RoleSuperAdmin, owner: "user-1", expected: true},
+		{name: "anonymous", owner: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), constant.ContextKeyUserID, tt.userID)
+			ctx = context.WithValue(ctx, constant.ContextKeyUserRole, tt.role)
+
+			if result := shared.CanAccess(ctx, tt.owner); result != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestFilterByID(t *testing.T) {
 	tests := []struct {
 		name     string
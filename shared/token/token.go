@@ -0,0 +1,31 @@
+// Package token generates opaque secrets that are handed to clients once and
+// stored only as a hash.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// DefaultLength is the number of random bytes in a generated token.
+const DefaultLength = 32
+
+// Generate returns a URL-safe random token made of length random bytes.
+func Generate(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Hash returns the hex encoded SHA-256 digest of a token, suitable for lookups.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
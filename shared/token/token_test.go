@@ -0,0 +1,42 @@
+package token_test
+
+import (
+	"oil/shared/token"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	first, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := token.Generate(token.DefaultLength)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if first == second {
+		t.Error("expected generated tokens to differ")
+	}
+
+	if len(first) != 43 {
+		t.Errorf("expected token length 43, got %d", len(first))
+	}
+}
+
+func TestHash(t *testing.T) {
+	hash := token.Hash("secret")
+
+	if hash != token.Hash("secret") {
+		t.Error("expected hash to be deterministic")
+	}
+
+	if hash == token.Hash("other") {
+		t.Error("expected different tokens to have different hashes")
+	}
+
+	if len(hash) != 64 {
+		t.Errorf("expected hash length 64, got %d", len(hash))
+	}
+}
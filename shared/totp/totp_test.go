@@ -0,0 +1,102 @@
+package totp_test
+
+import (
+	"oil/shared/totp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 secret of the RFC 6238 test vectors, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totp.Code(rfcSecret, totp.Step(time.Unix(tt.unix, 0)))
+
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, code)
+	}
+
+	_, err := totp.Code("not base32!", 1)
+	assert.ErrorIs(t, err, totp.ErrInvalidSecret)
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := totp.Step(now)
+
+	code, err := totp.Code(rfcSecret, step)
+	assert.NoError(t, err)
+
+	previous, err := totp.Code(rfcSecret, step-1)
+	assert.NoError(t, err)
+
+	stale, err := totp.Code(rfcSecret, step-2)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		code    string
+		after   int64
+		want    int64
+		wantErr bool
+	}{
+		{name: "current step", code: code, want: step},
+		{name: "spaced code", code: code[:3] + " " + code[3:], want: step},
+		{name: "previous step within skew", code: previous, want: step - 1},
+		{name: "step outside skew", code: stale, wantErr: true},
+		{name: "code already used", code: code, after: step, wantErr: true},
+		{name: "wrong length", code: "12345", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := totp.Validate(rfcSecret, tt.code, now, tt.after)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, totp.ErrInvalidCode)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	second, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.Len(t, first, 32)
+
+	_, err = totp.Code(first, 1)
+	assert.NoError(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("oil", "test@example.com", rfcSecret)
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/oil:test@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=oil")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
@@ -0,0 +1,459 @@
+// Package consumer runs Kafka consumers on top of kafka.Client.
+//
+// Messages of a partition are handled one at a time, in order, while
+// partitions are handled concurrently. Fetching never waits on a partition,
+// so a slow partition does not hold back the others. An offset is only committed once its
+// message was handled or parked on the dead-letter topic, so a crash leads to
+// redelivery rather than loss: handlers must be idempotent.
+package consumer
+
+//go:generate go run go.uber.org/mock/mockgen -source=./consumer.go -destination=./mocks/consumer_mock.go -package=mocks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"oil/config"
+	"oil/infras/kafka"
+	"oil/shared/timezone"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	kafkaGo "github.com/segmentio/kafka-go"
+)
+
+const (
+	defaultMaxAttempts      = 5
+	defaultRetryBackoff     = 500 * time.Millisecond
+	defaultDeadLetterSuffix = ".dlq"
+
+	maxRetryBackoff = time.Minute
+)
+
+// Handler processes a single message. Returning an error retries the message;
+// errors wrapped with Permanent skip the retries.
+type Handler func(ctx context.Context, message kafkaGo.Message) error
+
+// Subscription binds a handler to a topic. Group defaults to the configured
+// consumer group.
+type Subscription struct {
+	Name    string
+	Topic   string
+	Group   string
+	Handler Handler
+}
+
+// Registry lists the subscriptions to run.
+type Registry interface {
+	Subscriptions() []Subscription
+}
+
+// Reader is the part of *kafkaGo.Reader used by the runtime.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafkaGo.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafkaGo.Message) error
+	Close() error
+}
+
+// ReaderFactory opens a reader for a consumer group and topic.
+type ReaderFactory func(group, topic string) Reader
+
+// NewReaderFactory opens readers through the Kafka client.
+func NewReaderFactory(client kafka.Client) ReaderFactory {
+	return func(group, topic string) Reader {
+		reader := client.Reader(group, topic)
+		if reader == nil {
+			return nil
+		}
+
+		return reader
+	}
+}
+
+// DeadLetter is published to the dead-letter topic for a message that could
+// not be handled. Value holds the original message value as is.
+type DeadLetter struct {
+	Topic     string    `json:"topic"`
+	Partition int       `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as not worth retrying, e.g. for a malformed message.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Typed decodes the message value into T before calling handle. Messages that
+// cannot be decoded are permanent failures.
+func Typed[T any](handle func(ctx context.Context, key string, value T) error) Handler {
+	return func(ctx context.Context, message kafkaGo.Message) error {
+		decoded, err := kafka.DecodeKafkaMessage[T](message)
+		if err != nil {
+			return Permanent(err)
+		}
+
+		value, _ := decoded.Value.(T)
+
+		return handle(ctx, decoded.Key, value)
+	}
+}
+
+type Runtime struct {
+	client   kafka.Client
+	cfg      *config.Config
+	readers  ReaderFactory
+	registry Registry
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(client kafka.Client, cfg *config.Config, readers ReaderFactory, registry Registry) *Runtime {
+	return &Runtime{
+		client:   client,
+		cfg:      cfg,
+		readers:  readers,
+		registry: registry,
+	}
+}
+
+// Start runs every subscription with a topic until Shutdown is called.
+func (r *Runtime) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	for _, subscription := range r.registry.Subscriptions() {
+		if subscription.Topic == "" {
+			log.Warn().Str("consumer", subscription.Name).Msg("Consumer has no topic configured, skipping")
+
+			continue
+		}
+
+		group := subscription.Group
+		if group == "" {
+			group = r.cfg.Kafka.ConsumerGroup
+		}
+
+		reader := r.readers(group, subscription.Topic)
+		if reader == nil {
+			log.Error().Str("consumer", subscription.Name).Msg("Failed to create Kafka reader")
+
+			continue
+		}
+
+		r.wg.Add(1)
+
+		go func() {
+			defer r.wg.Done()
+
+			r.consume(ctx, subscription, reader)
+		}()
+
+		log.Info().Str("consumer", subscription.Name).Str("topic", subscription.Topic).Str("group", group).Msg("Consumer started")
+	}
+}
+
+// Shutdown stops fetching new messages and waits until the messages being
+// handled are committed or ctx expires. Messages fetched but not yet handled
+// are left uncommitted and are redelivered to the next consumer.
+func (r *Runtime) Shutdown(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Info().Msg("Consumers stopped")
+
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop consumers: %w", ctx.Err())
+	}
+}
+
+// consume fetches messages and queues each for the worker of its partition.
+func (r *Runtime) consume(ctx context.Context, subscription Subscription, reader Reader) {
+	partitions := map[int]*partitionQueue{}
+
+	var workers sync.WaitGroup
+
+	defer func() {
+		for _, queue := range partitions {
+			queue.close()
+		}
+
+		workers.Wait()
+
+		if err := reader.Close(); err != nil {
+			log.Error().Err(err).Str("consumer", subscription.Name).Msg("Failed to close Kafka reader")
+		}
+	}()
+
+	for {
+		message, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			log.Error().Err(err).Str("consumer", subscription.Name).Msg("Failed to fetch message from Kafka")
+
+			if !sleep(ctx, r.retryBackoff()) {
+				return
+			}
+
+			continue
+		}
+
+		queue, ok := partitions[message.Partition]
+		if !ok {
+			queue = newPartitionQueue()
+			partitions[message.Partition] = queue
+
+			workers.Add(1)
+
+			go func() {
+				defer workers.Done()
+
+				for {
+					message, ok := queue.pop()
+					// Leave what is queued once shutting down.
+					if !ok || ctx.Err() != nil {
+						return
+					}
+
+					r.process(ctx, subscription, reader, message)
+				}
+			}()
+		}
+
+		queue.push(message)
+	}
+}
+
+// partitionQueue holds the fetched messages of a partition until its worker
+// handles them. Pushing never blocks, so a partition whose handler is slow
+// only grows its own queue instead of stalling the fetch loop.
+type partitionQueue struct {
+	mu       sync.Mutex
+	messages []kafkaGo.Message
+	closed   bool
+	ready    chan struct{}
+}
+
+func newPartitionQueue() *partitionQueue {
+	return &partitionQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *partitionQueue) push(message kafkaGo.Message) {
+	q.mu.Lock()
+	q.messages = append(q.messages, message)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+func (q *partitionQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// pop waits for the next message and reports false once the queue is closed.
+func (q *partitionQueue) pop() (kafkaGo.Message, bool) {
+	for {
+		q.mu.Lock()
+
+		if q.closed {
+			q.mu.Unlock()
+
+			return kafkaGo.Message{}, false
+		}
+
+		if len(q.messages) > 0 {
+			message := q.messages[0]
+			q.messages[0] = kafkaGo.Message{}
+			q.messages = q.messages[1:]
+			q.mu.Unlock()
+
+			return message, true
+		}
+
+		q.mu.Unlock()
+
+		<-q.ready
+	}
+}
+
+func (q *partitionQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// process handles a message with retries, parks it on the dead-letter topic
+// when it keeps failing and commits its offset. Handlers and commits run
+// without ctx cancellation so that a message in progress is finished during
+// shutdown; ctx only interrupts the waits between retries.
+func (r *Runtime) process(ctx context.Context, subscription Subscription, reader Reader, message kafkaGo.Message) {
+	handlerCtx := context.WithoutCancel(ctx)
+	logger := log.With().Str("consumer", subscription.Name).Int("partition", message.Partition).Int64("offset", message.Offset).Logger()
+
+	attempts, err := r.handle(ctx, handlerCtx, subscription, message)
+	if err != nil && ctx.Err() != nil {
+		// Interrupted while waiting to retry; leave it for redelivery.
+		return
+	}
+
+	if err != nil {
+		logger.Error().Err(err).Int("attempts", attempts).Msg("Failed to handle message, sending it to the dead-letter topic")
+
+		for {
+			dlqErr := r.deadLetter(handlerCtx, subscription, message, err, attempts)
+			if dlqErr == nil {
+				break
+			}
+
+			logger.Error().Err(dlqErr).Msg("Failed to publish message to the dead-letter topic")
+
+			if !sleep(ctx, r.retryBackoff()) {
+				return
+			}
+		}
+	}
+
+	if err := reader.CommitMessages(handlerCtx, message); err != nil {
+		logger.Error().Err(err).Msg("Failed to commit message offset")
+	}
+}
+
+// handle calls the handler until it succeeds, fails permanently or runs out of attempts.
+func (r *Runtime) handle(ctx, handlerCtx context.Context, subscription Subscription, message kafkaGo.Message) (int, error) {
+	var err error
+
+	for attempt := 1; attempt <= r.maxAttempts(); attempt++ {
+		if attempt > 1 && !sleep(ctx, r.backoff(attempt-1)) {
+			return attempt - 1, err
+		}
+
+		if err = safeHandle(handlerCtx, subscription.Handler, message); err == nil {
+			return attempt, nil
+		}
+
+		if errors.As(err, &permanentError{}) {
+			return attempt, err
+		}
+
+		log.Warn().Err(err).Str("consumer", subscription.Name).Int("attempt", attempt).Msg("Failed to handle message, retrying")
+	}
+
+	return r.maxAttempts(), err
+}
+
+func (r *Runtime) deadLetter(ctx context.Context, subscription Subscription, message kafkaGo.Message, cause error, attempts int) error {
+	topic := subscription.Topic + r.deadLetterSuffix()
+
+	deadLetter := DeadLetter{
+		Topic:     message.Topic,
+		Partition: message.Partition,
+		Offset:    message.Offset,
+		Key:       string(message.Key),
+		Value:     string(message.Value),
+		Error:     cause.Error(),
+		Attempts:  attempts,
+		FailedAt:  timezone.Now(),
+	}
+
+	return r.client.SendMessages(ctx, topic, kafka.Message{Key: string(message.Key), Value: deadLetter}) //nolint:wrapcheck
+}
+
+// backoff doubles the configured delay for every failed attempt.
+func (r *Runtime) backoff(failures int) time.Duration {
+	delay := r.retryBackoff()
+
+	for range failures - 1 {
+		delay *= 2
+		if delay >= maxRetryBackoff {
+			return maxRetryBackoff
+		}
+	}
+
+	return delay
+}
+
+func (r *Runtime) retryBackoff() time.Duration {
+	if ms := r.cfg.Kafka.Consumer.RetryBackoffMs; ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+
+	return defaultRetryBackoff
+}
+
+func (r *Runtime) maxAttempts() int {
+	if r.cfg.Kafka.Consumer.MaxAttempts > 0 {
+		return r.cfg.Kafka.Consumer.MaxAttempts
+	}
+
+	return defaultMaxAttempts
+}
+
+func (r *Runtime) deadLetterSuffix() string {
+	if r.cfg.Kafka.Consumer.DeadLetterSuffix != "" {
+		return r.cfg.Kafka.Consumer.DeadLetterSuffix
+	}
+
+	return defaultDeadLetterSuffix
+}
+
+// safeHandle turns a handler panic into an error.
+func safeHandle(ctx context.Context, handler Handler, message kafkaGo.Message) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+
+	return handler(ctx, message)
+}
+
+// sleep waits for delay and reports false when ctx is cancelled first.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
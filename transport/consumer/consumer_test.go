@@ -0,0 +1,268 @@
+package consumer_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	kafkaGo "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/kafka"
+	kafkaMocks "oil/infras/kafka/mocks"
+	"oil/transport/consumer"
+	"oil/transport/consumer/mocks"
+)
+
+type payload struct {
+	Name string `json:"name"`
+}
+
+type registry []consumer.Subscription
+
+func (r registry) Subscriptions() []consumer.Subscription {
+	return r
+}
+
+func TestRuntime(t *testing.T) {
+	message := kafkaGo.Message{Topic: "events", Partition: 1, Offset: 42, Key: []byte("key"), Value: []byte(`{"name":"booking"}`)}
+
+	tests := []struct {
+		name         string
+		message      kafkaGo.Message
+		handler      func(calls *atomic.Int32) consumer.Handler
+		wantCalls    int32
+		wantDLQ      bool
+		wantAttempts int
+	}{
+		{
+			name:    "typed handler succeeds",
+			message: message,
+			handler: func(calls *atomic.Int32) consumer.Handler {
+				return consumer.Typed(func(_ context.Context, key string, value payload) error {
+					calls.Add(1)
+					assert.Equal(t, "key", key)
+					assert.Equal(t, "booking", value.Name)
+
+					return nil
+				})
+			},
+			wantCalls: 1,
+		},
+		{
+			name:    "transient failure is retried",
+			message: message,
+			handler: func(calls *atomic.Int32) consumer.Handler {
+				return func(_ context.Context, _ kafkaGo.Message) error {
+					if calls.Add(1) == 1 {
+						return errors.New("temporary failure")
+					}
+
+					return nil
+				}
+			},
+			wantCalls: 2,
+		},
+		{
+			name:    "exhausted retries go to the dead-letter topic",
+			message: message,
+			handler: func(calls *atomic.Int32) consumer.Handler {
+				return func(_ context.Context, _ kafkaGo.Message) error {
+					calls.Add(1)
+
+					return errors.New("still failing")
+				}
+			},
+			wantCalls:    3,
+			wantDLQ:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:    "panic is handled as a failure",
+			message: message,
+			handler: func(calls *atomic.Int32) consumer.Handler {
+				return func(_ context.Context, _ kafkaGo.Message) error {
+					calls.Add(1)
+					panic("boom")
+				}
+			},
+			wantCalls:    3,
+			wantDLQ:      true,
+			wantAttempts: 3,
+		},
+		{
+			name:    "undecodable message is not retried",
+			message: kafkaGo.Message{Topic: "events", Partition: 0, Offset: 7, Key: []byte("key"), Value: []byte("not json")},
+			handler: func(calls *atomic.Int32) consumer.Handler {
+				return consumer.Typed(func(_ context.Context, _ string, _ payload) error {
+					calls.Add(1)
+
+					return nil
+				})
+			},
+			wantCalls:    0,
+			wantDLQ:      true,
+			wantAttempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockReader := mocks.NewMockReader(ctrl)
+			mockKafka := kafkaMocks.NewMockClient(ctrl)
+
+			cfg := &config.Config{}
+			cfg.Kafka.ConsumerGroup = "group"
+			cfg.Kafka.Consumer.MaxAttempts = 3
+			cfg.Kafka.Consumer.RetryBackoffMs = 1
+
+			committed := make(chan struct{})
+			fetched := false
+
+			mockReader.EXPECT().
+				FetchMessage(gomock.Any()).
+				DoAndReturn(func(ctx context.Context) (kafkaGo.Message, error) {
+					if !fetched {
+						fetched = true
+
+						return tt.message, nil
+					}
+
+					<-ctx.Done()
+
+					return kafkaGo.Message{}, ctx.Err()
+				}).
+				AnyTimes()
+			mockReader.EXPECT().
+				CommitMessages(gomock.Any(), tt.message).
+				DoAndReturn(func(_ context.Context, _ ...kafkaGo.Message) error {
+					close(committed)
+
+					return nil
+				})
+			mockReader.EXPECT().Close().Return(nil)
+
+			if tt.wantDLQ {
+				mockKafka.EXPECT().
+					SendMessages(gomock.Any(), "events.dlq", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, messages ...kafka.Message) error {
+						deadLetter, ok := messages[0].Value.(consumer.DeadLetter)
+						assert.True(t, ok)
+						assert.Equal(t, tt.message.Offset, deadLetter.Offset)
+						assert.Equal(t, string(tt.message.Value), deadLetter.Value)
+						assert.Equal(t, tt.wantAttempts, deadLetter.Attempts)
+
+						return nil
+					})
+			}
+
+			calls := &atomic.Int32{}
+			subscriptions := registry{{Name: "test", Topic: "events", Handler: tt.handler(calls)}}
+
+			readers := func(group, topic string) consumer.Reader {
+				assert.Equal(t, "group", group)
+				assert.Equal(t, "events", topic)
+
+				return mockReader
+			}
+
+			runtime := consumer.New(mockKafka, cfg, readers, subscriptions)
+			runtime.Start(context.Background())
+
+			select {
+			case <-committed:
+			case <-time.After(time.Second):
+				t.Fatal("message was not committed")
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			assert.NoError(t, runtime.Shutdown(ctx))
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestRuntime_SlowPartitionDoesNotBlockOthers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReader := mocks.NewMockReader(ctrl)
+	mockKafka := kafkaMocks.NewMockClient(ctrl)
+
+	cfg := &config.Config{}
+	cfg.Kafka.ConsumerGroup = "group"
+
+	// More messages of the slow partition than any fixed buffer would hold,
+	// followed by a single message of another partition.
+	messages := []kafkaGo.Message{}
+	for offset := range int64(200) {
+		messages = append(messages, kafkaGo.Message{Topic: "events", Partition: 0, Offset: offset})
+	}
+
+	fast := kafkaGo.Message{Topic: "events", Partition: 1, Offset: 0}
+	messages = append(messages, fast)
+
+	fetched := 0
+
+	mockReader.EXPECT().
+		FetchMessage(gomock.Any()).
+		DoAndReturn(func(ctx context.Context) (kafkaGo.Message, error) {
+			if fetched < len(messages) {
+				fetched++
+
+				return messages[fetched-1], nil
+			}
+
+			<-ctx.Done()
+
+			return kafkaGo.Message{}, ctx.Err()
+		}).
+		AnyTimes()
+	mockReader.EXPECT().CommitMessages(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockReader.EXPECT().Close().Return(nil)
+
+	release := make(chan struct{})
+	handled := make(chan struct{})
+
+	handler := func(_ context.Context, message kafkaGo.Message) error {
+		if message.Partition == 0 {
+			<-release
+
+			return nil
+		}
+
+		close(handled)
+
+		return nil
+	}
+
+	subscriptions := registry{{Name: "test", Topic: "events", Handler: handler}}
+	readers := func(_, _ string) consumer.Reader {
+		return mockReader
+	}
+
+	runtime := consumer.New(mockKafka, cfg, readers, subscriptions)
+	runtime.Start(context.Background())
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("message of the other partition was not handled")
+	}
+
+	close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	assert.NoError(t, runtime.Shutdown(ctx))
+}
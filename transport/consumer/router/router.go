@@ -0,0 +1,27 @@
+package router
+
+import (
+	"oil/internal/consumers/booking"
+	"oil/transport/consumer"
+)
+
+type DomainConsumers struct {
+	Booking booking.Consumer
+}
+
+type Router struct {
+	DomainConsumers DomainConsumers
+}
+
+func (r *Router) Subscriptions() []consumer.Subscription {
+	subscriptions := []consumer.Subscription{}
+	subscriptions = append(subscriptions, r.DomainConsumers.Booking.Subscriptions()...)
+
+	return subscriptions
+}
+
+func New(domainConsumers DomainConsumers) *Router {
+	return &Router{
+		DomainConsumers: domainConsumers,
+	}
+}
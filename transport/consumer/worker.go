@@ -0,0 +1,47 @@
+package consumer
+
+import (
+	"context"
+	"oil/config"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Worker runs the consumers as a standalone process, see cmd/worker.
+type Worker struct {
+	Config  *config.Config
+	Runtime *Runtime
+}
+
+func NewWorker(cfg *config.Config, runtime *Runtime) *Worker {
+	return &Worker{
+		Config:  cfg,
+		Runtime: runtime,
+	}
+}
+
+// Run starts the consumers and blocks until SIGINT or SIGTERM. Messages in
+// progress get the cleanup period to finish before the process exits.
+func (w *Worker) Run() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w.Runtime.Start(context.Background())
+
+	<-ctx.Done()
+
+	cleanupPeriod := time.Duration(w.Config.Server.Shutdown.CleanupPeriodSeconds) * time.Second
+
+	log.Info().Dur("timeout", cleanupPeriod).Msg("Received SIGTERM. Stopping consumers.")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cleanupPeriod)
+	defer cancel()
+
+	if err := w.Runtime.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Consumers did not stop in time")
+	}
+}
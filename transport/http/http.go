@@ -1,14 +1,19 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"oil/config"
 	"oil/docs"
+	"oil/infras/jwt"
 	"oil/infras/postgres"
+	outboxService "oil/internal/domains/outbox/service"
+	retentionService "oil/internal/domains/retention/service"
 	"oil/shared/constant"
 	"oil/shared/logger"
+	"oil/transport/consumer"
 	httpMiddleware "oil/transport/http/middleware"
 	"oil/transport/http/response"
 	"oil/transport/http/router"
@@ -48,20 +53,30 @@ type HTTP struct {
 	DB             *postgres.Connection
 	appMiddleware  httpMiddleware.AppMiddleware
 	authMiddleware httpMiddleware.AuthRole
+	outboxRelay    outboxService.Relay
+	purger         retentionService.Purger
+	jwt            jwt.JWT
+	consumers      *consumer.Runtime
+	stopWorkers    context.CancelFunc
 }
 
-func New(cfg *config.Config, r router.Router, db *postgres.Connection, appMiddleware httpMiddleware.AppMiddleware, authMiddleware httpMiddleware.AuthRole) *HTTP {
+func New(cfg *config.Config, r router.Router, db *postgres.Connection, appMiddleware httpMiddleware.AppMiddleware, authMiddleware httpMiddleware.AuthRole, outboxRelay outboxService.Relay, purger retentionService.Purger, jwt jwt.JWT, consumers *consumer.Runtime) *HTTP {
 	return &HTTP{
 		Config:         cfg,
 		Router:         r,
 		DB:             db,
 		appMiddleware:  appMiddleware,
 		authMiddleware: authMiddleware,
+		outboxRelay:    outboxRelay,
+		purger:         purger,
+		jwt:            jwt,
+		consumers:      consumers,
 	}
 }
 
 func (h *HTTP) Serve() {
 	h.setup()
+	h.startWorkers()
 
 	log.Info().Str("port", h.Config.Server.Port).Msg("Starting up HTTP server.")
 
@@ -116,7 +131,7 @@ func (h *HTTP) setupMiddlewares() {
 
 func (h *HTTP) setupIdentity() {
 	h.mux.Use(middleware.RequestID)
-	h.mux.Use(middleware.RealIP)
+	h.mux.Use(h.appMiddleware.Client)
 }
 
 func (h *HTTP) setupRecover() {
@@ -204,6 +219,40 @@ func (h *HTTP) setupGracefulShutdown() {
 	go h.respondToSigterm(serverStateCh)
 }
 
+// startWorkers runs the background workers until the server enters its
+// cleanup period. Consumers only run here when enabled; otherwise they run in
+// cmd/worker.
+func (h *HTTP) startWorkers() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.stopWorkers = cancel
+
+	go h.outboxRelay.Run(ctx)
+	go h.purger.Run(ctx)
+	go h.jwt.RotateKeys(ctx)
+
+	if h.Config.Kafka.Consumer.Enable {
+		h.consumers.Start(ctx)
+	}
+}
+
+// shutdownWorkers stops the background workers. Consumers keep running during
+// the grace period like the HTTP server does, and get the cleanup period to
+// finish the messages they are handling.
+func (h *HTTP) shutdownWorkers(timeout time.Duration) {
+	if h.stopWorkers == nil {
+		return
+	}
+
+	h.stopWorkers()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := h.consumers.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Consumers did not stop in time")
+	}
+}
+
 func (h *HTTP) respondToSigterm(done chan os.Signal) {
 	<-done
 
@@ -228,6 +277,8 @@ func (h *HTTP) respondToSigterm(done chan os.Signal) {
 
 	h.State = ServerStateInCleanupPeriod
 
+	go h.shutdownWorkers(time.Duration(shutdownConfig.CleanupPeriodSeconds) * time.Second)
+
 	time.Sleep(time.Duration(shutdownConfig.CleanupPeriodSeconds) * time.Second)
 
 	log.Info().Msg("Cleaning up completed. Shutting down now.")
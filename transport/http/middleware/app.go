@@ -1,13 +1,19 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"net/http"
+	"net/netip"
 	"oil/config"
 	"oil/infras/otel"
 	"oil/shared/cache"
+	"oil/shared/constant"
+	"strings"
+
+	"github.com/rs/zerolog/log"
 )
 
 const (
@@ -17,20 +23,61 @@ const (
 type AppMiddleware interface {
 	Tracing(http.Handler) http.Handler
 	RateLimit() func(http.Handler) http.Handler
+	Client(http.Handler) http.Handler
 }
 
 type appMiddleware struct {
-	otel   otel.Otel
-	config *config.Config
-	cache  cache.RedisCache
+	otel           otel.Otel
+	config         *config.Config
+	cache          cache.RedisCache
+	trustedProxies []netip.Prefix
 }
 
 func NewAppMiddleware(otel otel.Otel, config *config.Config, cache cache.RedisCache) AppMiddleware {
+	trustedProxies, err := parseTrustedProxies(config.App.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Strs("trusted_proxies", config.App.TrustedProxies).Msg("failed to parse trusted proxies")
+	}
+
 	return &appMiddleware{
-		otel:   otel,
-		config: config,
-		cache:  cache,
+		otel:           otel,
+		config:         config,
+		cache:          cache,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// parseTrustedProxies parses proxies given as addresses or CIDR ranges
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+
+		if !strings.Contains(proxy, "/") {
+			addr, err := netip.ParseAddr(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+			}
+
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
 	}
+
+	return prefixes, nil
 }
 
 func (a *appMiddleware) Tracing(next http.Handler) http.Handler {
@@ -64,3 +111,14 @@ func (a *appMiddleware) Tracing(next http.Handler) http.Handler {
 		})
 	})
 }
+
+// Client puts the user agent and IP address of the caller in the context, for
+// the services that record where a request came from.
+func (a *appMiddleware) Client(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx := context.WithValue(request.Context(), constant.ContextKeyUserAgent, a.getUA(request))
+		ctx = context.WithValue(ctx, constant.ContextKeyClientIP, a.getClientIP(request))
+
+		next.ServeHTTP(writer, request.WithContext(ctx))
+	})
+}
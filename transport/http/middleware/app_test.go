@@ -0,0 +1,95 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"oil/config"
+	"oil/infras/otel/mocks"
+	"oil/shared/constant"
+	"oil/transport/http/middleware"
+)
+
+func TestAppMiddleware_Client(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.App.TrustedProxies = []string{"10.0.0.0/8", "::1"}
+
+	client := middleware.NewAppMiddleware(mocks.NewOtel(), cfg, nil).Client
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string][]string
+		wantIP     string
+	}{
+		{
+			name:       "direct caller",
+			remoteAddr: "203.0.113.7:51234",
+			wantIP:     "203.0.113.7",
+		},
+		{
+			name:       "direct caller forging the forwarding headers",
+			remoteAddr: "203.0.113.7:51234",
+			headers: map[string][]string{
+				constant.RequestHeaderForwardedFor: {"198.51.100.1"},
+				constant.RequestHeaderRealIP:       {"198.51.100.2"},
+			},
+			wantIP: "203.0.113.7",
+		},
+		{
+			name:       "caller behind a trusted proxy",
+			remoteAddr: "10.0.0.5:443",
+			headers:    map[string][]string{constant.RequestHeaderForwardedFor: {"203.0.113.7"}},
+			wantIP:     "203.0.113.7",
+		},
+		{
+			name:       "caller behind a trusted proxy forging the forwarded addresses",
+			remoteAddr: "10.0.0.5:443",
+			headers:    map[string][]string{constant.RequestHeaderForwardedFor: {"198.51.100.1, 203.0.113.7"}},
+			wantIP:     "203.0.113.7",
+		},
+		{
+			name:       "caller behind a chain of trusted proxies",
+			remoteAddr: "[::1]:443",
+			headers:    map[string][]string{constant.RequestHeaderForwardedFor: {"198.51.100.1, 203.0.113.7", "10.0.0.9"}},
+			wantIP:     "203.0.113.7",
+		},
+		{
+			name:       "caller behind a trusted proxy setting x-real-ip",
+			remoteAddr: "10.0.0.5:443",
+			headers:    map[string][]string{constant.RequestHeaderRealIP: {"203.0.113.7"}},
+			wantIP:     "203.0.113.7",
+		},
+		{
+			name:       "trusted proxy without forwarding headers",
+			remoteAddr: "10.0.0.5:443",
+			wantIP:     "10.0.0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIP string
+
+			handler := client(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				gotIP, _ = r.Context().Value(constant.ContextKeyClientIP).(string)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/rooms", nil)
+			req.RemoteAddr = tt.remoteAddr
+
+			for name, values := range tt.headers {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.wantIP, gotIP)
+		})
+	}
+}
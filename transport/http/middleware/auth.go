@@ -8,6 +8,7 @@ import (
 	"oil/infras/jwt"
 	"oil/infras/otel"
 	"oil/permissions"
+	"oil/shared"
 	"oil/shared/constant"
 	"oil/shared/failure"
 	"oil/transport/http/response"
@@ -126,6 +127,8 @@ func (m *authRoleImpl) Auth(next http.Handler) http.Handler {
 			switch {
 			case errors.Is(err, jwt.ErrExpiredToken):
 				message = "Token has expired"
+			case errors.Is(err, jwt.ErrRevokedToken):
+				message = "Token has been revoked"
 			case errors.Is(err, jwt.ErrInvalidToken):
 				message = "Invalid token"
 			case errors.Is(err, jwt.ErrInvalidClaim):
@@ -168,10 +171,21 @@ func (m *authRoleImpl) Auth(next http.Handler) http.Handler {
 			return
 		}
 
+		if shared.RequiresMFA(m.cfg, claims.Role) && !claims.MFA && !m.mfaExempt(path, method) {
+			err := failure.Forbidden("multi-factor authentication is required for your role")
+			response.WithError(writer, err)
+
+			scope.TraceError(err)
+			scope.End()
+
+			return
+		}
+
 		ctx = context.WithValue(ctx, constant.ContextKeyUserID, claims.UserID)
 		ctx = context.WithValue(ctx, constant.ContextKeyUserEmail, claims.Email)
 		ctx = context.WithValue(ctx, constant.ContextKeyUserRole, claims.Role)
 		ctx = context.WithValue(ctx, constant.ContextKeyTokenID, claims.TokenID)
+		ctx = context.WithValue(ctx, constant.ContextKeySessionID, claims.SessionID)
 
 		scope.End()
 
@@ -179,6 +193,12 @@ func (m *authRoleImpl) Auth(next http.Handler) http.Handler {
 	})
 }
 
+// mfaExempt reports whether an endpoint may be called without multi-factor
+// authentication by roles that require it.
+func (m *authRoleImpl) mfaExempt(path, method string) bool {
+	return m.permission != nil && m.permission.FindPermissions(path, method).MFAExempt
+}
+
 // RBAC checks if user has required role
 // Requires prior authentication via Auth middleware
 func (m *authRoleImpl) RBAC(next http.Handler) http.Handler {
@@ -0,0 +1,94 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"oil/config"
+	"oil/infras/jwt"
+	jwtMocks "oil/infras/jwt/mocks"
+	"oil/infras/otel/mocks"
+	"oil/permissions"
+	"oil/shared/constant"
+	"oil/transport/http/middleware"
+)
+
+func TestAuthRole_Auth_MFA(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockJWT := jwtMocks.NewMockJWT(ctrl)
+
+	cfg := &config.Config{}
+	cfg.App.Auth.MFARequiredRoles = []string{constant.RoleAdmin}
+
+	permission := &permissions.PermissionData{
+		Endpoints: []permissions.Permission{
+			{Path: "/v1/auth/mfa/setup", Method: http.MethodPost, MFAExempt: true},
+			{Path: "/v1/rooms", Method: http.MethodGet},
+		},
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.NewAuthRoleMiddleware(mockJWT, mocks.NewOtel(), permission, cfg).Auth)
+
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Post("/v1/auth/mfa/setup", ok)
+	router.Get("/v1/rooms", ok)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		claims   *jwt.Claims
+		wantCode int
+	}{
+		{
+			name:     "role requiring mfa without it",
+			method:   http.MethodGet,
+			path:     "/v1/rooms",
+			claims:   &jwt.Claims{UserID: "user-1", Email: "admin@example.com", Role: constant.RoleAdmin},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "role requiring mfa without it on an exempt endpoint",
+			method:   http.MethodPost,
+			path:     "/v1/auth/mfa/setup",
+			claims:   &jwt.Claims{UserID: "user-1", Email: "admin@example.com", Role: constant.RoleAdmin},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "role requiring mfa with it",
+			method:   http.MethodGet,
+			path:     "/v1/rooms",
+			claims:   &jwt.Claims{UserID: "user-1", Email: "admin@example.com", Role: constant.RoleAdmin, MFA: true},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "role not requiring mfa",
+			method:   http.MethodGet,
+			path:     "/v1/rooms",
+			claims:   &jwt.Claims{UserID: "user-2", Email: "user@example.com", Role: constant.RoleUser},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockJWT.EXPECT().ValidateToken(gomock.Any(), "access-token", jwt.AccessToken).Return(tt.claims, nil)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(constant.RequestHeaderAuthorization, "Bearer access-token")
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"errors"
 	"net/http"
+	"net/netip"
 	"oil/shared"
 	"oil/shared/cache"
 	"oil/shared/constant"
@@ -79,22 +80,53 @@ func (a *appMiddleware) getUA(r *http.Request) string {
 	return ua
 }
 
+// getClientIP returns the address of the caller. The forwarding headers can be
+// set by anyone, so they are only believed when the request comes from a
+// trusted proxy. Each proxy appends the address it was called from to
+// X-Forwarded-For, which makes the caller the last address in it that is not
+// a trusted proxy; the addresses before it could be made up.
 func (a *appMiddleware) getClientIP(r *http.Request) string {
-	// Check for X-Forwarded-For header first (most common proxy header)
-	if xff := r.Header.Get(constant.RequestHeaderForwardedFor); xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		if commaIdx := strings.Index(xff, ","); commaIdx > 0 {
-			return strings.TrimSpace(xff[:commaIdx])
+	remote, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	client := remote.Addr().Unmap()
+	if !a.isTrustedProxy(client) {
+		return client.String()
+	}
+
+	if xff := r.Header.Values(constant.RequestHeaderForwardedFor); len(xff) > 0 {
+		forwarded := strings.Split(strings.Join(xff, ","), ",")
+
+		for i := len(forwarded) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+			if err != nil {
+				break
+			}
+
+			client = addr.Unmap()
+			if !a.isTrustedProxy(client) {
+				break
+			}
 		}
 
-		return strings.TrimSpace(xff)
+		return client.String()
+	}
+
+	if xri, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(constant.RequestHeaderRealIP))); err == nil {
+		return xri.Unmap().String()
 	}
 
-	// Check for X-Real-IP header
-	if xri := r.Header.Get(constant.RequestHeaderRealIP); xri != "" {
-		return strings.TrimSpace(xri)
+	return client.String()
+}
+
+func (a *appMiddleware) isTrustedProxy(addr netip.Addr) bool {
+	for _, prefix := range a.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
 	}
 
-	// Fall back to RemoteAddr
-	return r.RemoteAddr
+	return false
 }
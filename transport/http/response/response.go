@@ -30,6 +30,21 @@ func WithJSON(writer http.ResponseWriter, code int, jsonPayload interface{}) {
 	response(writer, code, Data[any]{Data: &jsonPayload})
 }
 
+// WithContent sends a raw response body with the given content type
+func WithContent(writer http.ResponseWriter, code int, contentType string, body []byte) {
+	writer.Header().Set(constant.RequestHeaderContentType, contentType)
+	writer.WriteHeader(code)
+
+	if _, err := writer.Write(body); err != nil {
+		logger.ErrorWithStack(err)
+	}
+}
+
+// WithNotModified sends an empty response for when the client's copy is current
+func WithNotModified(writer http.ResponseWriter) {
+	writer.WriteHeader(http.StatusNotModified)
+}
+
 // WithError sends a response with an error message
 func WithError(writer http.ResponseWriter, err error) {
 	code := failure.GetCode(err)
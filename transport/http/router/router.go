@@ -1,8 +1,10 @@
 package router
 
 import (
+	"oil/internal/handlers/audit"
 	"oil/internal/handlers/auth"
 	"oil/internal/handlers/booking"
+	"oil/internal/handlers/outbox"
 	"oil/internal/handlers/room"
 	"oil/internal/handlers/user"
 
@@ -14,6 +16,8 @@ type DomainHandlers struct {
 	Room    room.Handler
 	Booking booking.Handler
 	User    user.Handler
+	Outbox  outbox.Handler
+	Audit   audit.Handler
 }
 
 type Router struct {
@@ -21,11 +25,15 @@ type Router struct {
 }
 
 func (r *Router) SetupRoutes(router chi.Router) {
+	r.DomainHandlers.Auth.WellKnownRouter(router)
+
 	router.Route("/v1", func(routerGroup chi.Router) {
 		r.DomainHandlers.Auth.Router(routerGroup)
 		r.DomainHandlers.Room.Router(routerGroup)
 		r.DomainHandlers.Booking.Router(routerGroup)
 		r.DomainHandlers.User.Router(routerGroup)
+		r.DomainHandlers.Outbox.Router(routerGroup)
+		r.DomainHandlers.Audit.Router(routerGroup)
 	})
 }
 